@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Archive formats recognised by detectArchive
+const (
+	ArchiveTar  = "tar"
+	ArchiveZip  = "zip"
+	ArchiveCpio = "cpio"
+	ArchiveRPM  = "rpm"
+)
+
+// ArchiveSeparator joins an archive path and a member name in result keys,
+// e.g. kernel.rpm!./lib/modules/foo.ko
+const ArchiveSeparator = "!"
+
+// maxArchiveDepth bounds archives nested inside archives
+const maxArchiveDepth = 4
+
+// Archive layout constants
+const (
+	tarMagicOffset   = 257
+	cpioHeaderSize   = 110
+	cpioTrailer      = "TRAILER!!!"
+	rpmLeadSize      = 96
+	rpmHeaderMaxTags = 1 << 16
+	rpmHeaderMaxData = 256 << 20
+)
+
+// Magic bytes of the archive formats
+var (
+	magicZip       = []byte("PK\x03\x04")
+	magicZipEmpty  = []byte("PK\x05\x06")
+	magicTar       = []byte("ustar")
+	magicCpioNewc  = []byte("070701")
+	magicCpioCRC   = []byte("070702")
+	magicRPMLead   = []byte{0xED, 0xAB, 0xEE, 0xDB}
+	magicRPMHeader = []byte{0x8E, 0xAD, 0xE8, 0x01}
+)
+
+// memberFunc receives the name and content of each regular archive member
+type memberFunc func(name string, data []byte) error
+
+// detectArchive identifies an archive by its magic bytes
+func detectArchive(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, magicZip), bytes.HasPrefix(data, magicZipEmpty):
+		return ArchiveZip
+	case bytes.HasPrefix(data, magicRPMLead):
+		return ArchiveRPM
+	case bytes.HasPrefix(data, magicCpioNewc), bytes.HasPrefix(data, magicCpioCRC):
+		return ArchiveCpio
+	case len(data) > tarMagicOffset+len(magicTar) && bytes.Equal(data[tarMagicOffset:tarMagicOffset+len(magicTar)], magicTar):
+		return ArchiveTar
+	}
+	return ""
+}
+
+// walkArchive calls fn for every regular file in an archive, reading at most
+// max bytes per member and max bytes in total
+func walkArchive(format string, data []byte, max int64, fn memberFunc) error {
+	budget := &extractBudget{remaining: max}
+	switch format {
+	case ArchiveTar:
+		return walkTar(data, budget, fn)
+	case ArchiveZip:
+		return walkZip(data, budget, fn)
+	case ArchiveCpio:
+		return walkCpio(data, budget, fn)
+	case ArchiveRPM:
+		return walkRPM(data, max, budget, fn)
+	}
+	return fmt.Errorf("unsupported archive format %q", format)
+}
+
+// extractBudget tracks how many bytes an archive may still expand to
+type extractBudget struct {
+	remaining int64
+}
+
+// read extracts one member, charging it against the budget
+func (eb *extractBudget) read(r io.Reader) ([]byte, error) {
+	data, err := readLimited(r, eb.remaining)
+	if err != nil {
+		return nil, err
+	}
+	eb.remaining -= int64(len(data))
+	return data, nil
+}
+
+// walkTar walks a tar archive
+func walkTar(data []byte, budget *extractBudget, fn memberFunc) error {
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := budget.read(tr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+		if err := fn(header.Name, content); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip walks a zip archive (including JAR and APK files)
+func walkZip(data []byte, budget *extractBudget, fn memberFunc) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		content, err := budget.read(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		if err := fn(file.Name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkCpio walks a cpio archive in the SVR4 "newc" format (with or without
+// checksums), as used by RPM payloads and initramfs images
+func walkCpio(data []byte, budget *extractBudget, fn memberFunc) error {
+	offset := 0
+	for {
+		if offset+cpioHeaderSize > len(data) {
+			return fmt.Errorf("invalid cpio archive: truncated header at offset %d", offset)
+		}
+		header := data[offset : offset+cpioHeaderSize]
+		if !bytes.HasPrefix(header, magicCpioNewc) && !bytes.HasPrefix(header, magicCpioCRC) {
+			return fmt.Errorf("invalid cpio archive: bad magic at offset %d", offset)
+		}
+
+		// Fields after the magic are 8 hex digits each
+		field := func(index int) (int, error) {
+			start := len(magicCpioNewc) + index*8
+			value, err := strconv.ParseUint(string(header[start:start+8]), 16, 32)
+			return int(value), err
+		}
+		mode, errMode := field(1)
+		fileSize, errSize := field(6)
+		nameSize, errName := field(11)
+		if err := errors.Join(errMode, errSize, errName); err != nil {
+			return fmt.Errorf("invalid cpio archive: bad header at offset %d: %w", offset, err)
+		}
+
+		nameStart := offset + cpioHeaderSize
+		dataStart := alignUp(nameStart+nameSize, 4)
+		dataEnd := dataStart + fileSize
+		if nameSize == 0 || nameStart+nameSize > len(data) || dataEnd > len(data) {
+			return fmt.Errorf("invalid cpio archive: entry at offset %d exceeds archive", offset)
+		}
+		name := string(bytes.TrimRight(data[nameStart:nameStart+nameSize], "\x00"))
+		if name == cpioTrailer {
+			return nil
+		}
+
+		if mode&0o170000 == 0o100000 {
+			content, err := budget.read(bytes.NewReader(data[dataStart:dataEnd]))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if err := fn(name, content); err != nil {
+				return err
+			}
+		}
+		offset = alignUp(dataEnd, 4)
+	}
+}
+
+// walkRPM skips the RPM lead, signature header and main header, then
+// decompresses the payload and walks it as cpio
+func walkRPM(data []byte, max int64, budget *extractBudget, fn memberFunc) error {
+	if len(data) < rpmLeadSize {
+		return errors.New("invalid RPM: truncated lead")
+	}
+	offset := rpmLeadSize
+
+	// The signature header is padded to a multiple of 8 bytes; the main header is not
+	signatureSize, err := rpmHeaderSize(data, offset)
+	if err != nil {
+		return fmt.Errorf("invalid RPM signature header: %w", err)
+	}
+	offset = alignUp(offset+signatureSize, 8)
+
+	headerSize, err := rpmHeaderSize(data, offset)
+	if err != nil {
+		return fmt.Errorf("invalid RPM header: %w", err)
+	}
+	offset += headerSize
+
+	payload, _, err := decompressLayers(data[offset:], max)
+	if err != nil {
+		return fmt.Errorf("RPM payload: %w", err)
+	}
+	if detectArchive(payload) != ArchiveCpio {
+		return errors.New("RPM payload is not a cpio archive")
+	}
+	return walkCpio(payload, budget, fn)
+}
+
+// rpmHeaderSize returns the size of the header structure at offset: 16 bytes
+// of magic and counts, 16 bytes per index entry and the data store
+func rpmHeaderSize(data []byte, offset int) (int, error) {
+	if offset+16 > len(data) {
+		return 0, errors.New("truncated")
+	}
+	if !bytes.HasPrefix(data[offset:], magicRPMHeader) {
+		return 0, fmt.Errorf("bad magic at offset %d", offset)
+	}
+	tags := binary.BigEndian.Uint32(data[offset+8:])
+	dataSize := binary.BigEndian.Uint32(data[offset+12:])
+	if tags > rpmHeaderMaxTags || dataSize > rpmHeaderMaxData {
+		return 0, fmt.Errorf("implausible header (%d tags, %d data bytes)", tags, dataSize)
+	}
+	size := 16 + int(tags)*16 + int(dataSize)
+	if offset+size > len(data) {
+		return 0, errors.New("truncated")
+	}
+	return size, nil
+}
+
+// alignUp rounds n up to a multiple of align
+func alignUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
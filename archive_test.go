@@ -0,0 +1,236 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+// archiveMember is a file placed into test archives
+type archiveMember struct {
+	name string
+	data []byte
+}
+
+var archiveMembers = []archiveMember{
+	{"boot/a.efi", []byte("first member")},
+	{"README", []byte("second member")},
+}
+
+func buildTar(t *testing.T, members []archiveMember) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "boot/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, m := range members {
+		if err := tw.WriteHeader(&tar.Header{Name: m.name, Mode: 0o644, Size: int64(len(m.data)), Format: tar.FormatUSTAR}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		tw.Write(m.data)
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, members []archiveMember) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatalf("Failed to create zip member: %v", err)
+		}
+		w.Write(m.data)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// buildCpio writes a newc archive with a directory entry, the members and the trailer
+func buildCpio(members []archiveMember) []byte {
+	var buf bytes.Buffer
+	entry := func(ino int, name string, mode int, data []byte) {
+		fmt.Fprintf(&buf, "070701%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X",
+			ino, mode, 0, 0, 1, 0, len(data), 0, 0, 0, 0, len(name)+1, 0)
+		buf.WriteString(name + "\x00")
+		buf.Write(make([]byte, alignUp(buf.Len(), 4)-buf.Len()))
+		buf.Write(data)
+		buf.Write(make([]byte, alignUp(buf.Len(), 4)-buf.Len()))
+	}
+	entry(1, "boot", 0o40755, nil)
+	for i, m := range members {
+		entry(i+2, m.name, 0o100644, m.data)
+	}
+	entry(0, cpioTrailer, 0, nil)
+	return buf.Bytes()
+}
+
+// buildRPM wraps a gzipped cpio payload in a lead, a padded signature header and a main header
+func buildRPM(members []archiveMember) []byte {
+	var payload bytes.Buffer
+	gw := gzip.NewWriter(&payload)
+	gw.Write(buildCpio(members))
+	gw.Close()
+	return buildRPMWithPayload(payload.Bytes())
+}
+
+// buildRPMXz is buildRPM with an xz payload, the default of current rpmbuild
+// releases besides zstd
+func buildRPMXz(t *testing.T, members []archiveMember) []byte {
+	var payload bytes.Buffer
+	xw, err := xz.NewWriter(&payload)
+	if err != nil {
+		t.Fatalf("Failed to create xz writer: %v", err)
+	}
+	xw.Write(buildCpio(members))
+	xw.Close()
+	return buildRPMWithPayload(payload.Bytes())
+}
+
+// buildRPMWithPayload prepends the lead and headers to a compressed payload
+func buildRPMWithPayload(payload []byte) []byte {
+	header := func(tag uint32, store []byte) []byte {
+		h := append([]byte{}, magicRPMHeader...)
+		h = append(h, 0, 0, 0, 0)
+		h = binary.BigEndian.AppendUint32(h, 1)
+		h = binary.BigEndian.AppendUint32(h, uint32(len(store)))
+		for _, v := range []uint32{tag, 7, 0, uint32(len(store))} {
+			h = binary.BigEndian.AppendUint32(h, v)
+		}
+		return append(h, store...)
+	}
+
+	rpm := append(append([]byte{}, magicRPMLead...), make([]byte, rpmLeadSize-len(magicRPMLead))...)
+	rpm = append(rpm, header(62, []byte("abc"))...)
+	rpm = append(rpm, make([]byte, alignUp(len(rpm), 8)-len(rpm))...)
+	rpm = append(rpm, header(1000, []byte("name\x00"))...)
+	return append(rpm, payload...)
+}
+
+// TestWalkArchive tests member traversal for every archive format
+func TestWalkArchive(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"tar", buildTar(t, archiveMembers), ArchiveTar},
+		{"zip", buildZip(t, archiveMembers), ArchiveZip},
+		{"cpio", buildCpio(archiveMembers), ArchiveCpio},
+		{"rpm", buildRPM(archiveMembers), ArchiveRPM},
+		{"rpm xz", buildRPMXz(t, archiveMembers), ArchiveRPM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if format := detectArchive(tt.data); format != tt.format {
+				t.Fatalf("Expected %s, got %q", tt.format, format)
+			}
+
+			var seen []archiveMember
+			err := walkArchive(tt.format, tt.data, DefaultMaxInputSize, func(name string, data []byte) error {
+				seen = append(seen, archiveMember{name, data})
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(seen) != len(archiveMembers) {
+				t.Fatalf("Expected %d members, got %d", len(archiveMembers), len(seen))
+			}
+			for i, m := range archiveMembers {
+				if seen[i].name != m.name || !bytes.Equal(seen[i].data, m.data) {
+					t.Errorf("Expected member %s %q, got %s %q", m.name, m.data, seen[i].name, seen[i].data)
+				}
+			}
+
+			if err := walkArchive(tt.format, tt.data, 16, func(string, []byte) error { return nil }); err == nil {
+				t.Errorf("Expected extraction budget to be enforced")
+			}
+		})
+	}
+}
+
+// TestWalkArchiveMalformed tests that damaged archives report errors instead of panicking
+func TestWalkArchiveMalformed(t *testing.T) {
+	cpio := buildCpio(archiveMembers)
+	rpm := buildRPM(archiveMembers)
+
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{"Truncated cpio", ArchiveCpio, cpio[:150]},
+		{"cpio without trailer", ArchiveCpio, cpio[:len(cpio)-cpioHeaderSize-12]},
+		{"Bad cpio mode", ArchiveCpio, append(append(append([]byte{}, cpio[:14]...), "zzzzzzzz"...), cpio[22:]...)},
+		{"RPM lead only", ArchiveRPM, rpm[:rpmLeadSize]},
+		{"RPM bad header magic", ArchiveRPM, append(append([]byte{}, rpm[:rpmLeadSize]...), make([]byte, 64)...)},
+		{"Zip garbage", ArchiveZip, []byte("PK\x03\x04garbage")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := walkArchive(tt.format, tt.data, DefaultMaxInputSize, func(string, []byte) error { return nil })
+			if err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+// TestBatchArchiveMembers tests archive!member keys, nesting and member globs
+func TestBatchArchiveMembers(t *testing.T) {
+	signed, err := os.ReadFile("testfiles/good/bootx64.efi")
+	if err != nil {
+		t.Skip("testfiles/good/bootx64.efi not available")
+	}
+
+	inner := buildTar(t, []archiveMember{{"signed.efi", signed}, {"notes.txt", []byte("plain text")}})
+	outer := buildZip(t, []archiveMember{{"bundle.tar", inner}, {"loose.txt", []byte("more text")}})
+
+	fh := FileHandler{Loader: MemoryLoader{"release.zip": outer}}
+	var buf bytes.Buffer
+	summary := Batch{Handler: fh, Workers: 2, Include: []string{"*.efi"}}.Run(&buf, []batchItem{{Path: "release.zip"}})
+
+	if summary.Total != 1 || summary.Signed != 1 {
+		t.Errorf("Expected only the nested .efi to be analyzed, got %+v\n%s", summary, buf.String())
+	}
+	if !strings.Contains(buf.String(), "✓ SIGNED   release.zip!bundle.tar!signed.efi") {
+		t.Errorf("Expected nested member key, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	summary = Batch{Handler: fh, Workers: 1, Exclude: []string{"*.txt"}}.Run(&buf, []batchItem{{Path: "release.zip"}})
+	if summary.Total != 1 {
+		t.Errorf("Expected text members to be excluded, got %+v\n%s", summary, buf.String())
+	}
+}
+
+// TestWalkRPMFixture tests a kmod package as distributions build them: a
+// zstd cpio payload holding an xz compressed, signed kernel module. The
+// payload is testfiles/modules/hello.ko.xz archived with bsdtar --format
+// newc and compressed with zstd -19, behind a lead and two headers.
+func TestWalkRPMFixture(t *testing.T) {
+	data, err := os.ReadFile("testfiles/modules/kmod-hello-1.0-1.x86_64.rpm")
+	if err != nil {
+		t.Skip("testfiles/modules/kmod-hello-1.0-1.x86_64.rpm not available")
+	}
+
+	fh := FileHandler{Loader: MemoryLoader{"kmod-hello.rpm": data}}
+	var buf bytes.Buffer
+	summary := Batch{Handler: fh, Workers: 1}.Run(&buf, []batchItem{{Path: "kmod-hello.rpm"}})
+	if summary.Total != 1 || summary.Signed != 1 {
+		t.Errorf("Expected the module in the RPM to be signed, got %+v\n%s", summary, buf.String())
+	}
+	if !strings.Contains(buf.String(), "✓ SIGNED   kmod-hello.rpm!./lib/modules/6.8.0/extra/hello.ko.xz (xz payload offset") {
+		t.Errorf("Expected the module member, got:\n%s", buf.String())
+	}
+}
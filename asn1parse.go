@@ -0,0 +1,406 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// FormatASN1Parse selects output in the layout of openssl asn1parse
+const FormatASN1Parse = "asn1parse"
+
+// asn1parseTagNames are openssl's names for universal tags 0-30
+var asn1parseTagNames = [...]string{
+	"EOC", "BOOLEAN", "INTEGER", "BIT STRING", "OCTET STRING",
+	"NULL", "OBJECT", "OBJECT DESCRIPTOR", "EXTERNAL", "REAL",
+	"ENUMERATED", "<ASN1 11>", "UTF8STRING", "<ASN1 13>",
+	"<ASN1 14>", "<ASN1 15>", "SEQUENCE", "SET",
+	"NUMERICSTRING", "PRINTABLESTRING", "T61STRING",
+	"VIDEOTEXSTRING", "IA5STRING", "UTCTIME", "GENERALIZEDTIME",
+	"GRAPHICSTRING", "VISIBLESTRING", "GENERALSTRING",
+	"UNIVERSALSTRING", "<ASN1 29>", "BMPSTRING",
+}
+
+// opensslObjectNames are the long names OpenSSL 3 prints for OIDs in the
+// built-in catalogue and in common X.509, CMS and Authenticode structures.
+// OIDs without an entry print in dotted form, as OpenSSL does for objects
+// it does not know.
+var opensslObjectNames = map[string]string{
+	"1.2.156.10197.1.104.1":      "sm4-ecb",
+	"1.2.156.10197.1.104.2":      "sm4-cbc",
+	"1.2.156.10197.1.301":        "sm2",
+	"1.2.156.10197.1.401":        "sm3",
+	"1.2.392.200011.61.1.1.1.2":  "camellia-128-cbc",
+	"1.2.392.200011.61.1.1.1.3":  "camellia-192-cbc",
+	"1.2.392.200011.61.1.1.1.4":  "camellia-256-cbc",
+	"1.2.643.2.2.3":              "GOST R 34.11-94 with GOST R 34.10-2001",
+	"1.2.643.2.2.9":              "GOST R 34.11-94",
+	"1.2.643.2.2.19":             "GOST R 34.10-2001",
+	"1.2.643.2.2.21":             "GOST 28147-89",
+	"1.2.643.7.1.1.1.1":          "GOST R 34.10-2012 with 256 bit modulus",
+	"1.2.643.7.1.1.1.2":          "GOST R 34.10-2012 with 512 bit modulus",
+	"1.2.643.7.1.1.2.2":          "GOST R 34.11-2012 with 256 bit hash",
+	"1.2.643.7.1.1.2.3":          "GOST R 34.11-2012 with 512 bit hash",
+	"1.2.643.7.1.1.3.2":          "GOST R 34.10-2012 with GOST R 34.11-2012 (256 bit)",
+	"1.2.643.7.1.1.3.3":          "GOST R 34.10-2012 with GOST R 34.11-2012 (512 bit)",
+	"1.2.643.7.1.1.5.1":          "id-tc26-cipher-gostr3412-2015-magma",
+	"1.2.643.7.1.1.5.2":          "id-tc26-cipher-gostr3412-2015-kuznyechik",
+	"1.2.840.10040.4.1":          "dsaEncryption",
+	"1.2.840.10040.4.3":          "dsaWithSHA1",
+	"1.2.840.10045.2.1":          "id-ecPublicKey",
+	"1.2.840.10045.3.1.1":        "prime192v1",
+	"1.2.840.10045.3.1.2":        "prime192v2",
+	"1.2.840.10045.3.1.3":        "prime192v3",
+	"1.2.840.10045.3.1.4":        "prime239v1",
+	"1.2.840.10045.3.1.5":        "prime239v2",
+	"1.2.840.10045.3.1.6":        "prime239v3",
+	"1.2.840.10045.3.1.7":        "prime256v1",
+	"1.2.840.10045.4.1":          "ecdsa-with-SHA1",
+	"1.2.840.10045.4.3.1":        "ecdsa-with-SHA224",
+	"1.2.840.10045.4.3.2":        "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":        "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":        "ecdsa-with-SHA512",
+	"1.2.840.113549.1.1.1":       "rsaEncryption",
+	"1.2.840.113549.1.1.2":       "md2WithRSAEncryption",
+	"1.2.840.113549.1.1.4":       "md5WithRSAEncryption",
+	"1.2.840.113549.1.1.5":       "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.10":      "rsassaPss",
+	"1.2.840.113549.1.1.11":      "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12":      "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13":      "sha512WithRSAEncryption",
+	"1.2.840.113549.1.1.14":      "sha224WithRSAEncryption",
+	"1.2.840.113549.1.1.15":      "sha512-224WithRSAEncryption",
+	"1.2.840.113549.1.1.16":      "sha512-256WithRSAEncryption",
+	"1.2.840.113549.1.7.1":       "pkcs7-data",
+	"1.2.840.113549.1.7.2":       "pkcs7-signedData",
+	"1.2.840.113549.1.7.3":       "pkcs7-envelopedData",
+	"1.2.840.113549.1.7.4":       "pkcs7-signedAndEnvelopedData",
+	"1.2.840.113549.1.7.5":       "pkcs7-digestData",
+	"1.2.840.113549.1.7.6":       "pkcs7-encryptedData",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"1.2.840.113549.1.9.2":       "unstructuredName",
+	"1.2.840.113549.1.9.3":       "contentType",
+	"1.2.840.113549.1.9.4":       "messageDigest",
+	"1.2.840.113549.1.9.5":       "signingTime",
+	"1.2.840.113549.1.9.6":       "countersignature",
+	"1.2.840.113549.1.9.7":       "challengePassword",
+	"1.2.840.113549.1.9.8":       "unstructuredAddress",
+	"1.2.840.113549.1.9.9":       "extendedCertificateAttributes",
+	"1.2.840.113549.1.9.14":      "Extension Request",
+	"1.2.840.113549.1.9.15":      "S/MIME Capabilities",
+	"1.2.840.113549.1.9.16":      "S/MIME",
+	"1.2.840.113549.1.9.16.1.4":  "id-smime-ct-TSTInfo",
+	"1.2.840.113549.1.9.16.2.12": "id-smime-aa-signingCertificate",
+	"1.2.840.113549.1.9.16.2.14": "id-smime-aa-timeStampToken",
+	"1.2.840.113549.1.9.16.2.47": "id-smime-aa-signingCertificateV2",
+	"1.2.840.113549.1.9.20":      "friendlyName",
+	"1.2.840.113549.1.9.21":      "localKeyID",
+	"1.2.840.113549.2.5":         "md5",
+	"1.2.840.113549.3.2":         "rc2-cbc",
+	"1.2.840.113549.3.4":         "rc4",
+	"1.2.840.113549.3.7":         "des-ede3-cbc",
+	"1.3.6.1.4.1.311.2.1.14":     "Microsoft Extension Request",
+	"1.3.6.1.4.1.311.2.1.21":     "Microsoft Individual Code Signing",
+	"1.3.6.1.4.1.311.2.1.22":     "Microsoft Commercial Code Signing",
+	"1.3.6.1.4.1.311.10.3.1":     "Microsoft Trust List Signing",
+	"1.3.6.1.4.1.311.10.3.3":     "Microsoft Server Gated Crypto",
+	"1.3.6.1.4.1.311.10.3.4":     "Microsoft Encrypted File System",
+	"1.3.6.1.4.1.311.17.1":       "Microsoft CSP Name",
+	"1.3.6.1.4.1.311.20.2.2":     "Microsoft Smartcard Login",
+	"1.3.6.1.4.1.311.20.2.3":     "Microsoft User Principal Name",
+	"1.3.6.1.5.5.7.1.1":          "Authority Information Access",
+	"1.3.6.1.5.5.7.1.3":          "qcStatements",
+	"1.3.6.1.5.5.7.2.1":          "Policy Qualifier CPS",
+	"1.3.6.1.5.5.7.2.2":          "Policy Qualifier User Notice",
+	"1.3.6.1.5.5.7.3.1":          "TLS Web Server Authentication",
+	"1.3.6.1.5.5.7.3.2":          "TLS Web Client Authentication",
+	"1.3.6.1.5.5.7.3.3":          "Code Signing",
+	"1.3.6.1.5.5.7.3.4":          "E-mail Protection",
+	"1.3.6.1.5.5.7.3.8":          "Time Stamping",
+	"1.3.6.1.5.5.7.3.9":          "OCSP Signing",
+	"1.3.6.1.5.5.7.48.1":         "OCSP",
+	"1.3.6.1.5.5.7.48.2":         "CA Issuers",
+	"1.3.14.3.2.7":               "des-cbc",
+	"1.3.14.3.2.26":              "sha1",
+	"1.3.36.3.3.2.8.1.1.7":       "brainpoolP256r1",
+	"1.3.36.3.3.2.8.1.1.11":      "brainpoolP384r1",
+	"1.3.36.3.3.2.8.1.1.13":      "brainpoolP512r1",
+	"1.3.101.110":                "X25519",
+	"1.3.101.111":                "X448",
+	"1.3.101.112":                "ED25519",
+	"1.3.101.113":                "ED448",
+	"1.3.132.0.10":               "secp256k1",
+	"1.3.132.0.34":               "secp384r1",
+	"1.3.132.0.35":               "secp521r1",
+	"2.5.4.3":                    "commonName",
+	"2.5.4.4":                    "surname",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "countryName",
+	"2.5.4.7":                    "localityName",
+	"2.5.4.8":                    "stateOrProvinceName",
+	"2.5.4.9":                    "streetAddress",
+	"2.5.4.10":                   "organizationName",
+	"2.5.4.11":                   "organizationalUnitName",
+	"2.5.4.12":                   "title",
+	"2.5.4.13":                   "description",
+	"2.5.4.17":                   "postalCode",
+	"2.5.4.41":                   "name",
+	"2.5.4.42":                   "givenName",
+	"2.5.4.43":                   "initials",
+	"2.5.4.44":                   "generationQualifier",
+	"2.5.4.46":                   "dnQualifier",
+	"2.5.4.65":                   "pseudonym",
+	"2.5.4.97":                   "organizationIdentifier",
+	"2.5.29.14":                  "X509v3 Subject Key Identifier",
+	"2.5.29.15":                  "X509v3 Key Usage",
+	"2.5.29.17":                  "X509v3 Subject Alternative Name",
+	"2.5.29.18":                  "X509v3 Issuer Alternative Name",
+	"2.5.29.19":                  "X509v3 Basic Constraints",
+	"2.5.29.30":                  "X509v3 Name Constraints",
+	"2.5.29.31":                  "X509v3 CRL Distribution Points",
+	"2.5.29.32":                  "X509v3 Certificate Policies",
+	"2.5.29.32.0":                "X509v3 Any Policy",
+	"2.5.29.35":                  "X509v3 Authority Key Identifier",
+	"2.5.29.37":                  "X509v3 Extended Key Usage",
+	"2.16.840.1.101.3.4.1.2":     "aes-128-cbc",
+	"2.16.840.1.101.3.4.1.6":     "aes-128-gcm",
+	"2.16.840.1.101.3.4.1.22":    "aes-192-cbc",
+	"2.16.840.1.101.3.4.1.26":    "aes-192-gcm",
+	"2.16.840.1.101.3.4.1.42":    "aes-256-cbc",
+	"2.16.840.1.101.3.4.1.46":    "aes-256-gcm",
+	"2.16.840.1.101.3.4.2.1":     "sha256",
+	"2.16.840.1.101.3.4.2.2":     "sha384",
+	"2.16.840.1.101.3.4.2.3":     "sha512",
+	"2.16.840.1.101.3.4.2.4":     "sha224",
+	"2.16.840.1.101.3.4.2.5":     "sha512-224",
+	"2.16.840.1.101.3.4.2.6":     "sha512-256",
+	"2.16.840.1.101.3.4.2.7":     "sha3-224",
+	"2.16.840.1.101.3.4.2.8":     "sha3-256",
+	"2.16.840.1.101.3.4.2.9":     "sha3-384",
+	"2.16.840.1.101.3.4.2.10":    "sha3-512",
+	"2.16.840.1.101.3.4.2.11":    "shake128",
+	"2.16.840.1.101.3.4.2.12":    "shake256",
+	"2.16.840.1.101.3.4.3.1":     "dsa_with_SHA224",
+	"2.16.840.1.101.3.4.3.2":     "dsa_with_SHA256",
+	"2.16.840.1.113730.1.1":      "Netscape Cert Type",
+	"2.16.840.1.113730.1.13":     "Netscape Comment",
+}
+
+// ASN1ParsePrinter writes ASN.1 structures line by line exactly like
+// "openssl asn1parse -inform DER", optionally with -i indentation
+type ASN1ParsePrinter struct {
+	W      io.Writer
+	Indent bool
+}
+
+// Print writes every element of data; offsets are relative to its start.
+// Like openssl, it stops with "Error in encoding" at the first element that
+// does not parse or after a top-level end-of-contents element, and prints
+// BER indefinite lengths as "l=inf" with their content up to the
+// end-of-contents element.
+func (p ASN1ParsePrinter) Print(data []byte) error {
+	_, err := p.print(data, 0, 0, false)
+	return err
+}
+
+// print writes the elements of data and returns the number of bytes they
+// take up. Content of indefinite length ends after its end-of-contents
+// element, or like openssl with the data if that is missing. Like openssl,
+// an end-of-contents element at the top level ends the output, while one
+// inside definite-length content is printed and skipped.
+func (p ASN1ParsePrinter) print(data []byte, offset, depth int, indefinite bool) (int, error) {
+	for pos := 0; pos < len(data); {
+		element, n, err := parseASN1Element(data[pos:], depth, offset+pos)
+		var parseErr *ParseError
+		infinite := errors.As(err, &parseErr) && parseErr.Kind == ParseIndefiniteLength && element.IsCompound
+		if err != nil && !infinite {
+			fmt.Fprintf(p.W, "Error in encoding\n")
+			return 0, err
+		}
+
+		kind := "prim: "
+		if element.IsCompound {
+			kind = "cons: "
+		}
+		length := fmt.Sprintf("l=%4d ", element.Length)
+		if infinite {
+			element.HeaderLen = 2
+			length = "l=inf  "
+		}
+		line := fmt.Sprintf("%5d:d=%-2d hl=%d %s%s", element.Offset, depth, element.HeaderLen, length, kind)
+		if p.Indent {
+			line += strings.Repeat(" ", depth)
+		}
+		line += fmt.Sprintf("%-18s", asn1parseTagName(element))
+		if !element.IsCompound && element.Class == 0 {
+			line += asn1parseValue(element.Tag, data[pos+element.HeaderLen:pos+n])
+		}
+		fmt.Fprintln(p.W, line)
+
+		switch {
+		case infinite:
+			inner, err := p.print(data[pos+element.HeaderLen:], offset+pos+element.HeaderLen, depth+1, true)
+			if err != nil {
+				return 0, err
+			}
+			n = element.HeaderLen + inner
+		case element.IsCompound:
+			if _, err := p.print(data[pos+element.HeaderLen:pos+n], offset+pos+element.HeaderLen, depth+1, false); err != nil {
+				return 0, err
+			}
+		}
+		pos += n
+		if (indefinite || depth == 0) && element.Class == 0 && element.Tag == TagEOC && !element.IsCompound {
+			return pos, nil
+		}
+	}
+	return len(data), nil
+}
+
+// asn1parseTagName names a tag the way openssl's ASN1_tag2str and class
+// prefixes do
+func asn1parseTagName(element ASN1Element) string {
+	switch element.Class {
+	case 1:
+		return fmt.Sprintf("appl [ %d ]", element.Tag)
+	case 2:
+		return fmt.Sprintf("cont [ %d ]", element.Tag)
+	case 3:
+		return fmt.Sprintf("priv [ %d ] ", element.Tag)
+	}
+	if element.Tag >= len(asn1parseTagNames) {
+		return fmt.Sprintf("<ASN1 %d>", element.Tag)
+	}
+	return asn1parseTagNames[element.Tag]
+}
+
+// asn1parseValue formats universal primitive content. Values openssl
+// cannot decode print as ":BAD <TYPE>" followed by their hex.
+func asn1parseValue(tag int, content []byte) string {
+	badValue := func(name string) string {
+		return fmt.Sprintf(":BAD %s:[%X]", name, content)
+	}
+	switch tag {
+	case TagPrintable, TagT61String, TagIA5String, TagVisibleString,
+		TagNumericString, TagUTF8String, TagUTCTime, TagGeneralTime:
+		if len(content) == 0 {
+			return ""
+		}
+		return ":" + string(content)
+
+	case TagObjectID:
+		oid, err := decodeOID(content)
+		if err != nil {
+			return badValue("OBJECT")
+		}
+		if name, ok := opensslObjectNames[oid]; ok {
+			return ":" + name
+		}
+		return ":" + oid
+
+	case TagBoolean:
+		switch len(content) {
+		case 0:
+			return ":BAD BOOLEAN:[]"
+		case 1:
+			return fmt.Sprintf(":%d", content[0])
+		}
+		return fmt.Sprintf(":BAD BOOLEAN:%d:[%X]", content[0], content)
+
+	case TagOctetString:
+		if len(content) == 0 {
+			return ""
+		}
+		for _, b := range content {
+			if (b < ' ' && b != '\n' && b != '\r' && b != '\t') || b > '~' {
+				return fmt.Sprintf("[HEX DUMP]:%X", content)
+			}
+		}
+		return ":" + string(content)
+
+	case TagInteger, TagEnumerated:
+		if !isMinimalInteger(content) {
+			if tag == TagInteger {
+				return badValue("INTEGER")
+			}
+			return badValue("ENUMERATED")
+		}
+		value := decodeInteger(content)
+		sign := ""
+		if value.Sign() < 0 {
+			sign = "-"
+		}
+		magnitude := new(big.Int).Abs(value).Bytes()
+		if len(magnitude) == 0 {
+			magnitude = []byte{0}
+		}
+		return fmt.Sprintf(":%s%X", sign, magnitude)
+	}
+	return ""
+}
+
+// locateStructure returns the ASN.1 structure to show for an input and its
+// offset: DER, PEM or base64 input from its first byte, or the signature
+// found in other files such as signed binaries. Offsets of PEM and base64
+// input refer to the decoded bytes.
+func locateStructure(data []byte) ([]byte, int, error) {
+	blocks, err := decodeTextInput(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(blocks) > 0 {
+		data = blocks[0].Bytes
+	}
+	// Binaries can start with bytes that happen to form some element, such
+	// as "MZ", so only a leading SEQUENCE counts as DER input. encoding/asn1
+	// rejects BER indefinite lengths, which the printer handles.
+	var element asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &element); err == nil && data[0] == 0x30 {
+		return data, 0, nil
+	}
+	if bytes.HasPrefix(data, []byte{0x30, 0x80}) {
+		return data, 0, nil
+	}
+	raw, offset, err := NewSignatureParser(data).FindValidSignature()
+	if err != nil {
+		return nil, 0, err
+	}
+	return raw.FullBytes, offset, nil
+}
+
+// printASN1ParseInput writes an input in asn1parse layout, with offsets
+// relative to the structure located in it, and returns the exit status
+func printASN1ParseInput(w io.Writer, data []byte, indent bool) int {
+	der, _, err := locateStructure(data)
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return locateExitCode(data, err)
+	}
+	if err := (ASN1ParsePrinter{W: w, Indent: indent}).Print(der); err != nil && !isZeroPadded(der) {
+		return ExitMalformed
+	}
+	return ExitValid
+}
+
+// isZeroPadded reports whether data holds one complete element followed by
+// nothing but zero bytes, such as a signature padded to the 8-byte alignment
+// of a WIN_CERTIFICATE. openssl prints an encoding error for a lone padding
+// byte, which does not make the signature before it malformed.
+func isZeroPadded(data []byte) bool {
+	_, n, err := parseASN1Element(data, 0, 0)
+	if err != nil {
+		return false
+	}
+	for _, b := range data[n:] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestASN1ParsePrinter compares against lines produced by OpenSSL 3.0
+func TestASN1ParsePrinter(t *testing.T) {
+	der := []byte{
+		0x30, 0x34,
+		0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x02,
+		0x01, 0x01, 0xFF,
+		0x02, 0x02, 0xFF, 0x01,
+		0x02, 0x02, 0x00, 0x01,
+		0x04, 0x05, 'h', 'e', 'l', 'l', 'o',
+		0x04, 0x03, 0x00, 0x01, 0x02,
+		0x13, 0x02, 'D', 'E',
+		0xA0, 0x03, 0x02, 0x01, 0x00,
+		0x80, 0x00,
+		0x06, 0x03, 0x88, 0x37, 0x01,
+		0x05, 0x00,
+	}
+	expected := []string{
+		"    0:d=0  hl=2 l=  52 cons: SEQUENCE          ",
+		"    2:d=1  hl=2 l=   9 prim:  OBJECT            :pkcs7-signedData",
+		"   13:d=1  hl=2 l=   1 prim:  BOOLEAN           :255",
+		"   16:d=1  hl=2 l=   2 prim:  INTEGER           :-FF",
+		"   20:d=1  hl=2 l=   2 prim:  INTEGER           :BAD INTEGER:[0001]",
+		"   24:d=1  hl=2 l=   5 prim:  OCTET STRING      :hello",
+		"   31:d=1  hl=2 l=   3 prim:  OCTET STRING      [HEX DUMP]:000102",
+		"   36:d=1  hl=2 l=   2 prim:  PRINTABLESTRING   :DE",
+		"   40:d=1  hl=2 l=   3 cons:  cont [ 0 ]        ",
+		"   42:d=2  hl=2 l=   1 prim:   INTEGER           :00",
+		"   45:d=1  hl=2 l=   0 prim:  cont [ 0 ]        ",
+		"   47:d=1  hl=2 l=   3 prim:  OBJECT            :2.999.1",
+		"   52:d=1  hl=2 l=   0 prim:  NULL              ",
+	}
+
+	var buf bytes.Buffer
+	if err := (ASN1ParsePrinter{W: &buf, Indent: true}).Print(der); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	buf.Reset()
+	(ASN1ParsePrinter{W: &buf}).Print(der)
+	if line := strings.SplitN(buf.String(), "\n", 3)[1]; line != "    2:d=1  hl=2 l=   9 prim: OBJECT            :pkcs7-signedData" {
+		t.Errorf("Expected no indentation without -i, got %q", line)
+	}
+
+	buf.Reset()
+	if err := (ASN1ParsePrinter{W: &buf}).Print([]byte{0x30, 0x03, 0x02, 0x05, 0x01}); err == nil {
+		t.Errorf("Expected error for a truncated element")
+	}
+	if !strings.HasSuffix(buf.String(), "Error in encoding\n") {
+		t.Errorf("Expected openssl's encoding error line, got %q", buf.String())
+	}
+}
+
+// TestASN1ParsePrinterEOC compares end-of-contents elements outside
+// indefinite lengths against OpenSSL 3.0, which stops at one on the top
+// level but not inside definite-length content
+func TestASN1ParsePrinterEOC(t *testing.T) {
+	tests := []struct {
+		name     string
+		der      []byte
+		expected []string
+	}{
+		{
+			name: "top level",
+			der:  []byte{0x02, 0x01, 0x01, 0x00, 0x00, 0x02, 0x01, 0x02},
+			expected: []string{
+				"    0:d=0  hl=2 l=   1 prim: INTEGER           :01",
+				"    3:d=0  hl=2 l=   0 prim: EOC               ",
+			},
+		},
+		{
+			name: "definite length",
+			der:  []byte{0x30, 0x08, 0x02, 0x01, 0x01, 0x00, 0x00, 0x02, 0x01, 0x02},
+			expected: []string{
+				"    0:d=0  hl=2 l=   8 cons: SEQUENCE          ",
+				"    2:d=1  hl=2 l=   1 prim: INTEGER           :01",
+				"    5:d=1  hl=2 l=   0 prim: EOC               ",
+				"    7:d=1  hl=2 l=   1 prim: INTEGER           :02",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (ASN1ParsePrinter{W: &buf}).Print(tt.der); err != nil {
+				t.Fatalf("Print failed: %v", err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != strings.Join(tt.expected, "\n") {
+				t.Errorf("Unexpected output:\n%s\nexpected:\n%s", got, strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+// TestASN1ParsePrinterImages compares the WIN_CERTIFICATE data of the signed
+// test images, alignment padding included, against the output of
+// "openssl asn1parse -inform DER" saved in testfiles/asn1parse
+func TestASN1ParsePrinterImages(t *testing.T) {
+	paths, _ := filepath.Glob("testfiles/good/*.efi")
+	if len(paths) == 0 {
+		t.Skip("No signed images available")
+	}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		t.Run(name, func(t *testing.T) {
+			expected, err := os.ReadFile(filepath.Join("testfiles/asn1parse", name+".txt"))
+			if err != nil {
+				t.Fatalf("Missing openssl output: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pe, err := ParsePE(data)
+			if err != nil {
+				t.Fatalf("ParsePE failed: %v", err)
+			}
+			certs, err := pe.Certificates()
+			if err != nil || len(certs) == 0 {
+				t.Fatalf("Expected a WIN_CERTIFICATE, got %v", err)
+			}
+
+			var buf bytes.Buffer
+			if status := printASN1ParseInput(&buf, certs[0].Data, false); status != ExitValid {
+				t.Errorf("Expected exit status %d, got %d", ExitValid, status)
+			}
+			if buf.String() != string(expected) {
+				t.Errorf("Output differs from openssl:\n%s", lineDiff(string(expected), buf.String()))
+			}
+		})
+	}
+}
+
+// TestASN1ParsePrinterIndefinite compares BER indefinite lengths against
+// OpenSSL 3.0
+func TestASN1ParsePrinterIndefinite(t *testing.T) {
+	// SEQUENCE (inf) { INTEGER 5, OCTET STRING (inf) { "hi" } }, NULL
+	ber := []byte{0x30, 0x80, 0x02, 0x01, 0x05, 0x24, 0x80, 0x04, 0x02, 'h', 'i', 0x00, 0x00, 0x00, 0x00, 0x05, 0x00}
+	expected := []string{
+		"    0:d=0  hl=2 l=inf  cons: SEQUENCE          ",
+		"    2:d=1  hl=2 l=   1 prim:  INTEGER           :05",
+		"    5:d=1  hl=2 l=inf  cons:  OCTET STRING      ",
+		"    7:d=2  hl=2 l=   2 prim:   OCTET STRING      :hi",
+		"   11:d=2  hl=2 l=   0 prim:   EOC               ",
+		"   13:d=1  hl=2 l=   0 prim:  EOC               ",
+		"   15:d=0  hl=2 l=   0 prim: NULL              ",
+	}
+
+	var buf bytes.Buffer
+	if err := (ASN1ParsePrinter{W: &buf, Indent: true}).Print(ber); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", got, strings.Join(expected, "\n"))
+	}
+}
+
+// TestPrintASN1ParseInput tests DER input and signatures inside binaries
+func TestPrintASN1ParseInput(t *testing.T) {
+	data, err := os.ReadFile("testfiles/good/MokManager.efi")
+	if err != nil {
+		t.Skip("MokManager.efi not available")
+	}
+	var buf bytes.Buffer
+	if status := printASN1ParseInput(&buf, data, false); status != 0 {
+		t.Fatalf("Expected exit status 0, got %d:\n%s", status, buf.String())
+	}
+	if !strings.HasPrefix(buf.String(), "    0:d=0  hl=4 l= 601 cons: SEQUENCE          \n") {
+		t.Errorf("Expected the signature found in the image at offset 0, got:\n%.200s", buf.String())
+	}
+
+	raw, _, _ := NewSignatureParser(data).FindValidSignature()
+	var der bytes.Buffer
+	printASN1ParseInput(&der, raw.FullBytes, false)
+	if der.String() != buf.String() {
+		t.Errorf("Expected the same output for the extracted DER signature")
+	}
+
+	buf.Reset()
+	if status := printASN1ParseInput(&buf, []byte("no signature here"), false); status != ExitUnsigned {
+		t.Errorf("Expected exit status %d without a signature, got %d", ExitUnsigned, status)
+	}
+}
+
+// lineDiff describes the first line where got differs from expected
+func lineDiff(expected, got string) string {
+	e, g := strings.Split(expected, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(e) || i < len(g); i++ {
+		var el, gl string
+		if i < len(e) {
+			el = e[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if el != gl {
+			return fmt.Sprintf("line %d:\n got: %.120q\nwant: %.120q", i+1, gl, el)
+		}
+	}
+	return ""
+}
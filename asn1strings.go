@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// isASN1StringTag reports whether the universal tag is one of the
+// character string types decoded by decodeASN1String
+func isASN1StringTag(tag int) bool {
+	switch tag {
+	case TagUTF8String, TagNumericString, TagPrintable, TagT61String,
+		TagVideotexString, TagIA5String, TagGraphicString, TagVisibleString,
+		TagGeneralString, TagUniversalString, TagBMPString:
+		return true
+	}
+	return false
+}
+
+// decodeASN1String converts the content of an ASN.1 character string to UTF-8.
+// The returned string is always usable for display: characters that violate
+// the encoding or the character set are kept (or replaced by U+FFFD when they
+// cannot be decoded) and the first violation is reported in the error.
+func decodeASN1String(tag int, content []byte) (string, error) {
+	switch tag {
+	case TagBMPString:
+		return decodeBMPString(content)
+	case TagUniversalString:
+		return decodeUniversalString(content)
+	case TagUTF8String:
+		if !utf8.Valid(content) {
+			return strings.ToValidUTF8(string(content), "�"), fmt.Errorf("invalid UTF-8 sequence at byte %d", firstInvalidUTF8(content))
+		}
+		return string(content), nil
+	case TagPrintable:
+		return string(content), checkCharset(content, isPrintableStringChar)
+	case TagIA5String:
+		return string(content), checkCharset(content, func(b byte) bool { return b < 0x80 })
+	case TagNumericString:
+		return string(content), checkCharset(content, func(b byte) bool { return b == ' ' || (b >= '0' && b <= '9') })
+	case TagVisibleString:
+		return string(content), checkCharset(content, func(b byte) bool { return b >= 0x20 && b <= 0x7E })
+	default:
+		return string(content), nil
+	}
+}
+
+// decodeBMPString decodes UCS-2 / UTF-16BE content, reporting odd lengths
+// and unpaired surrogates
+func decodeBMPString(content []byte) (string, error) {
+	var firstErr error
+	if len(content)%2 != 0 {
+		firstErr = fmt.Errorf("odd length %d for 2-byte characters", len(content))
+		content = content[:len(content)-1]
+	}
+
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(units); i++ {
+		u := units[i]
+		switch {
+		case utf16.IsSurrogate(rune(u)) && u < 0xDC00 && i+1 < len(units) && units[i+1] >= 0xDC00 && units[i+1] <= 0xDFFF:
+			sb.WriteRune(utf16.DecodeRune(rune(u), rune(units[i+1])))
+			i++
+		case utf16.IsSurrogate(rune(u)):
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unpaired surrogate 0x%04X at byte %d", u, 2*i)
+			}
+			sb.WriteRune(utf8.RuneError)
+		default:
+			sb.WriteRune(rune(u))
+		}
+	}
+	return sb.String(), firstErr
+}
+
+// decodeUniversalString decodes UCS-4 (UTF-32BE) content, reporting bad
+// lengths and code points outside the Unicode scalar value range
+func decodeUniversalString(content []byte) (string, error) {
+	var firstErr error
+	if rem := len(content) % 4; rem != 0 {
+		firstErr = fmt.Errorf("length %d is not a multiple of 4", len(content))
+		content = content[:len(content)-rem]
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(content); i += 4 {
+		r := rune(uint32(content[i])<<24 | uint32(content[i+1])<<16 | uint32(content[i+2])<<8 | uint32(content[i+3]))
+		if !utf8.ValidRune(r) {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invalid code point 0x%08X at byte %d", uint32(r), i)
+			}
+			r = utf8.RuneError
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), firstErr
+}
+
+// isPrintableStringChar reports whether b belongs to the PrintableString
+// character set of X.680
+func isPrintableStringChar(b byte) bool {
+	switch {
+	case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9':
+		return true
+	}
+	return strings.IndexByte(" '()+,-./:=?", b) >= 0
+}
+
+// checkCharset returns an error describing the first byte rejected by valid
+func checkCharset(content []byte, valid func(byte) bool) error {
+	for i, b := range content {
+		if !valid(b) {
+			return fmt.Errorf("character 0x%02X not allowed at byte %d", b, i)
+		}
+	}
+	return nil
+}
+
+// firstInvalidUTF8 returns the byte index of the first invalid UTF-8 sequence
+func firstInvalidUTF8(content []byte) int {
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeASN1String tests decoding and character set validation of string types
+func TestDecodeASN1String(t *testing.T) {
+	tests := []struct {
+		name        string
+		tag         int
+		content     []byte
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "BMPString ASCII",
+			tag:      TagBMPString,
+			content:  []byte{0x00, 'S', 0x00, 'U', 0x00, 'S', 0x00, 'E'},
+			expected: "SUSE",
+		},
+		{
+			name:     "BMPString non-Latin",
+			tag:      TagBMPString,
+			content:  []byte{0x00, 'N', 0x00, 0xFC, 0x00, 'r', 0x04, 0x14},
+			expected: "NürД",
+		},
+		{
+			name:     "BMPString surrogate pair",
+			tag:      TagBMPString,
+			content:  []byte{0xD8, 0x3D, 0xDE, 0x00},
+			expected: "😀",
+		},
+		{
+			name:        "BMPString unpaired surrogate",
+			tag:         TagBMPString,
+			content:     []byte{0xD8, 0x3D, 0x00, 'A'},
+			expected:    "�A",
+			expectError: true,
+		},
+		{
+			name:        "BMPString odd length",
+			tag:         TagBMPString,
+			content:     []byte{0x00, 'A', 0x00},
+			expected:    "A",
+			expectError: true,
+		},
+		{
+			name:     "UniversalString",
+			tag:      TagUniversalString,
+			content:  []byte{0x00, 0x00, 0x00, 'O', 0x00, 0x01, 0xF6, 0x00},
+			expected: "O😀",
+		},
+		{
+			name:        "UniversalString invalid code point",
+			tag:         TagUniversalString,
+			content:     []byte{0x00, 0x11, 0x00, 0x00},
+			expected:    "�",
+			expectError: true,
+		},
+		{
+			name:     "PrintableString valid",
+			tag:      TagPrintable,
+			content:  []byte("Example Corp. (Test)"),
+			expected: "Example Corp. (Test)",
+		},
+		{
+			name:        "PrintableString with @",
+			tag:         TagPrintable,
+			content:     []byte("a@b"),
+			expected:    "a@b",
+			expectError: true,
+		},
+		{
+			name:        "IA5String 8-bit",
+			tag:         TagIA5String,
+			content:     []byte{'a', 0xE9},
+			expected:    "a\xe9",
+			expectError: true,
+		},
+		{
+			name:        "NumericString letters",
+			tag:         TagNumericString,
+			content:     []byte("12a"),
+			expected:    "12a",
+			expectError: true,
+		},
+		{
+			name:        "UTF8String invalid",
+			tag:         TagUTF8String,
+			content:     []byte{'o', 0xFF, 'k'},
+			expected:    "o�k",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeASN1String(tt.tag, tt.content)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestFormatStringContent tests that decoded strings are displayed and violations flagged
+func TestFormatStringContent(t *testing.T) {
+	bmp := formatPrimitiveContent(TagBMPString, []byte{0x00, 'g', 0x00, 'r', 0x00, 'u', 0x00, 'b'})
+	if bmp != `"grub"` {
+		t.Errorf("Expected BMPString to be decoded, got %s", bmp)
+	}
+
+	printable := formatPrimitiveContent(TagPrintable, []byte("a_b"))
+	if !strings.Contains(printable, "invalid PrintableString") {
+		t.Errorf("Expected PrintableString violation to be flagged, got %s", printable)
+	}
+}
+
+// TestValidationDecodesBMPString tests that certificate field values are decoded to UTF-8
+func TestValidationDecodesBMPString(t *testing.T) {
+	// SEQUENCE { OID 2.5.4.3, BMPString "CA" }
+	data := []byte{0x30, 0x0B, 0x06, 0x03, 0x55, 0x04, 0x03, 0x1E, 0x04, 0x00, 'C', 0x00, 'A'}
+
+	parser := NewSignatureParser(data)
+	validation := parser.validateSignatureFields(data)
+	if validation.CommonName != "CA" {
+		t.Errorf("Expected common name %q, got %q", "CA", validation.CommonName)
+	}
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// SignedSuffix is appended to the input name for the default attach output
+const SignedSuffix = ".signed"
+
+// loadSignature reads a detached DER or PEM signature and checks that it is
+// PKCS#7 SignedData
+func loadSignature(fh FileHandler, path string) ([]byte, error) {
+	input, err := fh.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	der := append([]byte{}, input.Bytes()...)
+	blocks, err := decodeTextInput(der)
+	if err != nil {
+		return nil, err
+	}
+	switch len(blocks) {
+	case 0:
+	case 1:
+		der = blocks[0].Bytes
+	default:
+		return nil, fmt.Errorf("expected one signature, found %d PEM blocks", len(blocks))
+	}
+
+	p7, err := ParsePKCS7(der)
+	if err != nil {
+		return nil, fmt.Errorf("not a PKCS#7 signature: %w", err)
+	}
+	return p7.Raw, nil
+}
+
+// attachSignature embeds signature into a PE image or appends it to a
+// kernel module, replacing any existing signature, and describes the result
+func attachSignature(data, signature []byte) ([]byte, string, error) {
+	replaced := ""
+	switch detectContainer(data) {
+	case ContainerPE:
+		pe, err := ParsePE(data)
+		if err != nil {
+			return nil, "", err
+		}
+		if pe.Signed() {
+			if pe, err = ParsePE(pe.Strip()); err != nil {
+				return nil, "", err
+			}
+			replaced = ", replacing the existing certificate table"
+		}
+		out := pe.Attach(signature)
+		pe, err = ParsePE(out)
+		if err != nil {
+			return nil, "", err
+		}
+		return out, fmt.Sprintf("attached %d-byte signature as WIN_CERTIFICATE at offset %d%s",
+			len(signature), pe.CertTableOffset, replaced), nil
+	case ContainerModule:
+		if hasModuleSignature(data) {
+			sig, err := ParseModuleSignature(data)
+			if err != nil {
+				return nil, "", err
+			}
+			data = data[:sig.Offset]
+			replaced = ", replacing the existing signature"
+		}
+		return appendModuleSignature(data, signature), fmt.Sprintf("appended %d-byte signature at offset %d%s",
+			len(signature), len(data), replaced), nil
+	}
+	return nil, "", errors.New("not a PE image, ELF file or module with an appended signature")
+}
+
+// runAttachCommand implements "autograph-pls attach", returning the exit status
+func runAttachCommand(args []string) int {
+	fs := flag.NewFlagSet("attach", flag.ContinueOnError)
+	output := fs.String("o", "", "output `file` (default: <file>"+SignedSuffix+")")
+	maxSize := byteSize(DefaultMaxInputSize)
+	fs.Var(&maxSize, "max-size", "read at most `bytes` (suffix K, M or G) from stdin and pipes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s attach [options] <file> <signature>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nEmbeds a detached PKCS#7 signature (DER or PEM) into a PE/EFI image as a\n")
+		fmt.Fprintf(os.Stderr, "WIN_CERTIFICATE, or appends it to a kernel module or ELF image with a\n")
+		fmt.Fprintf(os.Stderr, "module_signature trailer. An existing signature is replaced. The output is\n")
+		fmt.Fprintf(os.Stderr, "analyzed again, and the signature must verify cryptographically over the\n")
+		fmt.Fprintf(os.Stderr, "image.\n")
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s attach grubx64.efi grubx64.p7s     # Write grubx64.efi%s\n", os.Args[0], SignedSuffix)
+		fmt.Fprintf(os.Stderr, "  %s attach -o signed.ko module.ko sig.pem\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	path, signaturePath := fs.Arg(0), fs.Arg(1)
+	if *output == "" {
+		if path == StdinPath {
+			fmt.Printf("Error: -o is required when reading stdin\n")
+			return ExitUsage
+		}
+		*output = path + SignedSuffix
+	}
+
+	fh := FileHandler{MaxSize: int64(maxSize)}
+	signature, err := loadSignature(fh, signaturePath)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(signaturePath), err)
+		return errorExitCode(err)
+	}
+	input, err := fh.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+	signed, description, err := attachSignature(input.Bytes(), signature)
+	input.Close()
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(path), err)
+		return errorExitCode(err)
+	}
+	if err := fh.SaveToFile(signed, *output); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitIOError
+	}
+	fmt.Printf("%s: %s\n", inputName(path), description)
+	fmt.Printf("Signed image written to: %s (%d bytes)\n", *output, len(signed))
+	return verifyOutput(fh, *output, signed)
+}
+
+// verifyOutput analyzes the image written to path like a batch run, then
+// verifies the signature embedded in signed, its content, over the image.
+// It returns ExitValid only if the signature is found, carries the required
+// fields and verifies.
+func verifyOutput(fh FileHandler, path string, signed []byte) int {
+	fmt.Println("========================================")
+	summary := (Batch{Handler: fh}).Run(os.Stdout, []batchItem{{Path: path}})
+	if !summary.Passed() {
+		fmt.Printf("Error: the signature in %s was not found or is invalid\n", path)
+		return summary.ExitCode
+	}
+
+	p7, err := embeddedPKCS7(signed)
+	if err == nil {
+		var check string
+		if check, err = checkSignature(signed, p7); err == nil {
+			fmt.Printf("Verified: %s\n", check)
+			return ExitValid
+		}
+	}
+	fmt.Printf("Error: the signature in %s does not verify: %v\n", path, err)
+	return ExitInvalid
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAttachSignature tests embedding into PE images and modules
+func TestAttachSignature(t *testing.T) {
+	signature := []byte{0x30, 0x03, 0x02, 0x01, 0x01}
+	other := []byte{0x30, 0x04, 0x04, 0x02, 0xAB, 0xCD}
+
+	image := buildTestPE(bytes.Repeat([]byte{0xCC}, 101))
+	signed, description, err := attachSignature(image, signature)
+	if err != nil {
+		t.Fatalf("attachSignature failed: %v", err)
+	}
+	if !bytes.Equal(signed, appendTestCertTable(image, signature)) {
+		t.Errorf("Expected an 8-byte aligned WIN_CERTIFICATE after the padded image")
+	}
+	if !strings.Contains(description, "WIN_CERTIFICATE at offset 432") {
+		t.Errorf("Unexpected description %q", description)
+	}
+
+	replaced, description, err := attachSignature(signed, other)
+	if err != nil || !strings.Contains(description, "replacing") {
+		t.Fatalf("Expected replacement, got %q (%v)", description, err)
+	}
+	pe, _ := ParsePE(replaced)
+	certs, _ := pe.Certificates()
+	if len(certs) != 1 || !bytes.Equal(certs[0].Data, other) {
+		t.Errorf("Expected only the new signature in the certificate table, got %+v", certs)
+	}
+
+	module := []byte("\x7fELF module content")
+	signed, _, err = attachSignature(module, signature)
+	if err != nil || !bytes.Equal(signed, buildTestModule(module, signature)) {
+		t.Fatalf("Expected module_signature trailer, got %q (%v)", signed, err)
+	}
+	replaced, _, err = attachSignature(signed, other)
+	if err != nil || !bytes.Equal(replaced, buildTestModule(module, other)) {
+		t.Errorf("Expected replaced module signature, got %q (%v)", replaced, err)
+	}
+
+	if _, _, err := attachSignature([]byte("plain data"), signature); err == nil {
+		t.Errorf("Expected error for unknown containers")
+	}
+}
+
+// TestLoadSignature tests reading detached DER and PEM signatures
+func TestLoadSignature(t *testing.T) {
+	ts := newTestRSASigner(t)
+	der := ts.signDetached(t, []byte("content"), false)
+	fh := FileHandler{Loader: MemoryLoader{
+		"sig.der":  append(append([]byte{}, der...), 0, 0, 0),
+		"sig.pem":  pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}),
+		"cert.pem": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.cert.Raw}),
+	}}
+
+	for _, path := range []string{"sig.der", "sig.pem"} {
+		signature, err := loadSignature(fh, path)
+		if err != nil || !bytes.Equal(signature, der) {
+			t.Errorf("%s: expected the DER signature without padding, got %d bytes (%v)", path, len(signature), err)
+		}
+	}
+	if _, err := loadSignature(fh, "cert.pem"); err == nil {
+		t.Errorf("Expected error for a certificate instead of a signature")
+	}
+}
+
+// TestRunAttachCommand tests attaching and re-analysing end to end
+func TestRunAttachCommand(t *testing.T) {
+	ts := newTestRSASigner(t)
+	dir := t.TempDir()
+	module := filepath.Join(dir, "module.ko")
+	signature := filepath.Join(dir, "module.p7s")
+	os.WriteFile(module, []byte("\x7fELF module content"), 0o644)
+	os.WriteFile(signature, ts.signDetached(t, []byte("\x7fELF module content"), false), 0o644)
+
+	var status int
+	output := captureStdout(t, func() { status = runAttachCommand([]string{module, signature}) })
+	if status != 0 {
+		t.Fatalf("Expected exit status 0, got %d:\n%s", status, output)
+	}
+	if !strings.Contains(output, "✓ SIGNED") || !strings.Contains(output, "Verified: module signature") {
+		t.Errorf("Expected the output to be re-analysed as signed and verified, got:\n%s", output)
+	}
+	signed, _ := os.ReadFile(module + SignedSuffix)
+	if sig, err := ParseModuleSignature(signed); err != nil || sig.Offset != len("\x7fELF module content") {
+		t.Errorf("Expected a module signature trailer in the output (%v)", err)
+	}
+
+	// A well-formed signature over other content must not pass
+	os.WriteFile(signature, ts.signDetached(t, []byte("other content"), false), 0o644)
+	output = captureStdout(t, func() { status = runAttachCommand([]string{"-max-size", "1K", module, signature}) })
+	if status != ExitInvalid || !strings.Contains(output, "does not verify") {
+		t.Errorf("Expected exit status %d for a signature over other content, got %d:\n%s", ExitInvalid, status, output)
+	}
+
+	captureStdout(t, func() { status = runAttachCommand([]string{module, module}) })
+	if status != ExitMalformed {
+		t.Errorf("Expected exit status %d for a non-signature file, got %d", ExitMalformed, status)
+	}
+}
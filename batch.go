@@ -0,0 +1,498 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileStatus classifies the outcome of analysing one file in batch mode
+type FileStatus int
+
+const (
+	// FileSigned files carry a signature with all required certificate fields
+	FileSigned FileStatus = iota
+	// FileUnsigned files contain no PKCS#7 SignedData at all
+	FileUnsigned
+	// FileInvalid files contain SignedData that fails validation
+	FileInvalid
+	// FileError files could not be read or analysed
+	FileError
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case FileSigned:
+		return "SIGNED"
+	case FileUnsigned:
+		return "UNSIGNED"
+	case FileInvalid:
+		return "INVALID"
+	default:
+		return "ERROR"
+	}
+}
+
+// oidSignedDataDER is the DER encoding of the pkcs7-signedData content type
+var oidSignedDataDER = []byte{0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x02}
+
+// FileResult is the batch analysis result for a single file
+type FileResult struct {
+	Path       string
+	Status     FileStatus
+	Offset     int
+	Size       int
+	KeySize    int
+	Validation SignatureValidation
+	Layers     []CompressionLayer
+	// Note adds detail to a verdict; Problem explains an INVALID verdict
+	// that is not about missing certificate fields
+	Note    string
+	Problem string
+	Err     error
+}
+
+// BatchSummary counts the results of a batch run
+type BatchSummary struct {
+	Total    int
+	Signed   int
+	Unsigned int
+	Invalid  int
+	Errors   int
+	// ExitCode is the highest exit code of the results
+	ExitCode int
+}
+
+// Passed reports whether every file was signed with a valid signature
+func (bs BatchSummary) Passed() bool {
+	return bs.Signed == bs.Total
+}
+
+func (bs *BatchSummary) add(result FileResult) {
+	bs.Total++
+	bs.ExitCode = max(bs.ExitCode, result.ExitCode())
+	switch result.Status {
+	case FileSigned:
+		bs.Signed++
+	case FileUnsigned:
+		bs.Unsigned++
+	case FileInvalid:
+		bs.Invalid++
+	default:
+		bs.Errors++
+	}
+}
+
+// batchItem is a file queued for analysis, or a path that already failed
+// while collecting
+type batchItem struct {
+	Path string
+	Err  error
+}
+
+// collectFiles expands the command-line paths into files to analyse. With
+// recursive set, directories are walked and include/exclude globs are applied
+// to the files (and exclude to the directories) found; explicitly named files
+// are always analysed.
+func collectFiles(paths []string, recursive bool, include, exclude []string) []batchItem {
+	var items []batchItem
+	for _, root := range paths {
+		if root == StdinPath {
+			items = append(items, batchItem{Path: root})
+			continue
+		}
+
+		info, err := os.Stat(root)
+		if err != nil {
+			items = append(items, batchItem{Path: root, Err: readError{err}})
+			continue
+		}
+		if !info.IsDir() {
+			items = append(items, batchItem{Path: root})
+			continue
+		}
+		if !recursive {
+			items = append(items, batchItem{Path: root, Err: readError{fmt.Errorf("%s is a directory (use -r to descend)", root)}})
+			continue
+		}
+
+		walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				items = append(items, batchItem{Path: path, Err: readError{err}})
+				return nil
+			}
+			rel, relErr := filepath.Rel(root, path)
+			if relErr != nil {
+				rel = path
+			}
+			if d.IsDir() {
+				if path != root && matchesAnyGlob(rel, exclude) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type()&(fs.ModeDevice|fs.ModeNamedPipe|fs.ModeSocket|fs.ModeIrregular) != 0 {
+				return nil
+			}
+			if len(include) > 0 && !matchesAnyGlob(rel, include) {
+				return nil
+			}
+			if matchesAnyGlob(rel, exclude) {
+				return nil
+			}
+			items = append(items, batchItem{Path: path})
+			return nil
+		})
+		if walkErr != nil {
+			items = append(items, batchItem{Path: root, Err: readError{walkErr}})
+		}
+	}
+	return items
+}
+
+// matchesAnyGlob matches a path relative to the walked directory against
+// shell globs; patterns containing a separator are matched against the whole
+// relative path, others against the base name
+func matchesAnyGlob(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		target := filepath.Base(path)
+		if strings.ContainsRune(pattern, '/') || strings.ContainsRune(pattern, filepath.Separator) {
+			target = path
+		}
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeData classifies one file's content without printing anything
+func analyzeData(data []byte) (result FileResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = FileResult{Status: FileError, Err: fmt.Errorf("%w: %v", errAnalysisCrashed, r)}
+		}
+	}()
+
+	parser := NewSignatureParser(data)
+	raw, offset, err := parser.FindValidSignature()
+	if err == nil {
+		return FileResult{
+			Status:     FileSigned,
+			Offset:     offset,
+			Size:       len(raw.FullBytes),
+			KeySize:    parser.calculateKeySize(raw.FullBytes, 0),
+			Validation: parser.validateSignatureFields(raw.FullBytes),
+		}
+	}
+
+	offset, found := findSignedData(data)
+	if !found {
+		return FileResult{Status: FileUnsigned}
+	}
+	result = FileResult{Status: FileInvalid, Offset: offset}
+	var content asn1.RawValue
+	if _, err := asn1.Unmarshal(data[offset:], &content); err == nil {
+		result.Size = len(content.FullBytes)
+		result.Validation = parser.validateSignatureFields(content.FullBytes)
+	}
+	return result
+}
+
+// findSignedData returns the offset of the last ContentInfo whose content
+// type is pkcs7-signedData, or of the bare OID when no SEQUENCE header
+// precedes it
+func findSignedData(data []byte) (int, bool) {
+	i := bytes.LastIndex(data, oidSignedDataDER)
+	if i < 0 {
+		return 0, false
+	}
+	// ContentInfo ::= SEQUENCE { contentType, [0] content }: the SEQUENCE
+	// tag and its length octets sit right before the OID
+	for _, header := range []struct {
+		prefix []byte
+		size   int
+	}{
+		{[]byte{0x30, 0x82}, 4},
+		{[]byte{0x30, 0x81}, 3},
+		{[]byte{0x30}, 2},
+	} {
+		start := i - header.size
+		if start >= 0 && bytes.HasPrefix(data[start:], header.prefix) {
+			return start, true
+		}
+	}
+	return i, true
+}
+
+// Batch analyses many files, expanding archives into their members
+type Batch struct {
+	Handler FileHandler
+	// Workers is the number of files analysed concurrently
+	Workers int
+	// Include and Exclude filter archive members like files found by -r
+	Include []string
+	Exclude []string
+}
+
+// Run analyses the items with a pool of workers, printing one line per file
+// or archive member in input order followed by the aggregate counts
+func (b Batch) Run(w io.Writer, items []batchItem) BatchSummary {
+	workers := b.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	type indexed struct {
+		index   int
+		results []FileResult
+	}
+	jobs := make(chan int)
+	results := make(chan indexed)
+
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results <- indexed{index, b.analyzeItem(items[index])}
+			}
+		}()
+	}
+	go func() {
+		for index := range items {
+			jobs <- index
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results arrive out of order; hold them back so output stays stable
+	var summary BatchSummary
+	pending := make(map[int][]FileResult)
+	next := 0
+	for r := range results {
+		pending[r.index] = r.results
+		for {
+			itemResults, ready := pending[next]
+			if !ready {
+				break
+			}
+			delete(pending, next)
+			for _, result := range itemResults {
+				printFileResult(w, result)
+				summary.add(result)
+			}
+			next++
+		}
+	}
+
+	printBatchSummary(w, summary)
+	return summary
+}
+
+// reportResults prints already computed results and their aggregate counts
+func reportResults(w io.Writer, results []FileResult) BatchSummary {
+	var summary BatchSummary
+	for _, result := range results {
+		printFileResult(w, result)
+		summary.add(result)
+	}
+	printBatchSummary(w, summary)
+	return summary
+}
+
+// printBatchSummary prints the aggregate counts of a batch run
+func printBatchSummary(w io.Writer, summary BatchSummary) {
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "Files analyzed: %d\n", summary.Total)
+	fmt.Fprintf(w, "  Signed:   %d\n", summary.Signed)
+	fmt.Fprintf(w, "  Unsigned: %d\n", summary.Unsigned)
+	fmt.Fprintf(w, "  Invalid:  %d\n", summary.Invalid)
+	fmt.Fprintf(w, "  Errors:   %d\n", summary.Errors)
+}
+
+// analyzeItem loads and analyses a single queued file; archives yield one
+// result per member
+func (b Batch) analyzeItem(item batchItem) []FileResult {
+	if item.Err != nil {
+		return []FileResult{{Path: item.Path, Status: FileError, Err: item.Err}}
+	}
+
+	input, err := b.Handler.Open(item.Path)
+	if err != nil {
+		return []FileResult{{Path: item.Path, Status: FileError, Err: err}}
+	}
+	defer input.Close()
+
+	data, layers, err := decompressLayers(input.Bytes(), b.Handler.maxSize())
+	if err != nil {
+		return []FileResult{{Path: item.Path, Status: FileError, Err: err}}
+	}
+
+	name := inputName(item.Path)
+	if format := detectArchive(data); format != "" {
+		return b.analyzeArchive(name, format, data, 0)
+	}
+	blocks, err := decodeTextInput(data)
+	if err != nil {
+		return []FileResult{{Path: name, Status: FileError, Err: err}}
+	}
+	if blocks != nil {
+		return analyzeBlocks(name, blocks)
+	}
+
+	result := analyzeData(data)
+	result.Path = name
+	result.Layers = layers
+	return []FileResult{result}
+}
+
+// analyzeBlocks analyses the DER blocks decoded from PEM or base64 input;
+// several blocks are keyed name!TYPE#n
+func analyzeBlocks(name string, blocks []TextBlock) []FileResult {
+	results := make([]FileResult, len(blocks))
+	for i, block := range blocks {
+		results[i] = analyzeData(block.Bytes)
+		results[i].Path = name
+		if len(blocks) > 1 {
+			results[i].Path = name + ArchiveSeparator + describeTextBlock(block, i)
+		}
+	}
+	return results
+}
+
+// analyzeArchive analyses every selected member of an archive, descending
+// into nested archives. Results are keyed archive!member; a broken archive
+// adds an error result after the members read so far. Signed JAR and APK
+// files yield one result per signature block instead of per member.
+func (b Batch) analyzeArchive(name, format string, data []byte, depth int) []FileResult {
+	if format == ArchiveZip {
+		if results := b.analyzeJar(name, data); results != nil {
+			return results
+		}
+	}
+
+	var results []FileResult
+	err := walkArchive(format, data, b.Handler.maxSize(), func(member string, content []byte) error {
+		key := name + ArchiveSeparator + member
+		if matchesAnyGlob(member, b.Exclude) {
+			return nil
+		}
+
+		inner, layers, err := decompressLayers(content, b.Handler.maxSize())
+		if err != nil {
+			results = append(results, FileResult{Path: key, Status: FileError, Err: err})
+			return nil
+		}
+		if nested := detectArchive(inner); nested != "" && depth < maxArchiveDepth {
+			results = append(results, b.analyzeArchive(key, nested, inner, depth+1)...)
+			return nil
+		}
+		if len(b.Include) > 0 && !matchesAnyGlob(member, b.Include) {
+			return nil
+		}
+
+		result := analyzeData(inner)
+		result.Path = key
+		result.Layers = layers
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		results = append(results, FileResult{Path: name, Status: FileError, Err: fmt.Errorf("%s archive: %w", format, err)})
+	}
+	return results
+}
+
+// analyzeJar verifies the v1 signature blocks of a zip-based archive, or
+// returns nil when it has none
+func (b Batch) analyzeJar(name string, data []byte) []FileResult {
+	signatures, manifest, err := readJarSignatures(data, b.Handler.maxSize())
+	if err != nil {
+		return []FileResult{{Path: name, Status: FileError, Err: fmt.Errorf("%s archive: %w", ArchiveZip, err)}}
+	}
+
+	var results []FileResult
+	for _, sig := range signatures {
+		if matchesAnyGlob(sig.blockName, b.Exclude) {
+			continue
+		}
+		result := analyzeJarSignature(sig, manifest)
+		result.Path = name + ArchiveSeparator + sig.blockName
+		results = append(results, result)
+	}
+	if len(signatures) > 0 && results == nil {
+		return []FileResult{}
+	}
+	return results
+}
+
+// printFileResult prints the one-line summary of a file
+func printFileResult(w io.Writer, result FileResult) {
+	name := result.Path
+	switch result.Status {
+	case FileSigned:
+		details := fmt.Sprintf("%s, %d bytes", shortOffset(result), result.Size)
+		if result.KeySize > 0 {
+			details += fmt.Sprintf(", %d-bit key", result.KeySize)
+		}
+		if result.Validation.CommonName != "" {
+			details += fmt.Sprintf(", CN=%s", result.Validation.CommonName)
+		}
+		if result.Note != "" {
+			details += ", " + result.Note
+		}
+		fmt.Fprintf(w, "✓ %-8s %s (%s)\n", result.Status, name, details)
+	case FileUnsigned:
+		fmt.Fprintf(w, "- %-8s %s\n", result.Status, name)
+	case FileInvalid:
+		if result.Problem != "" {
+			fmt.Fprintf(w, "✗ %-8s %s (%s)\n", result.Status, name, result.Problem)
+			return
+		}
+		fmt.Fprintf(w, "✗ %-8s %s (SignedData at %s, missing: %s)\n",
+			result.Status, name, shortOffset(result), strings.Join(missingFields(result.Validation), ", "))
+	default:
+		fmt.Fprintf(w, "! %-8s %s: %v\n", result.Status, name, result.Err)
+	}
+}
+
+// shortOffset renders a result offset, naming the decompression chain when
+// the offset refers to a decompressed payload
+func shortOffset(result FileResult) string {
+	if len(result.Layers) == 0 {
+		return fmt.Sprintf("offset %d", result.Offset)
+	}
+	return fmt.Sprintf("%s payload offset %d", describeLayers(result.Layers), result.Offset)
+}
+
+// missingFields lists the required certificate fields a validation lacks
+func missingFields(sv SignatureValidation) []string {
+	var missing []string
+	for _, field := range []struct {
+		name    string
+		present bool
+	}{
+		{"Common Name", sv.HasCommonName},
+		{"Country Name", sv.HasCountryName},
+		{"Locality Name", sv.HasLocalityName},
+		{"Organization Name", sv.HasOrganizationName},
+		{"Email Address", sv.HasEmailAddress},
+	} {
+		if !field.present {
+			missing = append(missing, field.name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestCollectFiles tests directory recursion and include/exclude globs
+func TestCollectFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.efi", "b.ko", "sub/c.efi", "skip/d.efi", "notes.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	relative := func(items []batchItem) []string {
+		var names []string
+		for _, item := range items {
+			if item.Err != nil {
+				names = append(names, "error:"+item.Path)
+				continue
+			}
+			rel, _ := filepath.Rel(root, item.Path)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	tests := []struct {
+		name      string
+		recursive bool
+		include   []string
+		exclude   []string
+		expected  string
+	}{
+		{"All files", true, nil, nil, "a.efi,b.ko,notes.txt,skip/d.efi,sub/c.efi"},
+		{"Include globs", true, []string{"*.efi", "*.ko"}, nil, "a.efi,b.ko,skip/d.efi,sub/c.efi"},
+		{"Exclude directory", true, []string{"*.efi"}, []string{"skip"}, "a.efi,sub/c.efi"},
+		{"Exclude by path", true, nil, []string{"sub/*", "*.txt"}, "a.efi,b.ko,skip/d.efi"},
+		{"Directory without -r", false, nil, nil, "error:" + root},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := strings.Join(relative(collectFiles([]string{root}, tt.recursive, tt.include, tt.exclude)), ",")
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestAnalyzeData tests the signed, unsigned and invalid classifications
+func TestAnalyzeData(t *testing.T) {
+	// ContentInfo { signedData, [0] {} } without any certificate fields
+	signedDataOnly := append([]byte{0x00, 0x30, 0x0D}, oidSignedDataDER...)
+	signedDataOnly = append(signedDataOnly, 0xA0, 0x00)
+
+	result := analyzeData(signedDataOnly)
+	if result.Status != FileInvalid || result.Offset != 1 {
+		t.Errorf("Expected INVALID at offset 1, got %s at %d", result.Status, result.Offset)
+	}
+	if missing := missingFields(result.Validation); len(missing) != 5 {
+		t.Errorf("Expected all five fields missing, got %v", missing)
+	}
+
+	if result := analyzeData(bytes.Repeat([]byte{0x30, 0x82}, 32)); result.Status != FileUnsigned {
+		t.Errorf("Expected UNSIGNED, got %s", result.Status)
+	}
+
+	goodFiles, _ := filepath.Glob("testfiles/good/*.efi")
+	if len(goodFiles) == 0 {
+		t.Skip("No good test files found")
+	}
+	data, err := os.ReadFile(goodFiles[0])
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", goodFiles[0], err)
+	}
+	if result := analyzeData(data); result.Status != FileSigned || result.KeySize == 0 {
+		t.Errorf("Expected SIGNED with key size for %s, got %s (%d bits)", goodFiles[0], result.Status, result.KeySize)
+	}
+}
+
+// TestRunBatch tests ordered per-file output, aggregate counts and the pass/fail verdict
+func TestRunBatch(t *testing.T) {
+	invalid := append([]byte{0x30, 0x0D}, oidSignedDataDER...)
+	invalid = append(invalid, 0xA0, 0x00)
+	fh := FileHandler{Loader: MemoryLoader{
+		"unsigned.bin": bytes.Repeat([]byte{0xAA}, 64),
+		"invalid.bin":  invalid,
+	}}
+
+	items := []batchItem{{Path: "unsigned.bin"}, {Path: "missing.bin"}, {Path: "invalid.bin"}}
+	for i := 0; i < 20; i++ {
+		items = append(items, batchItem{Path: "unsigned.bin"})
+	}
+
+	var buf bytes.Buffer
+	summary := Batch{Handler: fh, Workers: 4}.Run(&buf, items)
+
+	expected := BatchSummary{Total: 23, Unsigned: 21, Invalid: 1, Errors: 1, ExitCode: ExitIOError}
+	if summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+	if summary.Passed() {
+		t.Errorf("Expected batch with unsigned files to fail")
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "- UNSIGNED unsigned.bin") ||
+		!strings.HasPrefix(lines[1], "! ERROR    missing.bin") ||
+		!strings.HasPrefix(lines[2], "✗ INVALID  invalid.bin (SignedData at offset 0") {
+		t.Errorf("Expected results in input order, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Files analyzed: 23") {
+		t.Errorf("Expected aggregate line, got:\n%s", buf.String())
+	}
+
+	if !(BatchSummary{Total: 2, Signed: 2}).Passed() {
+		t.Errorf("Expected all-signed batch to pass")
+	}
+}
@@ -0,0 +1,449 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Catalogue categories; OID files may introduce further categories of their own
+const (
+	CategorySignature   = "signature"
+	CategoryPublicKey   = "public-key"
+	CategoryHash        = "hash"
+	CategoryCipher      = "cipher"
+	CategoryCurve       = "curve"
+	CategoryContentType = "content-type"
+	CategoryAttribute   = "attribute"
+	CategoryExtension   = "extension"
+	CategoryKeyPurpose  = "key-purpose"
+	CategoryPolicy      = "policy"
+	CategoryOther       = "other"
+)
+
+// Strength status of an algorithm; identifiers that are not algorithms
+// (attributes, extensions, ...) carry no status
+const (
+	StatusRecommended = "recommended"
+	StatusAcceptable  = "acceptable"
+	StatusDeprecated  = "deprecated"
+	StatusBroken      = "broken"
+	StatusDraft       = "draft"
+)
+
+// catalogueCategories fixes the order and headers of the text listing
+var catalogueCategories = []struct {
+	Category string
+	Title    string
+}{
+	{CategorySignature, "🔏 Signature Algorithms"},
+	{CategoryPublicKey, "🔑 Public Key Algorithms"},
+	{CategoryHash, "#️⃣ Hash Algorithms"},
+	{CategoryCipher, "🔒 Symmetric Ciphers"},
+	{CategoryCurve, "📐 Elliptic Curves"},
+	{CategoryContentType, "📦 Content Types"},
+	{CategoryAttribute, "🏷️  Attributes"},
+	{CategoryExtension, "🧩 Certificate Extensions"},
+	{CategoryKeyPurpose, "🎯 Extended Key Usages"},
+	{CategoryPolicy, "📜 Certificate Policies"},
+}
+
+// CatalogueEntry describes a known object identifier. KeySize and HashSize
+// are in bits and zero when not applicable or variable.
+type CatalogueEntry struct {
+	OID         string `json:"oid"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Family      string `json:"family,omitempty"`
+	Standard    string `json:"standard,omitempty"`
+	KeySize     int    `json:"key_size,omitempty"`
+	HashSize    int    `json:"hash_size,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source"`
+}
+
+func builtin(oid, name, category, family, standard string, keySize, hashSize int, status string) CatalogueEntry {
+	return CatalogueEntry{
+		OID:      oid,
+		Name:     name,
+		Category: category,
+		Family:   family,
+		Standard: standard,
+		KeySize:  keySize,
+		HashSize: hashSize,
+		Status:   status,
+		Source:   OIDSourceBuiltin,
+	}
+}
+
+// builtinCatalogue is the single source of the OIDs compiled into the binary;
+// oidNames and the -list output are derived from it
+var builtinCatalogue = []CatalogueEntry{
+	// RSA
+	builtin("1.2.840.113549.1.1.1", "rsaEncryption", CategoryPublicKey, "RSA", "RFC 8017", 0, 0, StatusAcceptable),
+	builtin("1.2.840.113549.1.1.2", "md2WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 128, StatusBroken),
+	builtin("1.2.840.113549.1.1.4", "md5WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 128, StatusBroken),
+	builtin("1.2.840.113549.1.1.5", "sha1WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 160, StatusDeprecated),
+	builtin("1.2.840.113549.1.1.10", "rsaPSS", CategorySignature, "RSA", "RFC 8017", 0, 0, StatusRecommended),
+	builtin("1.2.840.113549.1.1.11", "sha256WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 256, StatusRecommended),
+	builtin("1.2.840.113549.1.1.12", "sha384WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 384, StatusRecommended),
+	builtin("1.2.840.113549.1.1.13", "sha512WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 512, StatusRecommended),
+	builtin("1.2.840.113549.1.1.14", "sha224WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 224, StatusAcceptable),
+	builtin("1.2.840.113549.1.1.15", "sha512-224WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 224, StatusAcceptable),
+	builtin("1.2.840.113549.1.1.16", "sha512-256WithRSAEncryption", CategorySignature, "RSA", "RFC 8017", 0, 256, StatusRecommended),
+
+	// ECDSA
+	builtin("1.2.840.10045.2.1", "ecPublicKey", CategoryPublicKey, "ECDSA", "RFC 5480", 0, 0, StatusRecommended),
+	builtin("1.2.840.10045.4.1", "ecdsa-with-SHA1", CategorySignature, "ECDSA", "RFC 3279", 0, 160, StatusDeprecated),
+	builtin("1.2.840.10045.4.3.1", "ecdsa-with-SHA224", CategorySignature, "ECDSA", "RFC 5758", 0, 224, StatusAcceptable),
+	builtin("1.2.840.10045.4.3.2", "ecdsa-with-SHA256", CategorySignature, "ECDSA", "RFC 5758", 0, 256, StatusRecommended),
+	builtin("1.2.840.10045.4.3.3", "ecdsa-with-SHA384", CategorySignature, "ECDSA", "RFC 5758", 0, 384, StatusRecommended),
+	builtin("1.2.840.10045.4.3.4", "ecdsa-with-SHA512", CategorySignature, "ECDSA", "RFC 5758", 0, 512, StatusRecommended),
+
+	// DSA
+	builtin("1.2.840.10040.4.1", "dsaEncryption", CategoryPublicKey, "DSA", "RFC 3279", 0, 0, StatusDeprecated),
+	builtin("1.2.840.10040.4.3", "dsa-with-sha1", CategorySignature, "DSA", "RFC 3279", 0, 160, StatusDeprecated),
+	builtin("2.16.840.1.101.3.4.3.1", "dsa-with-sha224", CategorySignature, "DSA", "RFC 5758", 0, 224, StatusDeprecated),
+	builtin("2.16.840.1.101.3.4.3.2", "dsa-with-sha256", CategorySignature, "DSA", "RFC 5758", 0, 256, StatusDeprecated),
+
+	// EdDSA
+	builtin("1.3.101.112", "Ed25519", CategorySignature, "EdDSA", "RFC 8410", 256, 0, StatusRecommended),
+	builtin("1.3.101.113", "Ed448", CategorySignature, "EdDSA", "RFC 8410", 456, 0, StatusRecommended),
+
+	// GOST
+	builtin("1.2.643.2.2.19", "gost3410-2001", CategoryPublicKey, "GOST", "RFC 5832", 256, 0, StatusDeprecated),
+	builtin("1.2.643.7.1.1.1.1", "gost3410-2012-256", CategoryPublicKey, "GOST", "RFC 7091", 256, 0, StatusAcceptable),
+	builtin("1.2.643.7.1.1.1.2", "gost3410-2012-512", CategoryPublicKey, "GOST", "RFC 7091", 512, 0, StatusAcceptable),
+	builtin("1.2.643.2.2.3", "gost3411-94-with-gost3410-2001", CategorySignature, "GOST", "RFC 5832", 256, 256, StatusDeprecated),
+	builtin("1.2.643.7.1.1.3.2", "gost3411-2012-256-with-gost3410-2012-256", CategorySignature, "GOST", "RFC 7091", 256, 256, StatusAcceptable),
+	builtin("1.2.643.7.1.1.3.3", "gost3411-2012-512-with-gost3410-2012-512", CategorySignature, "GOST", "RFC 7091", 512, 512, StatusAcceptable),
+	builtin("1.2.643.2.2.9", "gost3411-94", CategoryHash, "GOST", "RFC 5831", 0, 256, StatusDeprecated),
+	builtin("1.2.643.7.1.1.2.2", "gost3411-2012-256", CategoryHash, "GOST", "RFC 6986", 0, 256, StatusAcceptable),
+	builtin("1.2.643.7.1.1.2.3", "gost3411-2012-512", CategoryHash, "GOST", "RFC 6986", 0, 512, StatusAcceptable),
+	builtin("1.2.643.2.2.21", "gost28147-89", CategoryCipher, "GOST", "RFC 5830", 256, 0, StatusDeprecated),
+	builtin("1.2.643.7.1.1.5.1", "gost3412-2015-magma", CategoryCipher, "GOST", "RFC 8891", 256, 0, StatusAcceptable),
+	builtin("1.2.643.7.1.1.5.2", "gost3412-2015-kuznyechik", CategoryCipher, "GOST", "RFC 7801", 256, 0, StatusAcceptable),
+
+	// Hashes
+	builtin("1.2.840.113549.2.5", "md5", CategoryHash, "MD5", "RFC 1321", 0, 128, StatusBroken),
+	builtin("1.3.14.3.2.26", "sha1", CategoryHash, "SHA-1", "FIPS 180-4", 0, 160, StatusDeprecated),
+	builtin("2.16.840.1.101.3.4.2.1", "sha256", CategoryHash, "SHA-2", "FIPS 180-4", 0, 256, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.2", "sha384", CategoryHash, "SHA-2", "FIPS 180-4", 0, 384, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.3", "sha512", CategoryHash, "SHA-2", "FIPS 180-4", 0, 512, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.4", "sha224", CategoryHash, "SHA-2", "FIPS 180-4", 0, 224, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.2.5", "sha512-224", CategoryHash, "SHA-2", "FIPS 180-4", 0, 224, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.2.6", "sha512-256", CategoryHash, "SHA-2", "FIPS 180-4", 0, 256, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.7", "sha3-224", CategoryHash, "SHA-3", "FIPS 202", 0, 224, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.2.8", "sha3-256", CategoryHash, "SHA-3", "FIPS 202", 0, 256, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.9", "sha3-384", CategoryHash, "SHA-3", "FIPS 202", 0, 384, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.10", "sha3-512", CategoryHash, "SHA-3", "FIPS 202", 0, 512, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.11", "shake128", CategoryHash, "SHA-3", "FIPS 202", 0, 128, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.2.12", "shake256", CategoryHash, "SHA-3", "FIPS 202", 0, 256, StatusRecommended),
+
+	// Curves
+	builtin("1.2.840.10045.3.1.1", "prime192v1", CategoryCurve, "NIST/X9.62", "ANSI X9.62", 192, 0, StatusDeprecated),
+	builtin("1.2.840.10045.3.1.7", "prime256v1", CategoryCurve, "NIST/X9.62", "RFC 5480", 256, 0, StatusRecommended),
+	builtin("1.3.132.0.34", "secp384r1", CategoryCurve, "NIST/SEC", "RFC 5480", 384, 0, StatusRecommended),
+	builtin("1.3.132.0.35", "secp521r1", CategoryCurve, "NIST/SEC", "RFC 5480", 521, 0, StatusRecommended),
+	builtin("1.3.132.0.10", "secp256k1", CategoryCurve, "SEC", "SEC 2", 256, 0, StatusAcceptable),
+	builtin("1.2.840.10045.3.1.2", "prime192v2", CategoryCurve, "X9.62", "ANSI X9.62", 192, 0, StatusDeprecated),
+	builtin("1.2.840.10045.3.1.3", "prime192v3", CategoryCurve, "X9.62", "ANSI X9.62", 192, 0, StatusDeprecated),
+	builtin("1.2.840.10045.3.1.4", "prime239v1", CategoryCurve, "X9.62", "ANSI X9.62", 239, 0, StatusDeprecated),
+	builtin("1.2.840.10045.3.1.5", "prime239v2", CategoryCurve, "X9.62", "ANSI X9.62", 239, 0, StatusDeprecated),
+	builtin("1.2.840.10045.3.1.6", "prime239v3", CategoryCurve, "X9.62", "ANSI X9.62", 239, 0, StatusDeprecated),
+	builtin("1.3.36.3.3.2.8.1.1.7", "brainpoolP256r1", CategoryCurve, "Brainpool", "RFC 5639", 256, 0, StatusAcceptable),
+	builtin("1.3.36.3.3.2.8.1.1.11", "brainpoolP384r1", CategoryCurve, "Brainpool", "RFC 5639", 384, 0, StatusAcceptable),
+	builtin("1.3.36.3.3.2.8.1.1.13", "brainpoolP512r1", CategoryCurve, "Brainpool", "RFC 5639", 512, 0, StatusAcceptable),
+
+	// Post-quantum
+	builtin("2.16.840.1.101.3.4.3.17", "ml-dsa-44", CategorySignature, "ML-DSA", "FIPS 204", 0, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.3.18", "ml-dsa-65", CategorySignature, "ML-DSA", "FIPS 204", 0, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.3.19", "ml-dsa-87", CategorySignature, "ML-DSA", "FIPS 204", 0, 0, StatusRecommended),
+	builtin("1.3.6.1.4.1.2.267.12.4.4", "falcon-512", CategorySignature, "Falcon", "Falcon (NIST round 3)", 0, 0, StatusDraft),
+	builtin("1.3.6.1.4.1.2.267.12.6.5", "falcon-1024", CategorySignature, "Falcon", "Falcon (NIST round 3)", 0, 0, StatusDraft),
+	builtin("2.16.840.1.101.3.4.3.20", "ml-kem-512", CategoryPublicKey, "ML-KEM", "FIPS 203", 0, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.3.21", "ml-kem-768", CategoryPublicKey, "ML-KEM", "FIPS 203", 0, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.3.22", "ml-kem-1024", CategoryPublicKey, "ML-KEM", "FIPS 203", 0, 0, StatusRecommended),
+
+	// Key agreement
+	builtin("1.3.101.110", "X25519", CategoryPublicKey, "ECDH", "RFC 8410", 256, 0, StatusRecommended),
+	builtin("1.3.101.111", "X448", CategoryPublicKey, "ECDH", "RFC 8410", 448, 0, StatusRecommended),
+
+	// Microsoft
+	builtin("1.3.6.1.4.1.311.2.1.4", "spcIndirectDataContent", CategoryContentType, "Authenticode", "Microsoft Authenticode", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.2.1.15", "spcPEImageData", CategoryAttribute, "Authenticode", "Microsoft Authenticode", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.10.3.6", "spcEncryptedDigestRetryCount", CategoryKeyPurpose, "Microsoft", "Microsoft PKI", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.10.3.1", "microsoftCertTrustListSigning", CategoryKeyPurpose, "Microsoft", "Microsoft PKI", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.10.3.4", "microsoftEncryptedFileSystem", CategoryKeyPurpose, "Microsoft", "Microsoft PKI", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.20.2.2", "microsoftSmartcardLogon", CategoryKeyPurpose, "Microsoft", "Microsoft PKI", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.21.19", "microsoftCertificateTemplate", CategoryExtension, "Microsoft", "Microsoft PKI", 0, 0, ""),
+	builtin("1.3.6.1.4.1.311.21.20", "microsoftCertificateManager", CategoryExtension, "Microsoft", "Microsoft PKI", 0, 0, ""),
+
+	// PKCS#7
+	builtin("1.2.840.113549.1.7.1", "pkcs7-data", CategoryContentType, "PKCS#7", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.7.2", "pkcs7-signedData", CategoryContentType, "PKCS#7", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.7.3", "pkcs7-envelopedData", CategoryContentType, "PKCS#7", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.7.4", "pkcs7-signedAndEnvelopedData", CategoryContentType, "PKCS#7", "RFC 2315", 0, 0, ""),
+	builtin("1.2.840.113549.1.7.5", "pkcs7-digestedData", CategoryContentType, "PKCS#7", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.7.6", "pkcs7-encryptedData", CategoryContentType, "PKCS#7", "RFC 5652", 0, 0, ""),
+
+	// PKCS#9
+	builtin("1.2.840.113549.1.9.2", "unstructuredName", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.3", "contentTypes", CategoryAttribute, "PKCS#9", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.4", "messageDigest", CategoryAttribute, "PKCS#9", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.5", "signingTime", CategoryAttribute, "PKCS#9", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.6", "countersignature", CategoryAttribute, "PKCS#9", "RFC 5652", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.7", "challengePassword", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.8", "unstructuredAddress", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.9", "extendedCertificateAttributes", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.14", "extensionReq", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.15", "sMIMECapabilities", CategoryAttribute, "PKCS#9", "RFC 8551", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.16", "sMIMEObjectIdentifier", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.20", "friendlyName", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("1.2.840.113549.1.9.21", "localKeyID", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+
+	// Extensions
+	builtin("2.5.29.14", "subjectKeyIdentifier", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.15", "keyUsage", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.17", "subjectAltName", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.19", "basicConstraints", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.32", "certificatePolicies", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.35", "authorityKeyIdentifier", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+	builtin("2.5.29.37", "extKeyUsage", CategoryExtension, "X.509", "RFC 5280", 0, 0, ""),
+
+	// EKU
+	builtin("1.3.6.1.5.5.7.3.1", "serverAuth", CategoryKeyPurpose, "PKIX", "RFC 5280", 0, 0, ""),
+	builtin("1.3.6.1.5.5.7.3.2", "clientAuth", CategoryKeyPurpose, "PKIX", "RFC 5280", 0, 0, ""),
+	builtin("1.3.6.1.5.5.7.3.3", "codeSigning", CategoryKeyPurpose, "PKIX", "RFC 5280", 0, 0, ""),
+	builtin("1.3.6.1.5.5.7.3.4", "emailProtection", CategoryKeyPurpose, "PKIX", "RFC 5280", 0, 0, ""),
+	builtin("1.3.6.1.5.5.7.3.8", "timeStamping", CategoryKeyPurpose, "PKIX", "RFC 5280", 0, 0, ""),
+
+	// DN
+	builtin(OIDCommonName, "commonName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin(OIDCountryName, "countryName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin(OIDLocalityName, "localityName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin(OIDOrganizationName, "organizationName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin(OIDEmailAddress, "emailAddress", CategoryAttribute, "PKCS#9", "RFC 2985", 0, 0, ""),
+	builtin("2.5.4.4", "surname", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.5", "serialNumber", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.8", "stateOrProvinceName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.9", "streetAddress", CategoryAttribute, "X.520", "RFC 4519", 0, 0, ""),
+	builtin("2.5.4.11", "organizationalUnitName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.12", "title", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.42", "givenName", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.43", "initials", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.44", "generationQualifier", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.46", "dnQualifier", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+	builtin("2.5.4.65", "pseudonym", CategoryAttribute, "X.520", "RFC 5280", 0, 0, ""),
+
+	// Symmetric
+	builtin("2.16.840.1.101.3.4.1.2", "aes128-cbc", CategoryCipher, "AES", "RFC 3565", 128, 0, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.1.6", "aes128-gcm", CategoryCipher, "AES", "RFC 5084", 128, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.1.22", "aes192-cbc", CategoryCipher, "AES", "RFC 3565", 192, 0, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.1.26", "aes192-gcm", CategoryCipher, "AES", "RFC 5084", 192, 0, StatusRecommended),
+	builtin("2.16.840.1.101.3.4.1.42", "aes256-cbc", CategoryCipher, "AES", "RFC 3565", 256, 0, StatusAcceptable),
+	builtin("2.16.840.1.101.3.4.1.46", "aes256-gcm", CategoryCipher, "AES", "RFC 5084", 256, 0, StatusRecommended),
+	builtin("1.2.840.113549.3.2", "rc2-cbc", CategoryCipher, "RC2", "RFC 2268", 0, 0, StatusBroken),
+	builtin("1.2.840.113549.3.4", "rc4", CategoryCipher, "RC4", "RFC 7465", 0, 0, StatusBroken),
+	builtin("1.2.840.113549.1.9.16.3.18", "chacha20-poly1305", CategoryCipher, "ChaCha20", "RFC 8103", 256, 0, StatusRecommended),
+
+	// Policies
+	builtin("2.23.140.1.2.1", "domain-validated", CategoryPolicy, "CA/Browser Forum", "CA/B Forum Baseline Requirements", 0, 0, ""),
+	builtin("2.23.140.1.2.2", "organization-validated", CategoryPolicy, "CA/Browser Forum", "CA/B Forum Baseline Requirements", 0, 0, ""),
+	builtin("2.23.140.1.2.3", "individual-validated", CategoryPolicy, "CA/Browser Forum", "CA/B Forum Baseline Requirements", 0, 0, ""),
+
+	// FIDO
+	builtin("1.3.6.1.4.1.45724.1.1.4", "fido-u2f-transports", CategoryExtension, "FIDO", "FIDO U2F", 0, 0, ""),
+	builtin("1.3.6.1.4.1.45724.2.1.1", "fido-authenticator-aaguid", CategoryExtension, "FIDO", "WebAuthn", 0, 0, ""),
+
+	// SM
+	builtin("1.2.156.10197.1.301", "sm2", CategoryPublicKey, "SM", "GB/T 32918", 256, 0, StatusAcceptable),
+	builtin("1.2.156.10197.1.401", "sm3", CategoryHash, "SM", "GB/T 32905", 0, 256, StatusAcceptable),
+	builtin("1.2.156.10197.1.104.1", "sm4-ecb", CategoryCipher, "SM", "GB/T 32907", 128, 0, StatusDeprecated),
+	builtin("1.2.156.10197.1.104.2", "sm4-cbc", CategoryCipher, "SM", "GB/T 32907", 128, 0, StatusAcceptable),
+
+	// Camellia
+	builtin("1.2.392.200011.61.1.1.1.2", "camellia128-cbc", CategoryCipher, "Camellia", "RFC 3657", 128, 0, StatusAcceptable),
+	builtin("1.2.392.200011.61.1.1.1.3", "camellia192-cbc", CategoryCipher, "Camellia", "RFC 3657", 192, 0, StatusAcceptable),
+	builtin("1.2.392.200011.61.1.1.1.4", "camellia256-cbc", CategoryCipher, "Camellia", "RFC 3657", 256, 0, StatusAcceptable),
+
+	// Legacy
+	builtin("1.2.840.113549.3.7", "des-ede3-cbc", CategoryCipher, "3DES", "RFC 2630", 168, 0, StatusDeprecated),
+	builtin("2.16.840.1.101.2.1.1.2", "fortezzaDSS", CategorySignature, "Fortezza", "SDN.701", 0, 0, StatusDeprecated),
+	builtin("1.2.840.113549.3.1", "rc4-40", CategoryCipher, "RC4", "RFC 7465", 40, 0, StatusBroken),
+}
+
+// Common OID mappings for display
+var oidNames = catalogueNames(builtinCatalogue)
+
+// catalogueNames maps each OID of a catalogue to its name
+func catalogueNames(entries []CatalogueEntry) map[string]string {
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[entry.OID] = entry.Name
+	}
+	return names
+}
+
+// catalogueEntries returns the built-in catalogue with entries loaded from
+// OID files applied: a loaded entry renames a built-in OID (and recategorises
+// it when it names a category) or adds a new one at the end
+func catalogueEntries() []CatalogueEntry {
+	entries := make([]CatalogueEntry, 0, len(builtinCatalogue)+len(oidUserEntries))
+	seen := make(map[string]bool, len(builtinCatalogue))
+	for _, entry := range builtinCatalogue {
+		if loaded, exists := oidUserEntries[entry.OID]; exists {
+			entry.Name = loaded.Name
+			entry.Description = loaded.Description
+			entry.Source = loaded.Source
+			if loaded.Category != "" {
+				entry.Category = loaded.Category
+			}
+		}
+		entries = append(entries, entry)
+		seen[entry.OID] = true
+	}
+
+	var added []CatalogueEntry
+	for oid, loaded := range oidUserEntries {
+		if seen[oid] {
+			continue
+		}
+		category := loaded.Category
+		if category == "" {
+			category = CategoryOther
+		}
+		added = append(added, CatalogueEntry{
+			OID:         oid,
+			Name:        loaded.Name,
+			Category:    category,
+			Description: loaded.Description,
+			Source:      loaded.Source,
+		})
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].OID < added[j].OID })
+	return append(entries, added...)
+}
+
+// filterCatalogue keeps the entries whose category or family contains the
+// filter, compared like OID names; an empty filter keeps everything
+func filterCatalogue(entries []CatalogueEntry, filter string) []CatalogueEntry {
+	needle := normalizeOIDName(filter)
+	if needle == "" {
+		return entries
+	}
+	var filtered []CatalogueEntry
+	for _, entry := range entries {
+		if strings.Contains(normalizeOIDName(entry.Category), needle) ||
+			strings.Contains(normalizeOIDName(entry.Family), needle) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// writeCatalogueText writes the human-readable listing, one section per
+// category in catalogue order followed by categories only found in OID files
+func writeCatalogueText(w io.Writer, entries []CatalogueEntry) {
+	byCategory := make(map[string][]CatalogueEntry)
+	for _, entry := range entries {
+		byCategory[entry.Category] = append(byCategory[entry.Category], entry)
+	}
+
+	type section struct{ category, title string }
+	var sections []section
+	known := make(map[string]bool)
+	for _, c := range catalogueCategories {
+		sections = append(sections, section{c.Category, c.Title})
+		known[c.Category] = true
+	}
+	var extra []string
+	for category := range byCategory {
+		if !known[category] {
+			extra = append(extra, category)
+		}
+	}
+	sort.Strings(extra)
+	for _, category := range extra {
+		sections = append(sections, section{category, "📁 " + category})
+	}
+
+	for _, s := range sections {
+		group := byCategory[s.category]
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", s.title, len(group))
+		for _, entry := range group {
+			line := fmt.Sprintf("  %s → %s", entry.OID, entry.Name)
+			if details := catalogueDetails(entry); details != "" {
+				line += fmt.Sprintf("  (%s)", details)
+			}
+			if entry.Description != "" {
+				line += fmt.Sprintf(" - %s", entry.Description)
+			}
+			fmt.Fprintf(w, "%s  [%s]\n", line, entry.Source)
+		}
+	}
+}
+
+// catalogueDetails summarises the metadata of an entry for the text listing
+func catalogueDetails(entry CatalogueEntry) string {
+	var parts []string
+	if entry.Family != "" {
+		parts = append(parts, entry.Family)
+	}
+	if entry.Standard != "" {
+		parts = append(parts, entry.Standard)
+	}
+	if entry.KeySize > 0 {
+		parts = append(parts, fmt.Sprintf("%d-bit key", entry.KeySize))
+	}
+	if entry.HashSize > 0 {
+		parts = append(parts, fmt.Sprintf("%d-bit hash", entry.HashSize))
+	}
+	if entry.Status != "" {
+		parts = append(parts, entry.Status)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeCatalogueJSON writes the entries as an indented JSON array
+func writeCatalogueJSON(w io.Writer, entries []CatalogueEntry) error {
+	if entries == nil {
+		entries = []CatalogueEntry{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(entries)
+}
+
+// writeCatalogueCSV writes the entries as CSV with a header row; unknown sizes
+// are left empty
+func writeCatalogueCSV(w io.Writer, entries []CatalogueEntry) error {
+	size := func(bits int) string {
+		if bits == 0 {
+			return ""
+		}
+		return strconv.Itoa(bits)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"oid", "name", "category", "family", "standard", "key_size", "hash_size", "status", "description", "source"})
+	for _, entry := range entries {
+		cw.Write([]string{
+			entry.OID, entry.Name, entry.Category, entry.Family, entry.Standard,
+			size(entry.KeySize), size(entry.HashSize), entry.Status, entry.Description, entry.Source,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestBuiltinCatalogueConsistency tests that every built-in entry is unique,
+// categorised and reflected in oidNames
+func TestBuiltinCatalogueConsistency(t *testing.T) {
+	known := make(map[string]bool)
+	for _, c := range catalogueCategories {
+		known[c.Category] = true
+	}
+	statuses := map[string]bool{
+		"": true, StatusRecommended: true, StatusAcceptable: true,
+		StatusDeprecated: true, StatusBroken: true, StatusDraft: true,
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range builtinCatalogue {
+		if seen[entry.OID] {
+			t.Errorf("Duplicate catalogue entry for %s", entry.OID)
+		}
+		seen[entry.OID] = true
+
+		if !isDottedOID(entry.OID) {
+			t.Errorf("Invalid OID %q", entry.OID)
+		}
+		if !known[entry.Category] {
+			t.Errorf("%s: unknown category %q", entry.OID, entry.Category)
+		}
+		if !statuses[entry.Status] {
+			t.Errorf("%s: unknown status %q", entry.OID, entry.Status)
+		}
+		if entry.Standard == "" {
+			t.Errorf("%s: missing standard reference", entry.OID)
+		}
+		if oidNames[entry.OID] != entry.Name {
+			t.Errorf("%s: oidNames has %q, catalogue has %q", entry.OID, oidNames[entry.OID], entry.Name)
+		}
+	}
+	if len(seen) != len(catalogueNames(builtinCatalogue)) {
+		t.Errorf("Expected %d names, got %d", len(seen), len(catalogueNames(builtinCatalogue)))
+	}
+}
+
+// TestCatalogueEntriesWithLoadedOIDs tests that OID files rename built-ins and add new entries
+func TestCatalogueEntriesWithLoadedOIDs(t *testing.T) {
+	restoreOIDRegistry(t)
+
+	registerOIDs([]OIDEntry{
+		{OID: "2.16.840.1.101.3.4.2.1", Name: "SHA-256", Source: "corp.oids"},
+		{OID: "1.3.6.1.4.1.99999.1", Name: "examplePolicy", Description: "Example policy", Category: "policy", Source: "corp.oids"},
+		{OID: "1.3.6.1.4.1.99999.2", Name: "exampleThing", Source: "corp.oids"},
+	})
+
+	entries := catalogueEntries()
+	if len(entries) != len(oidNames) {
+		t.Errorf("Expected %d entries, got %d", len(oidNames), len(entries))
+	}
+
+	byOID := make(map[string]CatalogueEntry)
+	for _, entry := range entries {
+		byOID[entry.OID] = entry
+	}
+
+	sha256 := byOID["2.16.840.1.101.3.4.2.1"]
+	if sha256.Name != "SHA-256" || sha256.Source != "corp.oids" || sha256.Category != CategoryHash || sha256.HashSize != 256 {
+		t.Errorf("Expected renamed built-in to keep its metadata, got %+v", sha256)
+	}
+	if policy := byOID["1.3.6.1.4.1.99999.1"]; policy.Category != CategoryPolicy || policy.Description != "Example policy" {
+		t.Errorf("Expected loaded policy entry, got %+v", policy)
+	}
+	if other := byOID["1.3.6.1.4.1.99999.2"]; other.Category != CategoryOther {
+		t.Errorf("Expected uncategorised entry in %q, got %+v", CategoryOther, other)
+	}
+
+	var buf bytes.Buffer
+	writeCatalogueText(&buf, filterCatalogue(entries, "policy"))
+	output := buf.String()
+	if !strings.Contains(output, "📜 Certificate Policies (4):") {
+		t.Errorf("Expected policy section with 4 entries, got:\n%s", output)
+	}
+	if !strings.Contains(output, "1.3.6.1.4.1.99999.1 → examplePolicy - Example policy  [corp.oids]") {
+		t.Errorf("Expected loaded entry in listing, got:\n%s", output)
+	}
+	if strings.Contains(output, "exampleThing") {
+		t.Errorf("Expected uncategorised entry to be filtered out, got:\n%s", output)
+	}
+}
+
+// TestFilterCatalogue tests filtering by category and family
+func TestFilterCatalogue(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected int
+	}{
+		{"", len(builtinCatalogue)},
+		{"curve", 13},
+		{"EdDSA", 2},
+		{"gost", 12},
+		{"key-purpose", 9},
+		{"nonexistent", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			if got := len(filterCatalogue(builtinCatalogue, tt.filter)); got != tt.expected {
+				t.Errorf("Expected %d entries, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestWriteCatalogueMachineReadable tests the JSON and CSV exports
+func TestWriteCatalogueMachineReadable(t *testing.T) {
+	entries := filterCatalogue(builtinCatalogue, "EdDSA")
+
+	var jsonBuf bytes.Buffer
+	if err := writeCatalogueJSON(&jsonBuf, entries); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded []CatalogueEntry
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].Name != "Ed25519" || decoded[0].KeySize != 256 || decoded[0].Source != OIDSourceBuiltin {
+		t.Errorf("Unexpected JSON entries: %+v", decoded)
+	}
+
+	jsonBuf.Reset()
+	if err := writeCatalogueJSON(&jsonBuf, nil); err != nil || strings.TrimSpace(jsonBuf.String()) != "[]" {
+		t.Errorf("Expected empty JSON array, got %q (%v)", jsonBuf.String(), err)
+	}
+
+	var csvBuf bytes.Buffer
+	if err := writeCatalogueCSV(&csvBuf, entries); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(&csvBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d records", len(records))
+	}
+	if records[0][0] != "oid" || records[0][5] != "key_size" {
+		t.Errorf("Unexpected header: %v", records[0])
+	}
+	expected := []string{"1.3.101.112", "Ed25519", "signature", "EdDSA", "RFC 8410", "256", "", "recommended", "", "built-in"}
+	if strings.Join(records[1], "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected row %v, got %v", expected, records[1])
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression formats recognised by detectCompression
+const (
+	CompressionGzip  = "gzip"
+	CompressionBzip2 = "bzip2"
+	CompressionZlib  = "zlib"
+	CompressionXz    = "xz"
+	CompressionZstd  = "zstd"
+)
+
+// maxCompressionLayers bounds nested containers such as a gzipped xz stream
+const maxCompressionLayers = 4
+
+// Magic bytes of the compression formats
+var (
+	magicGzip  = []byte{0x1F, 0x8B}
+	magicBzip2 = []byte("BZh")
+	magicXz    = []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}
+	magicZstd  = []byte{0x28, 0xB5, 0x2F, 0xFD}
+)
+
+// CompressionLayer records one decompression step applied to an input
+type CompressionLayer struct {
+	Format           string
+	CompressedSize   int
+	DecompressedSize int
+}
+
+// detectCompression identifies a compressed stream by its magic bytes. zlib
+// has no real magic, so it is only recognised by a valid deflate header, and
+// decompressLayers keeps the raw bytes unless the whole input decodes.
+func detectCompression(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, magicGzip):
+		return CompressionGzip
+	case bytes.HasPrefix(data, magicXz):
+		return CompressionXz
+	case bytes.HasPrefix(data, magicZstd):
+		return CompressionZstd
+	case bytes.HasPrefix(data, magicBzip2) && len(data) > 3 && data[3] >= '1' && data[3] <= '9':
+		return CompressionBzip2
+	case len(data) >= 2 && data[0] == 0x78 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0:
+		return CompressionZlib
+	}
+	return ""
+}
+
+// decompressLayers unwraps compressed containers until plain data remains,
+// never producing more than max bytes per layer. Data without a recognised
+// container is returned unchanged with no layers.
+func decompressLayers(data []byte, max int64) ([]byte, []CompressionLayer, error) {
+	var layers []CompressionLayer
+	for len(layers) < maxCompressionLayers {
+		format := detectCompression(data)
+		if format == "" {
+			break
+		}
+
+		inner, err := decompress(format, data, max)
+		if err != nil {
+			// A zlib header is only two bytes and easily matched by chance,
+			// e.g. by DER or PE input: keep the raw bytes
+			if format == CompressionZlib {
+				break
+			}
+			return nil, nil, fmt.Errorf("error decompressing %s data: %w", format, err)
+		}
+		layers = append(layers, CompressionLayer{
+			Format:           format,
+			CompressedSize:   len(data),
+			DecompressedSize: len(inner),
+		})
+		data = inner
+	}
+	return data, layers, nil
+}
+
+// decompress decodes one stream, gzip, bzip2 and zlib with the standard
+// library and xz and zstd with pure Go decoders
+func decompress(format string, data []byte, max int64) ([]byte, error) {
+	var r io.Reader
+	switch format {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case CompressionZlib:
+		return decompressZlib(data, max)
+	case CompressionBzip2:
+		r = bzip2.NewReader(bytes.NewReader(data))
+	case CompressionXz:
+		xr, err := xz.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		r = xr
+	case CompressionZstd:
+		// Bound the window too, or a crafted frame header could make the
+		// decoder allocate more than max before producing any output
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(uint64(max)))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		out, err := readLimited(zr, max)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, sizeLimitError(max)
+		}
+		return out, err
+	default:
+		return nil, fmt.Errorf("unsupported compression %q, decompress the input first", format)
+	}
+	return readLimited(r, max)
+}
+
+// decompressZlib decodes a zlib stream that must span all of data, so that
+// input which only starts with a plausible zlib header is not mistaken for it
+func decompressZlib(data []byte, max int64) ([]byte, error) {
+	br := bytes.NewReader(data)
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := readLimited(zr, max)
+	if err != nil {
+		return nil, err
+	}
+	if br.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes after the zlib stream", br.Len())
+	}
+	return out, nil
+}
+
+// describeLayers renders the decompression chain, e.g. "gzip → xz"
+func describeLayers(layers []CompressionLayer) string {
+	formats := make([]string, len(layers))
+	for i, layer := range layers {
+		formats[i] = layer.Format
+	}
+	return strings.Join(formats, " → ")
+}
+
+// describeOffset locates an offset found in possibly decompressed data. The
+// outermost container always starts at file offset 0; offsets inside it refer
+// to the fully decompressed payload.
+func describeOffset(offset int, layers []CompressionLayer) string {
+	if len(layers) == 0 {
+		return fmt.Sprintf("offset %d", offset)
+	}
+	return fmt.Sprintf("offset %d of the decompressed payload (%s container at file offset 0)", offset, describeLayers(layers))
+}
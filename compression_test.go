@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+)
+
+// compressionPayload is a small signature-like structure used as plain text
+var compressionPayload = bytes.Repeat([]byte{0x30, 0x03, 0x02, 0x01, 0x05}, 4)
+
+// TestDetectCompression tests magic byte detection
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"gzip", []byte{0x1F, 0x8B, 0x08, 0x00}, CompressionGzip},
+		{"bzip2", []byte("BZh91AY"), CompressionBzip2},
+		{"bzip2 bad level", []byte("BZh0"), ""},
+		{"xz", []byte{0xFD, '7', 'z', 'X', 'Z', 0x00, 0x00}, CompressionXz},
+		{"zstd", []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00}, CompressionZstd},
+		{"zlib", []byte{0x78, 0x9C}, CompressionZlib},
+		{"zlib bad check", []byte{0x78, 0x9D}, ""},
+		{"DER", []byte{0x30, 0x82, 0x01, 0x00}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := detectCompression(tt.data); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestDecompressLayers tests the standard library formats, nesting and plain input
+func TestDecompressLayers(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(compressionPayload)
+	gw.Close()
+
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(gz.Bytes())
+	zw.Close()
+
+	// python3 -c "import bz2; print(bz2.compress(bytes.fromhex('3003020105') * 4).hex())"
+	bz, _ := hex.DecodeString("425a68393141592653591da4f4fb000009c8003a004000200030cd005534690d29c53c5dc914e142407693d3ec")
+
+	tests := []struct {
+		name   string
+		data   []byte
+		chain  string
+		layers int
+	}{
+		{"gzip", gz.Bytes(), "gzip", 1},
+		{"bzip2", bz, "bzip2", 1},
+		{"zlib wrapping gzip", zl.Bytes(), "zlib → gzip", 2},
+		{"plain", compressionPayload, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, layers, err := decompressLayers(tt.data, DefaultMaxInputSize)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(data, compressionPayload) {
+				t.Errorf("Expected payload %x, got %x", compressionPayload, data)
+			}
+			if len(layers) != tt.layers || describeLayers(layers) != tt.chain {
+				t.Errorf("Expected chain %q, got %q", tt.chain, describeLayers(layers))
+			}
+		})
+	}
+}
+
+// TestDecompressBomb tests that decompression stops at the size limit
+func TestDecompressBomb(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(make([]byte, 1<<20))
+	gw.Close()
+
+	_, _, err := decompressLayers(gz.Bytes(), 64<<10)
+	if err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+
+	corrupt := append([]byte{}, gz.Bytes()[:20]...)
+	if _, _, err := decompressLayers(corrupt, DefaultMaxInputSize); err == nil {
+		t.Errorf("Expected error for truncated gzip stream")
+	}
+}
+
+// TestDecompressModules tests xz and zstd kernel modules compressed the way
+// kbuild does. The fixtures are an object file with a .modinfo section,
+// signed like scripts/sign-file but keeping the certificate:
+//
+//	openssl cms -sign -binary -noattr -nosmimecap -md sha256 -outform DER ...
+//	xz --check=crc32 --lzma2=dict=1MiB
+//	zstd -T0 -19
+func TestDecompressModules(t *testing.T) {
+	for _, tt := range []struct {
+		file   string
+		format string
+	}{
+		{"testfiles/modules/hello.ko.xz", CompressionXz},
+		{"testfiles/modules/hello.ko.zst", CompressionZstd},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Skipf("%s not available", tt.file)
+			}
+			module, layers, err := decompressLayers(data, DefaultMaxInputSize)
+			if err != nil || describeLayers(layers) != tt.format {
+				t.Fatalf("Expected a %s layer, got %q, err %v", tt.format, describeLayers(layers), err)
+			}
+			p7, err := embeddedPKCS7(module)
+			if err != nil {
+				t.Fatalf("Failed to parse the module signature: %v", err)
+			}
+			if check, err := checkSignature(module, p7); err != nil || check != "module signature over the module content" {
+				t.Errorf("Expected the module signature to verify, got %q, %v", check, err)
+			}
+
+			if _, _, err := decompressLayers(data, 1024); err == nil || !strings.Contains(err.Error(), "byte limit") {
+				t.Errorf("Expected size limit error, got %v", err)
+			}
+			if _, _, err := decompressLayers(data[:len(data)/2], DefaultMaxInputSize); err == nil {
+				t.Errorf("Expected error for a truncated %s stream", tt.format)
+			}
+		})
+	}
+}
+
+// TestDecompressZlibFallback tests that input only starting like zlib is kept
+func TestDecompressZlibFallback(t *testing.T) {
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(compressionPayload)
+	zw.Close()
+
+	for name, data := range map[string][]byte{
+		"corrupt stream": append([]byte{0x78, 0x9C}, compressionPayload...),
+		"trailing data":  append(zl.Bytes(), compressionPayload...),
+	} {
+		out, layers, err := decompressLayers(data, DefaultMaxInputSize)
+		if err != nil || len(layers) != 0 || !bytes.Equal(out, data) {
+			t.Errorf("%s: expected the raw bytes back, got %d layers, err %v", name, len(layers), err)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Kinds of change reported by diff, from the first signature to the second
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// ASN1Node is a parsed ASN.1 element with its encoding and, for
+// constructed elements whose content parses, its children
+type ASN1Node struct {
+	ASN1Element
+	Raw      []byte
+	Children []*ASN1Node
+}
+
+// DiffSource describes where a compared signature was found
+type DiffSource struct {
+	File   string `json:"file"`
+	Offset int    `json:"offset"`
+	Size   int    `json:"size"`
+}
+
+// CertificateDiff is a certificate present in only one signature
+type CertificateDiff struct {
+	Change      string `json:"change"`
+	Subject     string `json:"subject"`
+	Issuer      string `json:"issuer"`
+	Serial      string `json:"serial"`
+	Fingerprint string `json:"sha256"`
+}
+
+// FieldDiff is a differing algorithm or other SignedData field
+type FieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// AttributeDiff is a signed attribute that differs for a signer; A or B is
+// empty when the attribute is missing on that side
+type AttributeDiff struct {
+	Signer int    `json:"signer"`
+	OID    string `json:"oid"`
+	Name   string `json:"name,omitempty"`
+	Change string `json:"change"`
+	A      string `json:"a,omitempty"`
+	B      string `json:"b,omitempty"`
+}
+
+// ElementDiff is a TLV difference at a path of child indices. Offsets are
+// absolute in each file, -1 when the element exists only on the other side.
+type ElementDiff struct {
+	Path    string `json:"path"`
+	Change  string `json:"change"`
+	OffsetA int    `json:"offset_a"`
+	OffsetB int    `json:"offset_b"`
+	A       string `json:"a,omitempty"`
+	B       string `json:"b,omitempty"`
+}
+
+// SignatureDiff is the structural comparison of two signatures
+type SignatureDiff struct {
+	A            DiffSource        `json:"a"`
+	B            DiffSource        `json:"b"`
+	Certificates []CertificateDiff `json:"certificates"`
+	Algorithms   []FieldDiff       `json:"algorithms"`
+	Attributes   []AttributeDiff   `json:"signed_attributes"`
+	Elements     []ElementDiff     `json:"elements"`
+}
+
+// Identical reports whether no differences were found
+func (d SignatureDiff) Identical() bool {
+	return len(d.Certificates)+len(d.Algorithms)+len(d.Attributes)+len(d.Elements) == 0
+}
+
+// parseASN1Tree parses consecutive elements of data, which starts at
+// offset in its file, with the content ASN1Displayer prints for them.
+// Constructed elements whose content does not parse are kept as leaves.
+func parseASN1Tree(data []byte, offset, depth int) ([]*ASN1Node, error) {
+	return parseASN1Nodes(data, offset, depth, structureOther)
+}
+
+// parseASN1Nodes parses the elements inside an element of the given
+// structure, structureOther at the top level
+func parseASN1Nodes(data []byte, offset, depth, structure int) ([]*ASN1Node, error) {
+	var nodes []*ASN1Node
+	for pos := 0; pos < len(data); {
+		element, n, err := parseASN1Element(data[pos:], depth, offset+pos)
+		if err != nil {
+			return nil, err
+		}
+		if element.Class == 0 && element.Tag == TagInteger && !element.IsCompound {
+			if note := integerAnnotation(data[pos+element.HeaderLen:pos+n], structure, len(nodes)); note != "" {
+				element.Content += " " + note
+			}
+		}
+		node := &ASN1Node{ASN1Element: element, Raw: data[pos : pos+n]}
+		if element.IsCompound && element.Length > 0 {
+			content := node.Raw[element.HeaderLen:]
+			node.Children, _ = parseASN1Nodes(content, offset+pos+element.HeaderLen, depth+1, classifyStructure(&element, content))
+		}
+		nodes = append(nodes, node)
+		pos += n
+	}
+	return nodes, nil
+}
+
+// describe summarizes an element as its tag name and content
+func (e ASN1Element) describe() string {
+	if e.IsCompound {
+		return fmt.Sprintf("%s (%d bytes)", e.TagName, e.Length)
+	}
+	if e.Content == "" {
+		return e.TagName
+	}
+	return e.TagName + " " + e.Content
+}
+
+// loadDiffSignature reads a file (binary, DER, PEM or base64) and returns
+// the first SignedData in it with its offset
+func loadDiffSignature(fh FileHandler, path string) (*PKCS7, DiffSource, error) {
+	source := DiffSource{File: inputName(path)}
+	input, err := fh.Open(path)
+	if err != nil {
+		return nil, source, err
+	}
+	defer input.Close()
+
+	// The input may be memory-mapped and unmapped on Close
+	data := append([]byte{}, input.Bytes()...)
+	blocks, err := decodeTextInput(data)
+	if err != nil {
+		return nil, source, err
+	}
+	if len(blocks) > 0 {
+		data = blocks[0].Bytes
+	}
+	p7, offset, err := FindPKCS7(data)
+	if err != nil {
+		return nil, source, err
+	}
+	source.Offset, source.Size = offset, len(p7.Raw)
+	return p7, source, nil
+}
+
+// diffSignatures compares two parsed signatures
+func diffSignatures(a, b *PKCS7, sourceA, sourceB DiffSource) (SignatureDiff, error) {
+	diff := SignatureDiff{
+		A:            sourceA,
+		B:            sourceB,
+		Certificates: diffCertificates(a.Certificates, b.Certificates),
+		Algorithms:   diffAlgorithms(a, b),
+		Attributes:   diffAttributes(a.Signers, b.Signers),
+	}
+
+	treeA, err := parseASN1Tree(a.Raw, sourceA.Offset, 0)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", sourceA.File, err)
+	}
+	treeB, err := parseASN1Tree(b.Raw, sourceB.Offset, 0)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", sourceB.File, err)
+	}
+	diff.Elements = diffNodeLists("", treeA, treeB, []ElementDiff{})
+	return diff, nil
+}
+
+// diffCertificates lists certificates found in only one of the signatures,
+// matched by fingerprint
+func diffCertificates(a, b []*x509.Certificate) []CertificateDiff {
+	diffs := []CertificateDiff{}
+	onlyIn := func(certs, other []*x509.Certificate, change string) {
+		seen := make(map[[32]byte]bool)
+		for _, cert := range other {
+			seen[sha256.Sum256(cert.Raw)] = true
+		}
+		for _, cert := range certs {
+			fingerprint := sha256.Sum256(cert.Raw)
+			if seen[fingerprint] {
+				continue
+			}
+			diffs = append(diffs, CertificateDiff{
+				Change:      change,
+				Subject:     cert.Subject.String(),
+				Issuer:      cert.Issuer.String(),
+				Serial:      cert.SerialNumber.Text(16),
+				Fingerprint: hex.EncodeToString(fingerprint[:]),
+			})
+		}
+	}
+	onlyIn(a, b, DiffRemoved)
+	onlyIn(b, a, DiffAdded)
+	return diffs
+}
+
+// oidDisplayName returns "name (oid)" for known OIDs and the OID otherwise
+func oidDisplayName(oid asn1.ObjectIdentifier) string {
+	if name, ok := oidNames[oid.String()]; ok {
+		return fmt.Sprintf("%s (%s)", name, oid)
+	}
+	return oid.String()
+}
+
+// diffAlgorithms compares the content type and, signer by signer, the
+// digest and signature algorithms
+func diffAlgorithms(a, b *PKCS7) []FieldDiff {
+	diffs := []FieldDiff{}
+	add := func(field, valueA, valueB string) {
+		if valueA != valueB {
+			diffs = append(diffs, FieldDiff{Field: field, A: valueA, B: valueB})
+		}
+	}
+	add("contentType", oidDisplayName(a.ContentType), oidDisplayName(b.ContentType))
+	add("signers", strconv.Itoa(len(a.Signers)), strconv.Itoa(len(b.Signers)))
+	for i := range min(len(a.Signers), len(b.Signers)) {
+		sa, sb := a.Signers[i], b.Signers[i]
+		add(fmt.Sprintf("signer[%d].digestAlgorithm", i),
+			oidDisplayName(sa.DigestAlgorithm.Algorithm), oidDisplayName(sb.DigestAlgorithm.Algorithm))
+		add(fmt.Sprintf("signer[%d].signatureAlgorithm", i),
+			oidDisplayName(sa.SignatureAlgorithm.Algorithm), oidDisplayName(sb.SignatureAlgorithm.Algorithm))
+	}
+	return diffs
+}
+
+// diffAttributes compares the signed attributes of signers at the same index
+func diffAttributes(a, b []PKCS7Signer) []AttributeDiff {
+	diffs := []AttributeDiff{}
+	for i := range min(len(a), len(b)) {
+		valuesA, valuesB := attributeValues(a[i]), attributeValues(b[i])
+		var order []string
+		for _, attr := range a[i].Attributes {
+			order = append(order, attr.Type.String())
+		}
+		for _, attr := range b[i].Attributes {
+			if _, ok := valuesA[attr.Type.String()]; !ok {
+				order = append(order, attr.Type.String())
+			}
+		}
+
+		for _, oid := range order {
+			valueA, inA := valuesA[oid]
+			valueB, inB := valuesB[oid]
+			if inA && inB && bytes.Equal(valueA, valueB) {
+				continue
+			}
+			entry := AttributeDiff{Signer: i, OID: oid, Name: oidNames[oid], Change: DiffChanged}
+			switch {
+			case !inA:
+				entry.Change = DiffAdded
+			case !inB:
+				entry.Change = DiffRemoved
+			}
+			entry.A, entry.B = describeAttributeValue(valueA), describeAttributeValue(valueB)
+			diffs = append(diffs, entry)
+		}
+	}
+	return diffs
+}
+
+// attributeValues maps attribute OIDs to the concatenated DER of their values
+func attributeValues(signer PKCS7Signer) map[string][]byte {
+	values := make(map[string][]byte)
+	for _, attr := range signer.Attributes {
+		var der []byte
+		for _, value := range attr.Values {
+			der = append(der, value.FullBytes...)
+		}
+		values[attr.Type.String()] = der
+	}
+	return values
+}
+
+// describeAttributeValue describes the first element of attribute values
+func describeAttributeValue(der []byte) string {
+	if len(der) == 0 {
+		return ""
+	}
+	nodes, err := parseASN1Tree(der, 0, 0)
+	if err != nil || len(nodes) == 0 {
+		return hex.EncodeToString(der)
+	}
+	if len(nodes) > 1 {
+		return fmt.Sprintf("%s (+%d values)", nodes[0].describe(), len(nodes)-1)
+	}
+	return nodes[0].describe()
+}
+
+// diffNodeLists compares sibling elements position by position. Elements
+// with identical encodings are skipped, constructed elements with the same
+// tag are compared child by child, and anything else is reported as a
+// change of the whole element.
+func diffNodeLists(path string, a, b []*ASN1Node, diffs []ElementDiff) []ElementDiff {
+	for i := range max(len(a), len(b)) {
+		childPath := strconv.Itoa(i)
+		if path != "" {
+			childPath = path + "." + childPath
+		}
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ElementDiff{Path: childPath, Change: DiffAdded, OffsetA: -1, OffsetB: b[i].Offset, B: b[i].describe()})
+		case i >= len(b):
+			diffs = append(diffs, ElementDiff{Path: childPath, Change: DiffRemoved, OffsetA: a[i].Offset, OffsetB: -1, A: a[i].describe()})
+		default:
+			diffs = diffNodes(childPath, a[i], b[i], diffs)
+		}
+	}
+	return diffs
+}
+
+// diffNodes compares two elements at the same path
+func diffNodes(path string, a, b *ASN1Node, diffs []ElementDiff) []ElementDiff {
+	if bytes.Equal(a.Raw, b.Raw) {
+		return diffs
+	}
+	sameTag := a.Tag == b.Tag && a.Class == b.Class && a.IsCompound == b.IsCompound
+	if sameTag && a.Children != nil && b.Children != nil {
+		before := len(diffs)
+		diffs = diffNodeLists(path, a.Children, b.Children, diffs)
+		if len(diffs) > before {
+			return diffs
+		}
+		// Only the header differs, e.g. a non-minimal length encoding
+	}
+	return append(diffs, ElementDiff{
+		Path: path, Change: DiffChanged, OffsetA: a.Offset, OffsetB: b.Offset,
+		A: a.describe(), B: b.describe(),
+	})
+}
+
+// printSignatureDiff writes the human-readable report
+func printSignatureDiff(w io.Writer, d SignatureDiff) {
+	fmt.Fprintf(w, "--- %s (SignedData at offset %d, %d bytes)\n", d.A.File, d.A.Offset, d.A.Size)
+	fmt.Fprintf(w, "+++ %s (SignedData at offset %d, %d bytes)\n", d.B.File, d.B.Offset, d.B.Size)
+	if d.Identical() {
+		fmt.Fprintf(w, "\nSignatures are identical\n")
+		return
+	}
+
+	marks := map[string]string{DiffAdded: "+", DiffRemoved: "-", DiffChanged: "~"}
+	if len(d.Certificates) > 0 {
+		fmt.Fprintf(w, "\nCertificates:\n")
+		for _, c := range d.Certificates {
+			fmt.Fprintf(w, "  %s %s\n", marks[c.Change], c.Subject)
+			fmt.Fprintf(w, "      issuer %s, serial %s\n", c.Issuer, c.Serial)
+			fmt.Fprintf(w, "      SHA-256 %s\n", c.Fingerprint)
+		}
+	}
+	if len(d.Algorithms) > 0 {
+		fmt.Fprintf(w, "\nAlgorithms:\n")
+		for _, f := range d.Algorithms {
+			fmt.Fprintf(w, "  ~ %s: %s → %s\n", f.Field, f.A, f.B)
+		}
+	}
+	if len(d.Attributes) > 0 {
+		fmt.Fprintf(w, "\nSigned attributes:\n")
+		for _, a := range d.Attributes {
+			name := a.OID
+			if a.Name != "" {
+				name = fmt.Sprintf("%s (%s)", a.Name, a.OID)
+			}
+			switch a.Change {
+			case DiffAdded:
+				fmt.Fprintf(w, "  + signer[%d] %s: %s\n", a.Signer, name, a.B)
+			case DiffRemoved:
+				fmt.Fprintf(w, "  - signer[%d] %s: %s\n", a.Signer, name, a.A)
+			default:
+				fmt.Fprintf(w, "  ~ signer[%d] %s: %s → %s\n", a.Signer, name, a.A, a.B)
+			}
+		}
+	}
+	if len(d.Elements) > 0 {
+		fmt.Fprintf(w, "\nElements (path: offset in %s / %s):\n", d.A.File, d.B.File)
+		for _, e := range d.Elements {
+			switch e.Change {
+			case DiffAdded:
+				fmt.Fprintf(w, "  + %s: -/%d %s\n", e.Path, e.OffsetB, e.B)
+			case DiffRemoved:
+				fmt.Fprintf(w, "  - %s: %d/- %s\n", e.Path, e.OffsetA, e.A)
+			default:
+				fmt.Fprintf(w, "  ~ %s: %d/%d %s\n", e.Path, e.OffsetA, e.OffsetB, e.A)
+				fmt.Fprintf(w, "  %s  → %s\n", strings.Repeat(" ", len(e.Path)), e.B)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d certificate, %d algorithm, %d attribute and %d element differences\n",
+		len(d.Certificates), len(d.Algorithms), len(d.Attributes), len(d.Elements))
+}
+
+// runDiffCommand implements "autograph-pls diff". Like diff(1), it returns
+// 0 for identical signatures, 1 for differences and 2 for errors.
+func runDiffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output `format`: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [options] <file1> <file2>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nCompares the first PKCS#7 SignedData of two files: signed binaries or\n")
+		fmt.Fprintf(os.Stderr, "signatures in DER, PEM or base64. Reports certificates present in only one of\n")
+		fmt.Fprintf(os.Stderr, "them, differing algorithms and signed attributes, and element-level TLV\n")
+		fmt.Fprintf(os.Stderr, "differences with their offsets in both files. Exits 0 if the signatures are\n")
+		fmt.Fprintf(os.Stderr, "identical, 1 if they differ and 2 on errors.\n")
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s diff shimx64.efi shimx64-rebuilt.efi\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s diff -format json old.p7s new.p7s\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Printf("Error: unsupported diff format %q (use text or json)\n", *format)
+		return ExitUsage
+	}
+
+	fh := FileHandler{}
+	p7A, sourceA, err := loadDiffSignature(fh, fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(fs.Arg(0)), err)
+		return ExitDiffError
+	}
+	p7B, sourceB, err := loadDiffSignature(fh, fs.Arg(1))
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(fs.Arg(1)), err)
+		return ExitDiffError
+	}
+	diff, err := diffSignatures(p7A, p7B, sourceA, sourceB)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitDiffError
+	}
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return ExitDiffError
+		}
+		fmt.Println(string(out))
+	} else {
+		printSignatureDiff(os.Stdout, diff)
+	}
+	if diff.Identical() {
+		return ExitValid
+	}
+	return ExitDifferent
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiffSignatures tests certificate, attribute and element differences
+func TestDiffSignatures(t *testing.T) {
+	ts := newTestRSASigner(t)
+	other := newTestRSASigner(t)
+	base, err := ParsePKCS7(ts.signDetached(t, []byte("content"), false))
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	source := DiffSource{File: "a", Offset: 100}
+
+	diff, err := diffSignatures(base, base, source, source)
+	if err != nil || !diff.Identical() {
+		t.Fatalf("Expected identical signatures, got %+v (%v)", diff, err)
+	}
+
+	changed, _ := ParsePKCS7(ts.signDetached(t, []byte("changed"), false))
+	diff, err = diffSignatures(base, changed, source, DiffSource{File: "b", Offset: 7})
+	if err != nil {
+		t.Fatalf("diffSignatures failed: %v", err)
+	}
+	if len(diff.Certificates) != 0 || len(diff.Algorithms) != 0 {
+		t.Errorf("Expected the same certificate and algorithms, got %+v", diff)
+	}
+	if len(diff.Attributes) != 1 || diff.Attributes[0].Name != "messageDigest" || diff.Attributes[0].Change != DiffChanged {
+		t.Errorf("Expected only messageDigest to change, got %+v", diff.Attributes)
+	}
+	if len(diff.Elements) != 2 {
+		t.Fatalf("Expected messageDigest and signature element changes, got %+v", diff.Elements)
+	}
+	if e := diff.Elements[0]; e.OffsetA-100 != e.OffsetB-7 || !strings.HasPrefix(e.A, "OCTET STRING") {
+		t.Errorf("Expected offsets relative to each file, got %+v", e)
+	}
+
+	plain, _ := ParsePKCS7(other.signDetached(t, []byte("content"), true))
+	diff, _ = diffSignatures(base, plain, source, source)
+	if len(diff.Certificates) != 2 || diff.Certificates[0].Change != DiffRemoved || diff.Certificates[1].Change != DiffAdded {
+		t.Errorf("Expected one removed and one added certificate, got %+v", diff.Certificates)
+	}
+	if len(diff.Attributes) != 2 || diff.Attributes[0].Change != DiffRemoved || diff.Attributes[0].B != "" {
+		t.Errorf("Expected both signed attributes removed, got %+v", diff.Attributes)
+	}
+}
+
+// TestDiffNodeLists tests positional comparison of element trees
+func TestDiffNodeLists(t *testing.T) {
+	a, _ := parseASN1Tree([]byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x02}, 10, 0)
+	b, _ := parseASN1Tree([]byte{0x30, 0x08, 0x02, 0x01, 0x01, 0x02, 0x01, 0x03, 0x05, 0x00}, 20, 0)
+	diffs := diffNodeLists("", a, b, nil)
+	if len(diffs) != 2 {
+		t.Fatalf("Expected a changed and an added element, got %+v", diffs)
+	}
+	if diffs[0].Path != "0.1" || diffs[0].Change != DiffChanged || diffs[0].OffsetA != 15 || diffs[0].OffsetB != 25 {
+		t.Errorf("Unexpected change %+v", diffs[0])
+	}
+	if diffs[1].Path != "0.2" || diffs[1].Change != DiffAdded || diffs[1].OffsetA != -1 || diffs[1].B != "NULL" {
+		t.Errorf("Unexpected addition %+v", diffs[1])
+	}
+
+	// Different tags are reported once, not child by child
+	c, _ := parseASN1Tree([]byte{0x31, 0x03, 0x02, 0x01, 0x01}, 0, 0)
+	d, _ := parseASN1Tree([]byte{0x30, 0x03, 0x02, 0x01, 0x01}, 0, 0)
+	if diffs := diffNodeLists("", c, d, nil); len(diffs) != 1 || diffs[0].Path != "0" {
+		t.Errorf("Expected one change of the whole element, got %+v", diffs)
+	}
+}
+
+// TestRunDiffCommand tests exit codes and JSON output
+func TestRunDiffCommand(t *testing.T) {
+	ts := newTestRSASigner(t)
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.p7s"), filepath.Join(dir, "b.p7s")
+	os.WriteFile(a, ts.signDetached(t, []byte("content"), false), 0o644)
+	os.WriteFile(b, buildTestModule([]byte("\x7fELF"), ts.signDetached(t, []byte("other"), false)), 0o644)
+
+	var status int
+	output := captureStdout(t, func() { status = runDiffCommand([]string{a, a}) })
+	if status != 0 || !strings.Contains(output, "identical") {
+		t.Errorf("Expected identical signatures, got %d:\n%s", status, output)
+	}
+
+	output = captureStdout(t, func() { status = runDiffCommand([]string{"-format", "json", a, b}) })
+	if status != 1 {
+		t.Fatalf("Expected exit status 1 for differing signatures, got %d:\n%s", status, output)
+	}
+	var diff SignatureDiff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("Expected JSON output: %v\n%s", err, output)
+	}
+	if diff.B.Offset != 4 || len(diff.Attributes) != 1 {
+		t.Errorf("Expected the module signature at offset 4 with one changed attribute, got %+v", diff)
+	}
+
+	captureStdout(t, func() { status = runDiffCommand([]string{a, filepath.Join(dir, "missing")}) })
+	if status != 2 {
+		t.Errorf("Expected exit status 2 for a missing file, got %d", status)
+	}
+}
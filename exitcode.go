@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Exit codes of the analysis and of strip, attach, sign and browse. Batch
+// runs, archives and several PEM blocks exit with the highest code of any
+// file. diff and oid keep their own codes, see their -h.
+const (
+	// ExitValid inputs carry a signature with all required certificate fields
+	ExitValid = 0
+	// ExitUnsigned inputs hold no signature at all
+	ExitUnsigned = 1
+	// ExitUsage rejects invalid options or arguments, as the flag package does
+	ExitUsage = 2
+	// ExitInvalid inputs hold SignedData that fails validation or verification
+	ExitInvalid = 3
+	// ExitIOError reports an input that could not be read or an output that
+	// could not be written
+	ExitIOError = 4
+	// ExitMalformed inputs could not be decoded, or their structure does not
+	// parse
+	ExitMalformed = 5
+	// ExitInternal reports a crash
+	ExitInternal = 6
+)
+
+// Exit codes of diff and oid besides ExitValid and ExitUsage, which like
+// diff(1) tell a difference apart from an error
+const (
+	// ExitDifferent reports signatures that differ, or oid queries that do
+	// not resolve to a registered name
+	ExitDifferent = 1
+	// ExitDiffError reports a diff that failed, whatever the cause
+	ExitDiffError = 2
+)
+
+// errAnalysisCrashed marks results of analyses that panicked
+var errAnalysisCrashed = errors.New("analysis crashed")
+
+// readError marks errors loading an input, as opposed to errors in its
+// content
+type readError struct {
+	err error
+}
+
+func (e readError) Error() string {
+	return e.err.Error()
+}
+
+func (e readError) Unwrap() error {
+	return e.err
+}
+
+// errorExitCode classifies an error: loading and file system errors and
+// empty inputs are I/O errors, inputs without a signature are unsigned, and
+// everything else, including inputs too small for a signature, is malformed
+// input
+func errorExitCode(err error) int {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, errAnalysisCrashed):
+		return ExitInternal
+	case errors.Is(err, errEmptyInput):
+		return ExitIOError
+	case errors.Is(err, errInputTooSmall):
+		return ExitMalformed
+	case errors.Is(err, errNoValidSignature),
+		errors.Is(err, errNoCertificateTable), errors.Is(err, errNoAppendedSignature), errors.Is(err, errNoSignatureContainer):
+		return ExitUnsigned
+	case errors.As(err, &readError{}), errors.As(err, &pathErr):
+		return ExitIOError
+	}
+	return ExitMalformed
+}
+
+// missingSignatureExitCode returns the exit code for data in which no valid
+// signature was found: invalid if it still holds pkcs7-signedData, like a
+// batch run reports it, and unsigned otherwise
+func missingSignatureExitCode(data []byte) int {
+	if _, found := findSignedData(data); found {
+		return ExitInvalid
+	}
+	return ExitUnsigned
+}
+
+// locateExitCode returns the exit code for an error of locateStructure
+func locateExitCode(data []byte, err error) int {
+	if errors.Is(err, errNoValidSignature) {
+		return missingSignatureExitCode(data)
+	}
+	return errorExitCode(err)
+}
+
+// ExitCode returns the exit code for a single result
+func (r FileResult) ExitCode() int {
+	switch r.Status {
+	case FileSigned:
+		return ExitValid
+	case FileUnsigned:
+		return ExitUnsigned
+	case FileInvalid:
+		return ExitInvalid
+	}
+	return errorExitCode(r.Err)
+}
+
+// printExitCodes lists the exit codes for the -help output
+func printExitCodes(w io.Writer) {
+	for _, code := range []struct {
+		code        int
+		description string
+	}{
+		{ExitValid, "valid signature with all required certificate fields"},
+		{ExitUnsigned, "no signature found"},
+		{ExitUsage, "invalid options or arguments"},
+		{ExitInvalid, "signature present but invalid: missing fields or failed verification"},
+		{ExitIOError, "a file could not be read or written, or is empty"},
+		{ExitMalformed, "malformed input: too small, undecodable PEM, compression or archive, or ASN.1 parse errors"},
+		{ExitInternal, "internal error"},
+	} {
+		fmt.Fprintf(w, "  %d  %s\n", code.code, code.description)
+	}
+	fmt.Fprintf(w, "  Batch runs, archives and several PEM blocks exit with the highest code of any\n")
+	fmt.Fprintf(w, "  file. strip, attach, sign and browse use the same codes; diff and oid have\n")
+	fmt.Fprintf(w, "  their own (see their -h).\n")
+}
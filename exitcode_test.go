@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestErrorExitCode tests how errors are classified into exit codes
+func TestErrorExitCode(t *testing.T) {
+	fh := FileHandler{Loader: MemoryLoader{"tiny.bin": {0x30, 0x00}, "empty.bin": {}}}
+	_, missingErr := fh.Open("missing.bin")
+	_, tinyErr := fh.Open("tiny.bin")
+	_, emptyErr := fh.Open("empty.bin")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"missing file", missingErr, ExitIOError},
+		{"read error", readError{errors.New("input exceeds the limit")}, ExitIOError},
+		{"path error", fmt.Errorf("key.pem: %w", &os.PathError{Op: "open", Path: "key.pem", Err: os.ErrPermission}), ExitIOError},
+		{"too small", tinyErr, ExitMalformed},
+		{"empty", emptyErr, ExitIOError},
+		{"no signature", errNoValidSignature, ExitUnsigned},
+		{"nothing to strip", errNoAppendedSignature, ExitUnsigned},
+		{"crash", fmt.Errorf("%w: runtime error", errAnalysisCrashed), ExitInternal},
+		{"malformed", errors.New("malformed PEM input"), ExitMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorExitCode(tt.err); got != tt.expected {
+				t.Errorf("Expected exit code %d for %v, got %d", tt.expected, tt.err, got)
+			}
+		})
+	}
+}
+
+// TestResultExitCode tests exit codes of results and batch summaries
+func TestResultExitCode(t *testing.T) {
+	invalid := append([]byte{0x30, 0x0D}, oidSignedDataDER...)
+	invalid = append(invalid, 0xA0, 0x00)
+	if code := missingSignatureExitCode(invalid); code != ExitInvalid {
+		t.Errorf("Expected SignedData without a valid signature to be invalid, got %d", code)
+	}
+	if code := missingSignatureExitCode([]byte("no signature here")); code != ExitUnsigned {
+		t.Errorf("Expected data without SignedData to be unsigned, got %d", code)
+	}
+
+	var summary BatchSummary
+	for _, result := range []FileResult{
+		{Status: FileSigned},
+		{Status: FileInvalid},
+		{Status: FileUnsigned},
+	} {
+		summary.add(result)
+	}
+	if summary.ExitCode != ExitInvalid {
+		t.Errorf("Expected the highest exit code %d, got %d", ExitInvalid, summary.ExitCode)
+	}
+	summary.add(FileResult{Status: FileError, Err: errors.New("invalid zip archive")})
+	if summary.ExitCode != ExitMalformed {
+		t.Errorf("Expected a broken archive to raise the exit code to %d, got %d", ExitMalformed, summary.ExitCode)
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractIndexName is the index file written next to extracted parts
+const ExtractIndexName = "index.json"
+
+// maxExtractNameLength bounds the subject part of certificate file names
+const maxExtractNameLength = 64
+
+// Kinds of extracted files
+const (
+	ExtractCertificate = "certificate"
+	ExtractSignerInfo  = "signer-info"
+	ExtractContent     = "content"
+)
+
+// ExtractedFile describes one file written by -x, as listed in the index
+type ExtractedFile struct {
+	File        string `json:"file"`
+	Kind        string `json:"kind"`
+	Subject     string `json:"subject,omitempty"`
+	Issuer      string `json:"issuer,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	NotAfter    string `json:"not_after,omitempty"`
+	Signer      bool   `json:"signer,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// extractPKCS7 writes every certificate, every SignerInfo and the
+// encapsulated content of a SignedData into dir, followed by an index.
+// Certificates and SignerInfos use the -outform encoding; the content is
+// written as is. The index lists sizes and digests of the DER.
+func extractPKCS7(fh FileHandler, p7 *PKCS7, dir, outform string) ([]ExtractedFile, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	signerCerts := make(map[*x509.Certificate]bool)
+	for _, signer := range p7.Signers {
+		if cert, err := p7.Certificate(signer); err == nil {
+			signerCerts[cert] = true
+		}
+	}
+
+	var written []ExtractedFile
+	save := func(entry ExtractedFile, data []byte, encode bool) error {
+		entry.Size = len(data)
+		digest := sha256.Sum256(data)
+		entry.SHA256 = hex.EncodeToString(digest[:])
+		if encode {
+			var err error
+			if data, err = encodeOutput(data, outform); err != nil {
+				return err
+			}
+		}
+		if err := fh.SaveToFile(data, filepath.Join(dir, entry.File)); err != nil {
+			return fmt.Errorf("%s: %w", entry.File, err)
+		}
+		written = append(written, entry)
+		return nil
+	}
+
+	ext := outputExtension(outform)
+	used := make(map[string]bool)
+	for i, cert := range p7.Certificates {
+		name := certificateFileName(cert)
+		if used[name] {
+			// Issuers may reuse a CN and serial: tell the files apart by fingerprint
+			fingerprint := sha256.Sum256(p7.RawCertificates[i])
+			name += "-" + hex.EncodeToString(fingerprint[:4])
+		}
+		used[name] = true
+		entry := ExtractedFile{
+			File:     name + ext,
+			Kind:     ExtractCertificate,
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			Serial:   cert.SerialNumber.Text(16),
+			NotAfter: cert.NotAfter.UTC().Format("2006-01-02T15:04:05Z"),
+			Signer:   signerCerts[cert],
+		}
+		if err := save(entry, p7.RawCertificates[i], true); err != nil {
+			return written, err
+		}
+	}
+
+	for i, signer := range p7.Signers {
+		entry := ExtractedFile{
+			File: fmt.Sprintf("signerinfo-%d%s", i+1, ext),
+			Kind: ExtractSignerInfo,
+		}
+		if signer.SerialNumber != nil {
+			entry.Serial = signer.SerialNumber.Text(16)
+		}
+		if cert, err := p7.Certificate(signer); err == nil {
+			entry.Subject = cert.Subject.String()
+			entry.Issuer = cert.Issuer.String()
+		}
+		if err := save(entry, signer.Raw, true); err != nil {
+			return written, err
+		}
+	}
+
+	if p7.Content != nil {
+		entry := ExtractedFile{
+			File:        "content.bin",
+			Kind:        ExtractContent,
+			ContentType: p7.ContentType.String(),
+		}
+		if err := save(entry, p7.Content, false); err != nil {
+			return written, err
+		}
+	}
+
+	index, err := json.MarshalIndent(written, "", "  ")
+	if err != nil {
+		return written, err
+	}
+	if err := fh.SaveToFile(append(index, '\n'), filepath.Join(dir, ExtractIndexName)); err != nil {
+		return written, fmt.Errorf("%s: %w", ExtractIndexName, err)
+	}
+	return written, nil
+}
+
+// certificateFileName names a certificate file after its subject CN and
+// serial number, e.g. SUSE_Linux_Enterprise_Secure_Boot_CA-a3f1.
+// extractPKCS7 appends part of the fingerprint to names already taken.
+func certificateFileName(cert *x509.Certificate) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, cert.Subject.CommonName)
+	name = strings.Trim(name, "._")
+	if len(name) > maxExtractNameLength {
+		name = name[:maxExtractNameLength]
+	}
+	if name == "" {
+		name = "certificate"
+	}
+	return name + "-" + cert.SerialNumber.Text(16)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExtractPKCS7 tests the files and index written by -x
+func TestExtractPKCS7(t *testing.T) {
+	ts := newTestRSASigner(t)
+	p7, err := ParsePKCS7(ts.signDetached(t, []byte("content"), false))
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	p7.Content = []byte("encapsulated")
+
+	dir := filepath.Join(t.TempDir(), "parts")
+	files, err := extractPKCS7(FileHandler{}, p7, dir, OutformPEM)
+	if err != nil {
+		t.Fatalf("extractPKCS7 failed: %v", err)
+	}
+
+	expected := []struct {
+		file string
+		kind string
+	}{
+		{"Test_Signer-1267.pem", ExtractCertificate},
+		{"signerinfo-1.pem", ExtractSignerInfo},
+		{"content.bin", ExtractContent},
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %+v", len(expected), files)
+	}
+	for i, want := range expected {
+		if files[i].File != want.file || files[i].Kind != want.kind {
+			t.Errorf("File %d: expected %s (%s), got %s (%s)", i, want.file, want.kind, files[i].File, files[i].Kind)
+		}
+	}
+	if !files[0].Signer || files[0].Serial != "1267" || files[1].Subject == "" {
+		t.Errorf("Expected signer certificate details, got %+v and %+v", files[0], files[1])
+	}
+
+	certPEM, err := os.ReadFile(filepath.Join(dir, files[0].File))
+	if err != nil {
+		t.Fatalf("Failed to read certificate: %v", err)
+	}
+	if block, _ := pem.Decode(certPEM); block == nil || block.Type != "CERTIFICATE" || !bytes.Equal(block.Bytes, ts.cert.Raw) {
+		t.Errorf("Expected the signer certificate as PEM")
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "content.bin")); string(content) != "encapsulated" {
+		t.Errorf("Expected the raw encapsulated content, got %q", content)
+	}
+
+	indexJSON, err := os.ReadFile(filepath.Join(dir, ExtractIndexName))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	var index []ExtractedFile
+	if err := json.Unmarshal(indexJSON, &index); err != nil || len(index) != len(files) || index[0].SHA256 != files[0].SHA256 {
+		t.Errorf("Expected index describing the written files, got %s (%v)", indexJSON, err)
+	}
+}
+
+// TestExtractPKCS7SameName tests that certificates sharing a CN and serial
+// are written to separate files
+func TestExtractPKCS7SameName(t *testing.T) {
+	ts := newTestRSASigner(t)
+	p7, err := ParsePKCS7(ts.signDetached(t, []byte("content"), false))
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	other := newTestRSASigner(t)
+	p7.Certificates = append(p7.Certificates, other.cert)
+	p7.RawCertificates = append(p7.RawCertificates, other.cert.Raw)
+
+	dir := t.TempDir()
+	files, err := extractPKCS7(FileHandler{}, p7, dir, OutformDER)
+	if err != nil {
+		t.Fatalf("extractPKCS7 failed: %v", err)
+	}
+	fingerprint := sha256.Sum256(other.cert.Raw)
+	second := "Test_Signer-1267-" + hex.EncodeToString(fingerprint[:4]) + ".der"
+	if len(files) < 2 || files[0].File != "Test_Signer-1267.der" || files[1].File != second {
+		t.Fatalf("Expected %s to get a fingerprint suffix, got %+v", second, files)
+	}
+	for i, cert := range [][]byte{ts.cert.Raw, other.cert.Raw} {
+		if data, _ := os.ReadFile(filepath.Join(dir, files[i].File)); !bytes.Equal(data, cert) {
+			t.Errorf("Expected %s to hold certificate %d", files[i].File, i+1)
+		}
+	}
+}
+
+// TestCertificateFileName tests file names derived from subject and serial
+func TestCertificateFileName(t *testing.T) {
+	ts := newTestRSASigner(t)
+	if name := certificateFileName(ts.cert); name != "Test_Signer-1267" {
+		t.Errorf("Expected Test_Signer-1267, got %s", name)
+	}
+
+	cert := *ts.cert
+	cert.Subject.CommonName = "../../etc/passwd"
+	if name := certificateFileName(&cert); name != "etc_passwd-1267" {
+		t.Errorf("Expected path characters to be replaced, got %s", name)
+	}
+	cert.Subject.CommonName = ""
+	if name := certificateFileName(&cert); name != "certificate-1267" {
+		t.Errorf("Expected fallback name, got %s", name)
+	}
+}
@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !unix
+
+package main
+
+import "os"
+
+// newFileMode is the mode os.Create gives new files
+var newFileMode os.FileMode = 0o666
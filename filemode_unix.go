@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// newFileMode is the mode os.Create gives new files under the umask. The
+// umask can only be read by setting it, so this happens once at start-up,
+// before any goroutine creates files.
+var newFileMode = func() os.FileMode {
+	mask := syscall.Umask(0)
+	syscall.Umask(mask)
+	return 0o666 &^ os.FileMode(mask)
+}()
@@ -1,3 +1,8 @@
 module autograph-pls
 
 go 1.24.7
+
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/ulikunitz/xz v0.5.15
+)
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Layout of the annotated hex dump
+const (
+	hexDumpRowSize = 16
+	// hexDumpContextRows are shown after the row where parsing stopped
+	hexDumpContextRows = 2
+	// hexDumpIndent is the width of the offset column
+	hexDumpIndent = 10
+)
+
+// Byte markers of the annotated hex dump
+const (
+	hexMarkTag      = 'T'
+	hexMarkLength   = 'L'
+	hexMarkValue    = 'V'
+	hexMarkError    = '!'
+	hexMarkUnparsed = '?'
+)
+
+// HexDumper prints offset/hex/ASCII rows of ASN.1 data with the tag,
+// length and value bytes of every element marked below each row and the
+// elements labelled at their first byte
+type HexDumper struct {
+	W io.Writer
+}
+
+// hexDump is the state of one dump: a marker and labels per byte
+type hexDump struct {
+	data   []byte
+	base   int
+	marks  []byte
+	labels map[int][]string
+}
+
+// Dump prints the elements of data, which starts at offset base in its
+// file, and returns the first parse error. Elements nested in content that
+// fails to parse are marked up to the failing byte, like ASN1Displayer
+// prints them, and the rest of the content is marked unparsed, except in
+// constructed elements that overrun, whose available content is walked. At the top
+// level the dump stops a few rows after the failing byte.
+func (hd HexDumper) Dump(data []byte, base int) error {
+	d := &hexDump{data: data, base: base, marks: make([]byte, len(data)), labels: make(map[int][]string)}
+	for i := range d.marks {
+		d.marks[i] = ' '
+	}
+	end, err := d.walk(0, len(data), 0, structureOther)
+
+	fmt.Fprintf(hd.W, "%*s%c tag, %c length, %c value, %c parse error, %c unparsed\n", hexDumpIndent, "",
+		hexMarkTag, hexMarkLength, hexMarkValue, hexMarkError, hexMarkUnparsed)
+	for row := 0; row < end; row += hexDumpRowSize {
+		d.printRow(hd.W, row, min(row+hexDumpRowSize, len(data)))
+	}
+	return err
+}
+
+// walk marks the elements of data[start:end] at the given depth, inside an
+// element of the given structure, and returns how much of the data the dump
+// should show
+func (d *hexDump) walk(start, end, depth, structure int) (int, error) {
+	var firstErr error
+	for pos, index := start, 0; pos < end; index++ {
+		element, n, err := parseASN1Element(d.data[pos:end], depth, d.base+pos)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				return end, err
+			}
+			if parseErr.Kind == ParseOverrun && element.IsCompound {
+				// The header is intact: mark the length that overruns and walk
+				// what is there of the content
+				d.header(pos, element)
+				d.marks[parseErr.At-d.base] = hexMarkError
+				d.labels[parseErr.At-d.base] = append(d.labels[parseErr.At-d.base], fmt.Sprintf("%d parse error: %s", parseErr.At, parseErr.Reason()))
+				shown, _ := d.walk(pos+element.HeaderLen, end, depth+1, classifyStructure(&element, d.data[pos+element.HeaderLen:end]))
+				return shown, err
+			}
+			failed := d.fail(pos, end, parseErr)
+			return min(len(d.data), (failed/hexDumpRowSize+1+hexDumpContextRows)*hexDumpRowSize), err
+		}
+		if element.Class == 0 && element.Tag == TagInteger && !element.IsCompound {
+			if note := integerAnnotation(d.data[pos+element.HeaderLen:pos+n], structure, index); note != "" {
+				element.Content += " " + note
+			}
+		}
+
+		d.header(pos, element)
+		if element.IsCompound {
+			if _, err := d.walk(pos+element.HeaderLen, pos+n, depth+1, classifyStructure(&element, d.data[pos+element.HeaderLen:pos+n])); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		} else {
+			for i := pos + element.HeaderLen; i < pos+n; i++ {
+				d.marks[i] = hexMarkValue
+			}
+		}
+		pos += n
+	}
+	return end, firstErr
+}
+
+// header marks the tag and length octets of the element at pos and labels it
+func (d *hexDump) header(pos int, element ASN1Element) {
+	d.marks[pos] = hexMarkTag
+	for i := pos + 1; i < pos+element.HeaderLen; i++ {
+		d.marks[i] = hexMarkLength
+	}
+	d.labels[pos] = append(d.labels[pos], fmt.Sprintf("%d %s", d.base+pos, element.describe()))
+}
+
+// fail marks the byte that broke the element at pos, leaves the rest of the
+// enclosing content, up to end, unparsed and returns the byte's position
+func (d *hexDump) fail(pos, end int, err *ParseError) int {
+	failed := err.At - d.base
+	for i := pos; i < end; i++ {
+		d.marks[i] = hexMarkUnparsed
+	}
+	if failed > pos {
+		d.marks[pos] = hexMarkTag
+	}
+	d.marks[failed] = hexMarkError
+	d.labels[failed] = append(d.labels[failed], fmt.Sprintf("%d parse error: %s", err.At, err.Reason()))
+	return failed
+}
+
+// printRow prints the hex and ASCII of data[from:to], then the markers
+// unless all bytes are values, then the labels of elements starting there
+func (d *hexDump) printRow(w io.Writer, from, to int) {
+	var hexPart, markPart, textPart strings.Builder
+	values := true
+	for i := from; i < from+hexDumpRowSize; i++ {
+		if i-from == hexDumpRowSize/2 {
+			hexPart.WriteByte(' ')
+			markPart.WriteByte(' ')
+		}
+		if i >= to {
+			hexPart.WriteString("   ")
+			continue
+		}
+		fmt.Fprintf(&hexPart, "%02x ", d.data[i])
+		markPart.WriteString(string(d.marks[i]) + "  ")
+		values = values && d.marks[i] == hexMarkValue
+		if c := d.data[i]; c >= 0x20 && c < 0x7F {
+			textPart.WriteByte(c)
+		} else {
+			textPart.WriteByte('.')
+		}
+	}
+	fmt.Fprintf(w, "%8d  %s |%s|\n", d.base+from, hexPart.String(), textPart.String())
+	if !values {
+		fmt.Fprintf(w, "%*s%s\n", hexDumpIndent, "", strings.TrimRight(markPart.String(), " "))
+	}
+
+	var starts []int
+	for pos := range d.labels {
+		if pos >= from && pos < to {
+			starts = append(starts, pos)
+		}
+	}
+	sort.Ints(starts)
+	for _, pos := range starts {
+		column := hexDumpIndent + (pos-from)*3
+		if pos-from >= hexDumpRowSize/2 {
+			column++
+		}
+		for _, label := range d.labels[pos] {
+			fmt.Fprintf(w, "%*s^ %s\n", column, "", label)
+		}
+	}
+}
+
+// hexDumpCandidate returns where to dump data that holds no valid
+// signature: the last SignedData, or the start of the data
+func hexDumpCandidate(data []byte) int {
+	if offset, found := findSignedData(data); found {
+		return offset
+	}
+	return 0
+}
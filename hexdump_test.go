@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestHexDumper tests markers, labels and parse errors at both levels
+func TestHexDumper(t *testing.T) {
+	// SEQUENCE { INTEGER 5, SEQUENCE { OCTET STRING with an overlong length }, NULL, truncated BOOLEAN }, stray byte
+	der := []byte{
+		0x30, 0x0F, 0x02, 0x01, 0x05, 0x30, 0x06, 0x04, 0x09, 0x41, 0x42, 0x43, 0x44, 0x05, 0x00, 0x01,
+		0x01, 0xFF,
+	}
+	expected := []string{
+		"          T tag, L length, V value, ! parse error, ? unparsed",
+		"     100  30 0f 02 01 05 30 06 04  09 41 42 43 44 05 00 01  |0....0...ABCD...|",
+		"          T  L  T  L  V  T  L  T   !  ?  ?  ?  ?  T  L  T",
+		"          ^ 100 SEQUENCE (15 bytes)",
+		"                ^ 102 INTEGER 5 (0x05)",
+		"                         ^ 105 SEQUENCE (6 bytes)",
+		"                                   ^ 108 parse error: element extends beyond available data (11 bytes needed, 6 available)",
+		"                                                  ^ 113 NULL",
+		"     116  01 ff                                             |..|",
+		"          !  !",
+		"          ^ 116 parse error: element extends beyond available data (3 bytes needed, 2 available)",
+		"             ^ 117 parse error: insufficient data for the element header (2 bytes needed, 1 available)",
+	}
+
+	var buf bytes.Buffer
+	if err := (HexDumper{W: &buf}).Dump(der, 100); err == nil || !strings.Contains(err.Error(), "offset 117: insufficient data") {
+		t.Errorf("Expected the top-level error, got %v", err)
+	}
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected dump:\n%s\nexpected:\n%s", got, strings.Join(expected, "\n"))
+	}
+
+	// Rows of values only have no marker line; a top-level error ends the dump
+	data := append([]byte{0x04, 0x20}, bytes.Repeat([]byte{'A'}, 32)...)
+	data = append(data, 0x30, 0x84, 0xFF, 0xFF, 0xFF, 0xFF)
+	data = append(data, make([]byte, 200)...)
+	buf.Reset()
+	(HexDumper{W: &buf}).Dump(data, 0)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if lines[4] != "      16  41 41 41 41 41 41 41 41  41 41 41 41 41 41 41 41  |AAAAAAAAAAAAAAAA|" || !strings.HasPrefix(lines[5], "      32 ") {
+		t.Errorf("Expected a value row without markers, got %q", lines[4:6])
+	}
+	if last := lines[len(lines)-2]; !strings.HasPrefix(last, "      64 ") {
+		t.Errorf("Expected the dump to end two rows after the error at 35, got %q", last)
+	}
+	if !strings.Contains(buf.String(), "   ^ 35 parse error: length exceeds the 50 MiB limit") {
+		t.Errorf("Expected the length octet flagged, got:\n%s", buf.String())
+	}
+}
+
+// TestHexDumperOverrun tests that the content of a constructed element that
+// overruns its level is walked
+func TestHexDumperOverrun(t *testing.T) {
+	der := []byte{0x30, 0x05, 0x30, 0x0A, 0x02, 0x01, 0x07}
+	expected := []string{
+		"          T tag, L length, V value, ! parse error, ? unparsed",
+		"       0  30 05 30 0a 02 01 07                              |0.0....|",
+		"          T  L  T  !  T  L  V",
+		"          ^ 0 SEQUENCE (5 bytes)",
+		"                ^ 2 SEQUENCE (10 bytes)",
+		"                   ^ 3 parse error: element extends beyond available data (12 bytes needed, 5 available)",
+		"                      ^ 4 INTEGER 7 (0x07)",
+	}
+
+	var buf bytes.Buffer
+	if err := (HexDumper{W: &buf}).Dump(der, 0); err == nil {
+		t.Errorf("Expected the overrun to be returned")
+	}
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected dump:\n%s\nexpected:\n%s", got, strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,362 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// FormatHTML selects the self-contained HTML report
+const FormatHTML = "html"
+
+// htmlHexRowSize is the number of bytes per row of the hex view
+const htmlHexRowSize = 16
+
+// HTMLReport is the content of the HTML report for a single input
+type HTMLReport struct {
+	File      string
+	FileSize  int64
+	SHA256    string
+	Layers    []CompressionLayer
+	Encoding  string
+	Generated time.Time
+	Result    FileResult
+	// Check names what the signature check covered and CheckErr is its
+	// outcome; without Check, CheckErr says why nothing was checked
+	Check        string
+	CheckErr     error
+	Certificates []*x509.Certificate
+	signerCerts  map[*x509.Certificate]bool
+	// Structure holds the signature found at Result.Offset, Tree its
+	// elements and TreeErr the error that kept them from being parsed
+	Structure []byte
+	Tree      []*ASN1Node
+	TreeErr   error
+}
+
+// htmlCertificate is a row of the certificate inventory
+type htmlCertificate struct {
+	Subject, Issuer, Serial, NotBefore, NotAfter, Key, Algorithm, Fingerprint string
+	Signer                                                                    bool
+}
+
+// htmlHexByte is one byte of the hex view; Class marks element headers (h)
+// and the content of alternating primitive elements (c0, c1)
+type htmlHexByte struct {
+	Hex, Char, Class, Title string
+}
+
+// htmlHexRow is one row of the hex view with the elements starting in it
+type htmlHexRow struct {
+	Offset int
+	Bytes  []htmlHexByte
+	Notes  []string
+}
+
+// NewHTMLReport analyses data, the decoded content of the file called name,
+// for a report
+func NewHTMLReport(name string, fileSize int64, data []byte, layers []CompressionLayer, encoding string) *HTMLReport {
+	sum := sha256.Sum256(data)
+	report := &HTMLReport{
+		File:      name,
+		FileSize:  fileSize,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Layers:    layers,
+		Encoding:  encoding,
+		Generated: time.Now().UTC(),
+		Result:    analyzeData(data),
+	}
+	report.Result.Path = name
+	report.Result.Layers = layers
+	if report.Result.Size > 0 {
+		report.Structure = data[report.Result.Offset : report.Result.Offset+report.Result.Size]
+		report.Tree, report.TreeErr = parseASN1Tree(report.Structure, report.Result.Offset, 0)
+	}
+	if p7, _, err := FindPKCS7(data); err == nil {
+		report.Certificates = p7.Certificates
+		report.signerCerts = make(map[*x509.Certificate]bool)
+		for _, signer := range p7.Signers {
+			if cert, err := p7.Certificate(signer); err == nil {
+				report.signerCerts[cert] = true
+			}
+		}
+		report.Check, report.CheckErr = checkSignature(data, p7)
+		// Present fields do not make a signature that fails to verify valid
+		if report.Check != "" && report.CheckErr != nil && report.Result.Status == FileSigned {
+			report.Result.Status = FileInvalid
+			report.Result.Problem = fmt.Sprintf("%s does not verify: %v", report.Check, report.CheckErr)
+		}
+	}
+	return report
+}
+
+// Write renders the report as a single HTML document without external
+// resources
+func (r *HTMLReport) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, r); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Passed reports whether the input carries a valid signature
+func (r *HTMLReport) Passed() bool {
+	return r.Result.Status == FileSigned
+}
+
+// Verdict returns the one-line verdict of the report
+func (r *HTMLReport) Verdict() string {
+	switch r.Result.Status {
+	case FileSigned:
+		return "Valid signature - all required fields present"
+	case FileUnsigned:
+		return "No signature found"
+	case FileInvalid:
+		if r.Result.Problem != "" {
+			return "Invalid signature - " + r.Result.Problem
+		}
+		return "Invalid signature - missing " + strings.Join(missingFields(r.Result.Validation), ", ")
+	default:
+		return fmt.Sprintf("Analysis failed: %v", r.Result.Err)
+	}
+}
+
+// Fields lists the required certificate fields with their values
+func (r *HTMLReport) Fields() []struct {
+	Name, Value string
+	Present     bool
+} {
+	sv := r.Result.Validation
+	return []struct {
+		Name, Value string
+		Present     bool
+	}{
+		{"Common Name", sv.CommonName, sv.HasCommonName},
+		{"Country Name", sv.CountryName, sv.HasCountryName},
+		{"Locality Name", sv.LocalityName, sv.HasLocalityName},
+		{"Organization Name", sv.OrganizationName, sv.HasOrganizationName},
+		{"Email Address", sv.EmailAddress, sv.HasEmailAddress},
+	}
+}
+
+// Inventory describes the embedded certificates
+func (r *HTMLReport) Inventory() []htmlCertificate {
+	var certs []htmlCertificate
+	for _, cert := range r.Certificates {
+		fingerprint := sha256.Sum256(cert.Raw)
+		certs = append(certs, htmlCertificate{
+			Subject:     cert.Subject.String(),
+			Issuer:      cert.Issuer.String(),
+			Serial:      colonHex(cert.SerialNumber.Bytes()),
+			NotBefore:   cert.NotBefore.UTC().Format(time.RFC3339),
+			NotAfter:    cert.NotAfter.UTC().Format(time.RFC3339),
+			Key:         describePublicKey(cert),
+			Algorithm:   cert.SignatureAlgorithm.String(),
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			Signer:      r.signerCerts[cert],
+		})
+	}
+	return certs
+}
+
+// describePublicKey names the key algorithm and size of a certificate
+func describePublicKey(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bits", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// HexRows lays out the structure for the annotated hex view
+func (r *HTMLReport) HexRows() []htmlHexRow {
+	base := r.Result.Offset
+	cells := make([]htmlHexByte, len(r.Structure))
+	notes := make(map[int][]string)
+	primitives := 0
+	var annotate func(nodes []*ASN1Node)
+	annotate = func(nodes []*ASN1Node) {
+		for _, node := range nodes {
+			start := node.Offset - base
+			title := fmt.Sprintf("%d: %s", node.Offset, node.TagName)
+			for i := start; i < start+node.HeaderLen; i++ {
+				cells[i].Class, cells[i].Title = "h", title
+			}
+			if len(node.Children) == 0 {
+				class := fmt.Sprintf("c%d", primitives%2)
+				for i := start + node.HeaderLen; i < start+len(node.Raw); i++ {
+					cells[i].Class, cells[i].Title = class, title
+				}
+				primitives++
+			}
+			notes[start/htmlHexRowSize] = append(notes[start/htmlHexRowSize], fmt.Sprintf("%d %s", node.Offset, node.TagName))
+			annotate(node.Children)
+		}
+	}
+	annotate(r.Tree)
+
+	var rows []htmlHexRow
+	for from := 0; from < len(r.Structure); from += htmlHexRowSize {
+		row := htmlHexRow{Offset: base + from, Notes: notes[from/htmlHexRowSize]}
+		for i := from; i < min(from+htmlHexRowSize, len(r.Structure)); i++ {
+			c := r.Structure[i]
+			cells[i].Hex, cells[i].Char = fmt.Sprintf("%02x", c), "."
+			if c >= 0x20 && c < 0x7F {
+				cells[i].Char = string(rune(c))
+			}
+			row.Bytes = append(row.Bytes, cells[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// htmlReportTemplate renders HTMLReport. The tree uses details elements so
+// folding works without scripts; offsets link to rows of the hex view.
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"args":           func(values ...any) []any { return values },
+	"describe":       (*ASN1Node).describe,
+	"describeOffset": describeOffset,
+	"open":           func(node *ASN1Node) bool { return node.Depth < browserCollapseDepth },
+	"row":            func(offset, base int) int { return base + (offset-base)/htmlHexRowSize*htmlHexRowSize },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>autograph-pls report: {{.File}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.15em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; } th, td { text-align: left; padding: 2px 12px 2px 0; vertical-align: top; }
+code, .tree, .hex { font-family: monospace; font-size: 13px; }
+.ok { color: #176f2c; } .bad { color: #b3261e; } .muted { color: #777; }
+.verdict { font-size: 1.1em; font-weight: bold; }
+.inventory td, .inventory th { border-bottom: 1px solid #eee; padding: 4px 12px 4px 0; }
+.tree details { margin-left: 1.5em; } .tree > details { margin-left: 0; }
+.tree .leaf { margin-left: 1.5em; padding-left: 1em; }
+.tree summary { cursor: pointer; }
+.tree a, .hex a { color: #555; text-decoration: none; }
+.hex td { padding: 0 1em 0 0; white-space: pre; }
+.hex .h { background: #ffe08a; } .hex .c0 { background: #d7ecff; } .hex .c1 { background: #e3f6dc; }
+.hex tr:target { outline: 2px solid #e07b00; }
+.hex .notes { color: #555; white-space: normal; }
+</style>
+</head>
+<body>
+<h1>autograph-pls report: {{.File}}</h1>
+
+<h2>File</h2>
+<table>
+<tr><th>Name</th><td><code>{{.File}}</code></td></tr>
+<tr><th>Size</th><td>{{.FileSize}} bytes</td></tr>
+<tr><th>SHA-256</th><td><code>{{.SHA256}}</code>{{if or .Layers .Encoding}} <span class="muted">(of the decoded content)</span>{{end}}</td></tr>
+{{- range .Layers}}
+<tr><th>Container</th><td>{{.Format}}, {{.CompressedSize}} bytes → {{.DecompressedSize}} bytes decompressed</td></tr>
+{{- end}}
+{{- if .Encoding}}
+<tr><th>Encoding</th><td>{{.Encoding}}</td></tr>
+{{- end}}
+<tr><th>Generated</th><td>{{.Generated.Format "2006-01-02T15:04:05Z"}}</td></tr>
+</table>
+
+<h2>Validation</h2>
+<p class="verdict {{if .Passed}}ok{{else}}bad{{end}}">{{.Result.Status}}: {{.Verdict}}</p>
+{{- if .Structure}}
+<p>Signature at {{describeOffset .Result.Offset .Layers}}, {{.Result.Size}} bytes{{if .Result.KeySize}}, {{.Result.KeySize}}-bit key{{end}}</p>
+<table>
+{{- range .Fields}}
+<tr><th>{{.Name}}</th><td>{{if .Present}}<span class="ok">✓</span> {{.Value}}{{else}}<span class="bad">✗ missing</span>{{end}}</td></tr>
+{{- end}}
+{{- if .Check}}
+<tr><th>Signature check</th><td>{{if .CheckErr}}<span class="bad">✗ {{.Check}}: {{.CheckErr}}</span>{{else}}<span class="ok">✓</span> {{.Check}} verified{{end}}</td></tr>
+{{- else if .CheckErr}}
+<tr><th>Signature check</th><td class="muted">not checked: {{.CheckErr}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- with .Inventory}}
+<h2>Certificates</h2>
+<table class="inventory">
+<tr><th>#</th><th>Subject</th><th>Issuer</th><th>Serial</th><th>Validity</th><th>Key</th><th>Signature</th><th>SHA-256</th></tr>
+{{- range $i, $cert := .}}
+<tr><td>{{$i}}{{if .Signer}} <span class="muted">signer</span>{{end}}</td><td>{{.Subject}}</td><td>{{.Issuer}}</td><td><code>{{.Serial}}</code></td><td>{{.NotBefore}}<br>{{.NotAfter}}</td><td>{{.Key}}</td><td>{{.Algorithm}}</td><td><code>{{.Fingerprint}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if or .Tree .TreeErr}}
+<h2>ASN.1 Structure</h2>
+{{- if .TreeErr}}
+<p class="bad">✗ parse error: {{.TreeErr}}</p>
+{{- end}}
+<div class="tree">
+{{- $base := .Result.Offset}}
+{{- range .Tree}}{{template "node" (args . $base)}}{{end}}
+</div>
+
+<h2>Hex View</h2>
+<p class="muted">Element headers are highlighted in yellow, primitive content in alternating blue and green. Hover a byte for its element.</p>
+<table class="hex">
+{{- range .HexRows}}
+<tr id="x{{.Offset}}"><td class="muted">{{printf "%8d" .Offset}}</td><td>{{range .Bytes}}<span{{if .Class}} class="{{.Class}}" title="{{.Title}}"{{end}}>{{.Hex}}</span> {{end}}</td><td>{{range .Bytes}}<span{{if .Class}} class="{{.Class}}"{{end}}>{{.Char}}</span>{{end}}</td><td class="notes">{{range $i, $note := .Notes}}{{if $i}}, {{end}}{{$note}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+{{define "node"}}{{$node := index . 0}}{{$base := index . 1}}
+{{- if $node.Children}}<details{{if open $node}} open{{end}}><summary><a href="#x{{row $node.Offset $base}}">{{$node.Offset}}</a> {{$node.TagName}} <span class="muted">({{$node.Length}} bytes)</span></summary>
+{{- range $node.Children}}{{template "node" (args . $base)}}{{end}}</details>
+{{- else}}<div class="leaf"><a href="#x{{row $node.Offset $base}}">{{$node.Offset}}</a> {{describe $node}}</div>{{end}}
+{{- end}}`))
+
+// writeHTMLInput writes the report for the decompressed content of the file
+// called name and returns the exit status of its result
+func writeHTMLInput(w io.Writer, name string, fileSize int64, data []byte, layers []CompressionLayer) int {
+	if format := detectArchive(data); format != "" {
+		fmt.Printf("Error: -format %s reports on a single signature, not on %s archives\n", FormatHTML, format)
+		return ExitUsage
+	}
+	blocks, err := decodeTextInput(data)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitMalformed
+	}
+	if len(blocks) > 1 {
+		fmt.Printf("Error: -format %s reports on a single signature, found %d PEM blocks\n", FormatHTML, len(blocks))
+		return ExitUsage
+	}
+	encoding := ""
+	if len(blocks) == 1 {
+		data = blocks[0].Bytes
+		encoding = "base64"
+		if blocks[0].Type != TextBase64 {
+			encoding = "PEM " + blocks[0].Type + " block"
+		}
+	}
+
+	report := NewHTMLReport(name, fileSize, data, layers, encoding)
+	if err := report.Write(w); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitIOError
+	}
+	return report.Result.ExitCode()
+}
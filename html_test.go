@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestHTMLReport tests the verdicts, inventory, tree and hex view of a report
+func TestHTMLReport(t *testing.T) {
+	data, err := os.ReadFile("testfiles/good/MokManager.efi")
+	if err != nil {
+		t.Skip("MokManager.efi not available")
+	}
+	report := NewHTMLReport("MokManager.efi", int64(len(data)), data, nil, "")
+	if !report.Passed() || report.Check != "Authenticode signature and image digest" || report.CheckErr != nil {
+		t.Errorf("Expected a verified signature, got %s, %q: %v", report.Result.Status, report.Check, report.CheckErr)
+	}
+	inventory := report.Inventory()
+	if len(inventory) != 1 || !inventory[0].Signer || inventory[0].Key != "RSA 2048 bits" {
+		t.Errorf("Expected the signing certificate in the inventory, got %+v", inventory)
+	}
+	rows := report.HexRows()
+	if len(rows) != (report.Result.Size+htmlHexRowSize-1)/htmlHexRowSize || rows[0].Offset != report.Result.Offset {
+		t.Fatalf("Expected hex rows covering the signature from offset %d, got %d rows", report.Result.Offset, len(rows))
+	}
+	if first := rows[0].Bytes[0]; first.Hex != "30" || first.Class != "h" || first.Title != "851849: SEQUENCE" {
+		t.Errorf("Expected the SEQUENCE header annotated, got %+v", first)
+	}
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		`<p class="verdict ok">SIGNED: Valid signature - all required fields present</p>`,
+		`<details open><summary><a href="#x851849">851849</a> SEQUENCE`,
+		`INTEGER 1 (0x01) [version v1]`,
+		`<tr id="x851849">`,
+		`build@suse.de`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected the report to contain %q", want)
+		}
+	}
+	for _, external := range []string{"<script", "<link", "src=", "http"} {
+		if strings.Contains(html, external) {
+			t.Errorf("Expected a self-contained report, found %q", external)
+		}
+	}
+
+	// A structure that does not parse is reported rather than left out
+	report.Tree, report.TreeErr = nil, &ParseError{Kind: ParseOverrun, Offset: 851849, At: 851850, Expected: 9, Available: 4}
+	buf.Reset()
+	report.Write(&buf)
+	if !strings.Contains(buf.String(), "✗ parse error: offset 851849") {
+		t.Errorf("Expected the parse error in the report")
+	}
+}
+
+// TestHTMLReportFailedCheck tests that present fields do not make a
+// signature that fails to verify valid
+func TestHTMLReportFailedCheck(t *testing.T) {
+	data, err := os.ReadFile("testfiles/good/MokManager.efi")
+	if err != nil {
+		t.Skip("MokManager.efi not available")
+	}
+	tampered := bytes.Clone(data)
+	tampered[0x400] ^= 0xFF
+	report := NewHTMLReport("MokManager.efi", int64(len(tampered)), tampered, nil, "")
+	if report.Passed() || report.CheckErr == nil || report.Result.ExitCode() != ExitInvalid {
+		t.Fatalf("Expected a failed check to fail the report, got %s: %v", report.Result.Status, report.CheckErr)
+	}
+
+	var buf bytes.Buffer
+	report.Write(&buf)
+	want := `<p class="verdict bad">INVALID: Invalid signature - Authenticode signature does not verify: image digest does not match the signed digest</p>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected the report to contain %q", want)
+	}
+}
+
+// TestWriteHTMLInput tests exit statuses and unsupported inputs
+func TestWriteHTMLInput(t *testing.T) {
+	var buf bytes.Buffer
+	if status := writeHTMLInput(&buf, "plain.bin", 17, []byte("no signature here"), nil); status != ExitUnsigned {
+		t.Errorf("Expected exit status %d without a signature, got %d", ExitUnsigned, status)
+	}
+	if html := buf.String(); !strings.Contains(html, "UNSIGNED: No signature found") || strings.Contains(html, "Hex View") {
+		t.Errorf("Expected an UNSIGNED report without structure, got:\n%s", html)
+	}
+
+	ts := newTestRSASigner(t)
+	sig := ts.signDetached(t, []byte("content"), false)
+	block, _ := encodeOutput(sig, OutformPEM)
+	pem := append(append([]byte{}, block...), block...)
+	buf.Reset()
+	var status int
+	output := captureStdout(t, func() { status = writeHTMLInput(&buf, "two.pem", int64(len(pem)), pem, nil) })
+	if status != ExitUsage || buf.Len() != 0 || !strings.Contains(output, "found 2 PEM blocks") {
+		t.Errorf("Expected an error for several PEM blocks, got %d: %s", status, output)
+	}
+
+	buf.Reset()
+	writeHTMLInput(&buf, "one.pem", int64(len(pem)/2), pem[:len(pem)/2], nil)
+	if html := buf.String(); !strings.Contains(html, "<th>Encoding</th><td>PEM PKCS7 block</td>") || !strings.Contains(html, "not checked: the signed content is detached") {
+		t.Errorf("Expected a decoded PEM block with a detached signature, got:\n%s", html)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StdinPath is the file argument that selects standard input
+const StdinPath = "-"
+
+// DefaultMaxInputSize caps how much is read into memory from stdin, pipes
+// and other inputs that cannot be memory-mapped
+const DefaultMaxInputSize = 1 << 30
+
+// MinInputSize is the smallest input that can hold an ASN.1 structure
+const MinInputSize = 4
+
+// errInputTooSmall rejects inputs shorter than MinInputSize
+var errInputTooSmall = errors.New("file too small to contain ASN.1 structure")
+
+// errEmptyInput rejects inputs of zero bytes, which usually mean a failed
+// read rather than a malformed file
+var errEmptyInput = errors.New("input is empty")
+
+// checkInputSize rejects inputs of size bytes that cannot hold an ASN.1
+// structure
+func checkInputSize(size int64) error {
+	switch {
+	case size == 0:
+		return errEmptyInput
+	case size < MinInputSize:
+		return errInputTooSmall
+	}
+	return nil
+}
+
+// byteSize is a flag.Value accepting a byte count with an optional binary
+// K, M or G suffix, e.g. 64M
+type byteSize int64
+
+func (bs *byteSize) String() string {
+	return strconv.FormatInt(int64(*bs), 10)
+}
+
+func (bs *byteSize) Set(value string) error {
+	size, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*bs = byteSize(size)
+	return nil
+}
+
+// parseByteSize parses a positive byte count such as 4096, 512K, 64M or 2G
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	number := strings.TrimSuffix(strings.ToUpper(s), "B")
+	shift := 0
+	if n := len(number); n > 0 {
+		switch number[n-1] {
+		case 'K':
+			shift = 10
+		case 'M':
+			shift = 20
+		case 'G':
+			shift = 30
+		}
+		if shift > 0 {
+			number = number[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if value > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("size %q too large", s)
+	}
+	return value << shift, nil
+}
+
+// inputName returns a human-readable name for a file argument
+func inputName(path string) string {
+	if path == StdinPath {
+		return "(stdin)"
+	}
+	return path
+}
+
+// readLimited reads r to the end, failing once more than max bytes arrive
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+	if int64(len(data)) > max {
+		return nil, sizeLimitError(max)
+	}
+	return data, nil
+}
+
+// sizeLimitError reports an input larger than max bytes
+func sizeLimitError(max int64) error {
+	return fmt.Errorf("input exceeds the %d byte limit (raise it with -max-size)", max)
+}
+
+// loadBuffered reads a whole stream into memory; it serves stdin, pipes,
+// character devices and procfs entries that cannot be memory-mapped
+func loadBuffered(r io.Reader, max int64) (Input, error) {
+	data, err := readLimited(r, max)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInputSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	return newBytesInput(data, nil), nil
+}
+
+// isMappable reports whether a file can be memory-mapped: only regular files
+// with a known, non-zero size (procfs files report 0)
+func isMappable(stat os.FileInfo) bool {
+	return stat.Mode().IsRegular() && stat.Size() > 0
+}
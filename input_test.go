@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestParseByteSize tests size arguments with and without suffixes
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"4096", 4096, false},
+		{"512K", 512 << 10, false},
+		{"64m", 64 << 20, false},
+		{"2GB", 2 << 30, false},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"lots", 0, true},
+		{"9999999999999G", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error but got %d", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestLoadFileStdin tests reading "-" from stdin with and without hitting the size cap
+func TestLoadFileStdin(t *testing.T) {
+	testData := []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+
+	fh := FileHandler{Stdin: bytes.NewReader(testData)}
+	data, cleanup, err := fh.LoadFile(StdinPath)
+	if err != nil {
+		t.Fatalf("Failed to read stdin: %v", err)
+	}
+	defer cleanup()
+	if !bytes.Equal(data, testData) {
+		t.Errorf("Expected %v, got %v", testData, data)
+	}
+
+	fh = FileHandler{Stdin: bytes.NewReader(testData), MaxSize: 4}
+	if _, _, err := fh.LoadFile(StdinPath); err == nil || !strings.Contains(err.Error(), "-max-size") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+
+	fh = FileHandler{Stdin: bytes.NewReader(testData[:2])}
+	if _, _, err := fh.LoadFile(StdinPath); err == nil {
+		t.Errorf("Expected error for input below %d bytes", MinInputSize)
+	}
+}
+
+// TestLoadFileDirectory tests that directories are rejected with a clear error
+func TestLoadFileDirectory(t *testing.T) {
+	_, _, err := FileHandler{}.LoadFile(t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("Expected directory error, got %v", err)
+	}
+}
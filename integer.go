@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RSAPublicExponentF4 is the customary RSA public exponent 65537 (Fermat number F4)
+const RSAPublicExponentF4 = 65537
+
+// decodeInteger converts two's complement INTEGER content to a big.Int
+func decodeInteger(content []byte) *big.Int {
+	value := new(big.Int).SetBytes(content)
+	if len(content) > 0 && content[0]&0x80 != 0 {
+		// Negative: subtract 2^(8*len) to undo two's complement
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(8*len(content))))
+	}
+	return value
+}
+
+// isMinimalInteger reports whether the INTEGER content uses the minimal
+// number of octets required by DER (X.690 8.3.2)
+func isMinimalInteger(content []byte) bool {
+	if len(content) < 2 {
+		return len(content) == 1
+	}
+	if content[0] == 0x00 && content[1]&0x80 == 0 {
+		return false
+	}
+	if content[0] == 0xFF && content[1]&0x80 != 0 {
+		return false
+	}
+	return true
+}
+
+// colonHex renders bytes as lowercase colon-separated hex, like openssl x509
+func colonHex(data []byte) string {
+	if len(data) == 0 {
+		return "00"
+	}
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, ":")
+}
+
+// formatInteger formats INTEGER content. Values that fit in 64 bits keep the
+// compact "decimal (0xHEX)" form; larger values are shown in decimal and
+// colon-separated hex, as openssl x509 prints serials, together with their
+// bit length.
+func formatInteger(content []byte) string {
+	if len(content) == 0 {
+		return "INVALID (empty INTEGER)"
+	}
+
+	value := decodeInteger(content)
+	var result string
+	if len(content) <= 8 {
+		result = fmt.Sprintf("%s (0x%X)", value.String(), content)
+	} else {
+		magnitude := new(big.Int).Abs(value)
+		sign := ""
+		if value.Sign() < 0 {
+			sign = "-"
+		}
+		unit := "bits"
+		if magnitude.BitLen() == 1 {
+			unit = "bit"
+		}
+		result = fmt.Sprintf("%s (%s%s, %d %s)", value.String(), sign, colonHex(magnitude.Bytes()), magnitude.BitLen(), unit)
+	}
+
+	if value.IsInt64() && value.Int64() == RSAPublicExponentF4 {
+		result += " [RSA public exponent F4]"
+	}
+	if !isMinimalInteger(content) {
+		result += " [non-minimal encoding]"
+	}
+	return result
+}
+
+// Structures whose INTEGERs integerAnnotation labels
+const (
+	structureOther = iota
+	// structureVersion is the [0] EXPLICIT wrapper of a certificate version
+	structureVersion
+	structureTBSCertificate
+	// structureTBSCertificateV1 omits the version, so the serial comes first
+	structureTBSCertificateV1
+	structureIssuerAndSerial
+	// structureCMS is SignedData or SignerInfo, which start with CMSVersion
+	structureCMS
+)
+
+// asn1Child is a direct child of a constructed element
+type asn1Child struct {
+	ASN1Element
+	content []byte
+}
+
+// asn1Children splits constructed content into its direct children,
+// stopping at the first element that does not parse
+func asn1Children(content []byte) []asn1Child {
+	var children []asn1Child
+	for pos := 0; pos < len(content); {
+		element, n, err := parseASN1Element(content[pos:], 0, pos)
+		if err != nil {
+			break
+		}
+		children = append(children, asn1Child{element, content[pos+element.HeaderLen : pos+n]})
+		pos += n
+	}
+	return children
+}
+
+// is reports whether a child has the universal tag, or the context tag when
+// class is 2
+func (c asn1Child) is(class, tag int) bool {
+	return c.Class == class && c.Tag == tag
+}
+
+// classifyStructure identifies the structure of a constructed element from
+// the tags of its children. Callers classify each constructed element once,
+// when descending into it, and pass the result to integerAnnotation for its
+// children.
+func classifyStructure(parent *ASN1Element, content []byte) int {
+	children := asn1Children(content)
+	if parent.Class == 2 && parent.Tag == 0 {
+		if len(children) == 1 && children[0].is(0, TagInteger) {
+			return structureVersion
+		}
+		return structureOther
+	}
+	if parent.Class != 0 || parent.Tag != TagSequence {
+		return structureOther
+	}
+
+	// TBSCertificate ::= SEQUENCE { [0] version OPTIONAL, serialNumber,
+	// signature AlgorithmIdentifier, issuer Name, validity, subject, ... }
+	rest, tbs := children, structureTBSCertificateV1
+	if len(rest) > 0 && rest[0].is(2, 0) && rest[0].IsCompound {
+		rest, tbs = rest[1:], structureTBSCertificate
+	}
+	if len(rest) >= 6 && rest[0].is(0, TagInteger) && rest[1].is(0, TagSequence) && rest[2].is(0, TagSequence) &&
+		rest[3].is(0, TagSequence) && isValidity(rest[3].content) && rest[4].is(0, TagSequence) {
+		return tbs
+	}
+
+	switch {
+	case isIssuerAndSerial(content):
+		return structureIssuerAndSerial
+	// SignedData ::= SEQUENCE { version, digestAlgorithms SET, encapContentInfo, ... }
+	case len(children) >= 3 && children[0].is(0, TagInteger) && children[1].is(0, TagSet) && children[1].IsCompound &&
+		children[2].is(0, TagSequence):
+		return structureCMS
+	// SignerInfo ::= SEQUENCE { version, sid, digestAlgorithm, ... }
+	case len(children) >= 4 && children[0].is(0, TagInteger) &&
+		((children[1].is(0, TagSequence) && isIssuerAndSerial(children[1].content)) ||
+			(children[1].is(2, 0) && !children[1].IsCompound)) &&
+		children[2].is(0, TagSequence):
+		return structureCMS
+	}
+	return structureOther
+}
+
+// isIssuerAndSerial reports whether content is an IssuerAndSerialNumber:
+// SEQUENCE { issuer Name, serialNumber }
+func isIssuerAndSerial(content []byte) bool {
+	children := asn1Children(content)
+	return len(children) == 2 && children[0].is(0, TagSequence) && isName(children[0].content) && children[1].is(0, TagInteger)
+}
+
+// isName reports whether content is an RDNSequence: SETs only
+func isName(content []byte) bool {
+	for _, child := range asn1Children(content) {
+		if !child.is(0, TagSet) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidity reports whether content holds the two times of a Validity
+func isValidity(content []byte) bool {
+	children := asn1Children(content)
+	if len(children) != 2 {
+		return false
+	}
+	for _, child := range children {
+		if !child.is(0, TagUTCTime) && !child.is(0, TagGeneralTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// integerAnnotation returns a note describing the role of the INTEGER at
+// index in an element of the given structure, as classifyStructure
+// identified it: the version of a certificate or of SignedData and
+// SignerInfo, or the serial number of a TBSCertificate or an
+// IssuerAndSerialNumber. Negative serials are flagged.
+func integerAnnotation(content []byte, structure, index int) string {
+	if len(content) == 0 {
+		return ""
+	}
+	value := decodeInteger(content)
+
+	serial := false
+	switch structure {
+	case structureVersion:
+		// TBSCertificate: version [0] EXPLICIT Version DEFAULT v1
+		if value.IsInt64() && value.Int64() >= 0 && value.Int64() <= 2 {
+			return fmt.Sprintf("[X.509 version v%d]", value.Int64()+1)
+		}
+		return "[invalid X.509 version]"
+	case structureCMS:
+		if index == 0 && value.IsInt64() && value.Int64() >= 0 && value.Int64() <= 5 {
+			return fmt.Sprintf("[version v%d]", value.Int64())
+		}
+	case structureTBSCertificate, structureIssuerAndSerial:
+		serial = index == 1
+	case structureTBSCertificateV1:
+		serial = index == 0
+	}
+	if !serial {
+		return ""
+	}
+	if value.Sign() < 0 {
+		return "[serial number, negative]"
+	}
+	return "[serial number]"
+}
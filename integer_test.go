@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestFormatInteger tests INTEGER rendering for small, large and unusual encodings
+func TestFormatInteger(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{
+			name:     "Small positive",
+			content:  []byte{0x01, 0x23},
+			expected: "291 (0x0123)",
+		},
+		{
+			name:     "Minus one",
+			content:  []byte{0xFF},
+			expected: "-1 (0xFF)",
+		},
+		{
+			name:     "Negative two bytes",
+			content:  []byte{0xFF, 0x00},
+			expected: "-256 (0xFF00)",
+		},
+		{
+			name:     "RSA exponent",
+			content:  []byte{0x01, 0x00, 0x01},
+			expected: "65537 (0x010001) [RSA public exponent F4]",
+		},
+		{
+			name:     "Large serial",
+			content:  []byte{0x00, 0xCA, 0xFC, 0xB5, 0xD7, 0x5E, 0xC5, 0x89, 0x82},
+			expected: "14626765626405128578 (ca:fc:b5:d7:5e:c5:89:82, 64 bits)",
+		},
+		{
+			name:     "Large negative",
+			content:  []byte{0xFF, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+			expected: "-9223372036854775809 (-80:00:00:00:00:00:00:01, 64 bits)",
+		},
+		{
+			name:     "Non-minimal positive",
+			content:  []byte{0x00, 0x05},
+			expected: "5 (0x0005) [non-minimal encoding]",
+		},
+		{
+			name:     "Empty",
+			content:  []byte{},
+			expected: "INVALID (empty INTEGER)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatInteger(tt.content)
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+// tlv encodes a short-form DER element for tests
+func tlv(tag byte, content ...[]byte) []byte {
+	value := bytes.Join(content, nil)
+	return append([]byte{tag, byte(len(value))}, value...)
+}
+
+// TestIntegerAnnotation tests version and serial number detection from the
+// enclosing structure
+func TestIntegerAnnotation(t *testing.T) {
+	sequence := &ASN1Element{Class: 0, Tag: TagSequence, IsCompound: true}
+	versionWrapper := &ASN1Element{Class: 2, Tag: 0, IsCompound: true}
+
+	algorithm := tlv(0x30, tlv(0x06, []byte{0x2A, 0x03}))
+	name := tlv(0x30, tlv(0x31))
+	validity := tlv(0x30, tlv(0x17, []byte("250101000000Z")), tlv(0x17, []byte("350101000000Z")))
+	tbsFields := [][]byte{algorithm, name, validity, name, tlv(0x30)}
+	tbsV3 := bytes.Join(append([][]byte{tlv(0xA0, tlv(0x02, []byte{0x02})), tlv(0x02, []byte{0x10})}, tbsFields...), nil)
+	tbsV1 := bytes.Join(append([][]byte{tlv(0x02, []byte{0x01})}, tbsFields...), nil)
+	signedData := bytes.Join([][]byte{tlv(0x02, []byte{0x01}), tlv(0x31), tlv(0x30, tlv(0x06, []byte{0x2A, 0x03}))}, nil)
+	issuerAndSerial := bytes.Join([][]byte{name, tlv(0x02, []byte{0x10})}, nil)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		parent   *ASN1Element
+		siblings []byte
+		index    int
+		expected string
+	}{
+		{"X.509 v3", []byte{0x02}, versionWrapper, tlv(0x02, []byte{0x02}), 0, "[X.509 version v3]"},
+		{"Serial after version", []byte{0x10}, sequence, tbsV3, 1, "[serial number]"},
+		{"Serial of v1 certificate", []byte{0x01}, sequence, tbsV1, 0, "[serial number]"},
+		{"CMS version", []byte{0x01}, sequence, signedData, 0, "[version v1]"},
+		{"Serial after issuer", []byte{0x10}, sequence, issuerAndSerial, 1, "[serial number]"},
+		{"Negative serial", []byte{0x80}, sequence, issuerAndSerial, 1, "[serial number, negative]"},
+		{"After AlgorithmIdentifier", []byte{0x05}, sequence, bytes.Join([][]byte{algorithm, tlv(0x02, []byte{0x05})}, nil), 1, ""},
+		{"First of two INTEGERs", []byte{0x01}, sequence, bytes.Join([][]byte{tlv(0x02, []byte{0x01}), tlv(0x02, []byte{0x02})}, nil), 0, ""},
+		{"Top level", []byte{0x01}, nil, nil, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structure := structureOther
+			if tt.parent != nil {
+				structure = classifyStructure(tt.parent, tt.siblings)
+			}
+			result := integerAnnotation(tt.content, structure, tt.index)
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestDisplayAnnotatesSerial tests that the displayer labels serial numbers
+func TestDisplayAnnotatesSerial(t *testing.T) {
+	// IssuerAndSerialNumber: SEQUENCE { SEQUENCE {}, INTEGER -1 }
+	testData := []byte{0x30, 0x05, 0x30, 0x00, 0x02, 0x01, 0xFF}
+
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	done := make(chan bool)
+	go func() {
+		buf.ReadFrom(r)
+		done <- true
+	}()
+
+	err := ASN1Displayer{}.Display(testData, 0)
+
+	w.Close()
+	os.Stdout = oldStdout
+	<-done
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "-1 (0xFF) [serial number, negative]") {
+		t.Errorf("Expected negative serial to be flagged, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// JAR signing layout (also used by the Android v1 scheme)
+const (
+	jarMetaDir   = "META-INF/"
+	jarManifest  = "META-INF/MANIFEST.MF"
+	jarSigSuffix = ".SF"
+)
+
+// jarBlockSuffixes are the extensions of PKCS#7 signature block files
+var jarBlockSuffixes = []string{".RSA", ".DSA", ".EC"}
+
+// jarDigestAlgorithms maps manifest digest attribute prefixes to hashes
+var jarDigestAlgorithms = map[string]crypto.Hash{
+	"MD5":     crypto.MD5,
+	"SHA1":    crypto.SHA1,
+	"SHA-1":   crypto.SHA1,
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+// jarSignature is one signature block with its signature file
+type jarSignature struct {
+	blockName string
+	block     []byte
+	sigName   string
+	sig       []byte
+}
+
+// readJarSignatures collects the signature blocks of a zip-based archive
+// (JAR, APK, ...) together with their .SF files and the manifest. It returns
+// no signatures for archives that are not signed this way.
+func readJarSignatures(data []byte, max int64) ([]jarSignature, []byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	budget := &extractBudget{remaining: max}
+	read := func(file *zip.File) ([]byte, error) {
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		defer rc.Close()
+		content, err := budget.read(rc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		return content, nil
+	}
+
+	var signatures []jarSignature
+	var manifest []byte
+	sigFiles := make(map[string]*zip.File)
+	for _, file := range zr.File {
+		upper := strings.ToUpper(file.Name)
+		if !strings.HasPrefix(upper, jarMetaDir) || strings.Contains(upper[len(jarMetaDir):], "/") {
+			continue
+		}
+		base := strings.TrimSuffix(upper, path.Ext(upper))
+		switch {
+		case upper == jarManifest:
+			if manifest, err = read(file); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasSuffix(upper, jarSigSuffix):
+			sigFiles[base] = file
+		case hasAnySuffix(upper, jarBlockSuffixes):
+			block, err := read(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			signatures = append(signatures, jarSignature{blockName: file.Name, block: block, sigName: base})
+		}
+	}
+
+	for i := range signatures {
+		file, found := sigFiles[signatures[i].sigName]
+		if !found {
+			signatures[i].sigName = ""
+			continue
+		}
+		signatures[i].sigName = file.Name
+		if signatures[i].sig, err = read(file); err != nil {
+			return nil, nil, err
+		}
+	}
+	return signatures, manifest, nil
+}
+
+// hasAnySuffix reports whether s ends with one of the suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeJarSignature classifies one signature block: the certificate fields
+// are checked like any other signature, and the block must also carry a
+// valid signature over its .SF file, whose manifest digest must match
+func analyzeJarSignature(sig jarSignature, manifest []byte) FileResult {
+	result := analyzeData(sig.block)
+	if result.Status == FileError {
+		return result
+	}
+
+	if err := verifyJarSignature(sig, manifest); err != nil {
+		result.Status = FileInvalid
+		result.Problem = err.Error()
+		return result
+	}
+	if result.Status == FileSigned {
+		result.Note = path.Base(sig.sigName) + " verified"
+	}
+	return result
+}
+
+// verifyJarSignature checks the PKCS#7 signature over the .SF file and the
+// manifest digest recorded in the .SF file
+func verifyJarSignature(sig jarSignature, manifest []byte) error {
+	if sig.sigName == "" {
+		return fmt.Errorf("no signature file %s%s for %s", strings.TrimSuffix(sig.blockName, path.Ext(sig.blockName)), jarSigSuffix, sig.blockName)
+	}
+	p7, err := ParsePKCS7(sig.block)
+	if err != nil {
+		return fmt.Errorf("not a PKCS#7 signature block: %w", err)
+	}
+	if err := p7.Verify(sig.sig); err != nil {
+		return fmt.Errorf("%s: %w", sig.sigName, err)
+	}
+	if err := checkManifestDigest(sig.sig, manifest); err != nil {
+		return fmt.Errorf("%s: %w", sig.sigName, err)
+	}
+	return nil
+}
+
+// checkManifestDigest compares the *-Digest-Manifest attributes in the main
+// section of a signature file against the manifest. Signature files without
+// a known whole-manifest digest have their per-entry *-Digest attributes
+// compared with the matching manifest sections instead. At least one digest
+// must be checked.
+func checkManifestDigest(sigFile, manifest []byte) error {
+	sections := manifestSections(sigFile)
+	if len(sections) == 0 {
+		return errors.New("empty signature file")
+	}
+	checked, err := checkDigests(sections[0].attributes, "-DIGEST-MANIFEST", manifest, "META-INF/MANIFEST.MF")
+	if err != nil || checked {
+		return err
+	}
+
+	entries := make(map[string][]byte)
+	for i, entry := range manifestSections(manifest) {
+		if i > 0 {
+			entries[entry.attributes["NAME"]] = entry.raw
+		}
+	}
+	for _, section := range sections[1:] {
+		name := section.attributes["NAME"]
+		if name == "" {
+			continue
+		}
+		raw, found := entries[name]
+		if !found && manifest == nil {
+			return errors.New("signed entry digests but the archive has no META-INF/MANIFEST.MF")
+		}
+		if !found {
+			return fmt.Errorf("entry %s has no section in META-INF/MANIFEST.MF", name)
+		}
+		sectionChecked, err := checkDigests(section.attributes, "-DIGEST", raw, "the MANIFEST.MF section of "+name)
+		if err != nil {
+			return err
+		}
+		checked = checked || sectionChecked
+	}
+	if !checked {
+		return errors.New("no supported digest of META-INF/MANIFEST.MF to check")
+	}
+	return nil
+}
+
+// checkDigests compares the attributes named <algorithm><suffix> against the
+// digest of data, described as what, and reports whether any algorithm was
+// known
+func checkDigests(attributes map[string]string, suffix string, data []byte, what string) (bool, error) {
+	checked := false
+	for name, value := range attributes {
+		algorithm, found := strings.CutSuffix(name, suffix)
+		if !found {
+			continue
+		}
+		hash, known := jarDigestAlgorithms[algorithm]
+		if !known || !hash.Available() {
+			continue
+		}
+		if data == nil {
+			return false, errors.New("signed manifest digest but the archive has no META-INF/MANIFEST.MF")
+		}
+		expected, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		h := hash.New()
+		h.Write(data)
+		if !bytes.Equal(h.Sum(nil), expected) {
+			return false, fmt.Errorf("%s digest of %s does not match", algorithm, what)
+		}
+		checked = true
+	}
+	return checked, nil
+}
+
+// manifestSection is one section of a manifest or signature file: its
+// attributes, with upper-cased names, and its raw bytes including the blank
+// line that ends it, which per-entry digests cover
+type manifestSection struct {
+	attributes map[string]string
+	raw        []byte
+}
+
+// manifestSections splits a manifest or signature file into its main
+// section and per-entry sections, joining continuation lines
+func manifestSections(data []byte) []manifestSection {
+	var sections []manifestSection
+	var current *manifestSection
+	var last string
+	start := 0
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n') + 1
+		if end == 0 {
+			end = len(data) - pos
+		}
+		line := strings.TrimRight(string(data[pos:pos+end]), "\r\n")
+		pos += end
+
+		if line == "" {
+			if current != nil {
+				current.raw = data[start:pos]
+				current = nil
+			}
+			start = pos
+			continue
+		}
+		if current == nil {
+			sections = append(sections, manifestSection{attributes: make(map[string]string)})
+			current = &sections[len(sections)-1]
+			last = ""
+		}
+		current.raw = data[start:pos]
+		if strings.HasPrefix(line, " ") && last != "" {
+			current.attributes[last] += line[1:]
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		last = strings.ToUpper(strings.TrimSpace(name))
+		current.attributes[last] = strings.TrimSpace(value)
+	}
+	return sections
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// buildSignedJar builds a JAR signed the v1 way by ts; tamper is applied to
+// the members after signing
+func buildSignedJar(t *testing.T, ts testSigner, tamper func(members []archiveMember)) []byte {
+	t.Helper()
+	manifest := []byte("Manifest-Version: 1.0\r\nCreated-By: test\r\n\r\nName: a.class\r\nSHA-256-Digest: AAAA\r\n\r\n")
+	digest := sha256.Sum256(manifest)
+	sigFile := []byte("Signature-Version: 1.0\r\nSHA-256-Digest-Manifest: " +
+		base64.StdEncoding.EncodeToString(digest[:]) + "\r\nCreated-By: test\r\n\r\n")
+
+	members := []archiveMember{
+		{"META-INF/MANIFEST.MF", manifest},
+		{"META-INF/CERT.SF", sigFile},
+		{"META-INF/CERT.RSA", ts.signDetached(t, sigFile, false)},
+		{"a.class", []byte("\xCA\xFE\xBA\xBE")},
+	}
+	if tamper != nil {
+		tamper(members)
+	}
+	return buildZip(t, members)
+}
+
+// TestAnalyzeJar tests signature block discovery and .SF verification
+func TestAnalyzeJar(t *testing.T) {
+	ts := newTestRSASigner(t)
+	b := Batch{}
+
+	tests := []struct {
+		name    string
+		tamper  func(members []archiveMember)
+		status  FileStatus
+		message string
+	}{
+		{"valid", nil, FileSigned, "CERT.SF verified"},
+		{"modified signature file", func(m []archiveMember) {
+			m[1].data = append(bytes.Clone(m[1].data), "X-Extra: 1\r\n"...)
+		}, FileInvalid, "messageDigest attribute does not match"},
+		{"modified manifest", func(m []archiveMember) {
+			m[0].data = bytes.Replace(m[0].data, []byte("AAAA"), []byte("BBBB"), 1)
+		}, FileInvalid, "SHA-256 digest of META-INF/MANIFEST.MF does not match"},
+		{"missing signature file", func(m []archiveMember) {
+			m[1].name = "META-INF/OTHER.SF"
+		}, FileInvalid, "no signature file META-INF/CERT.SF"},
+		{"garbage block", func(m []archiveMember) {
+			m[2].data = []byte("not a signature")
+		}, FileInvalid, "not a PKCS#7 signature block"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := b.analyzeArchive("app.jar", ArchiveZip, buildSignedJar(t, ts, tt.tamper), 0)
+			if len(results) != 1 {
+				t.Fatalf("Expected one result per signature block, got %d", len(results))
+			}
+			result := results[0]
+			if result.Path != "app.jar!META-INF/CERT.RSA" || result.Status != tt.status {
+				t.Errorf("Expected %s for app.jar!META-INF/CERT.RSA, got %s for %s", tt.status, result.Status, result.Path)
+			}
+
+			var buf bytes.Buffer
+			printFileResult(&buf, result)
+			if !strings.Contains(buf.String(), tt.message) {
+				t.Errorf("Expected output to mention %q, got %q", tt.message, buf.String())
+			}
+		})
+	}
+
+	// Zip files without signature blocks are still expanded member by member
+	plain := buildZip(t, archiveMembers)
+	if results := b.analyzeArchive("plain.zip", ArchiveZip, plain, 0); len(results) != len(archiveMembers) {
+		t.Errorf("Expected %d member results for an unsigned zip, got %d", len(archiveMembers), len(results))
+	}
+}
+
+// TestManifestSections tests section parsing with continuation lines and the
+// raw bytes of each section
+func TestManifestSections(t *testing.T) {
+	main := "Signature-Version: 1.0\r\nSHA-256-Digest-Manifest: abc\r\n def\r\n\r\n"
+	entry := "Name: x\r\nSHA-256-Digest: y\r\n"
+	sections := manifestSections([]byte(main + entry))
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+	if sections[0].attributes["SHA-256-DIGEST-MANIFEST"] != "abcdef" {
+		t.Errorf("Expected continuation line to be joined, got %q", sections[0].attributes["SHA-256-DIGEST-MANIFEST"])
+	}
+	if _, found := sections[0].attributes["NAME"]; found {
+		t.Errorf("Expected per-entry attributes to stay in their section")
+	}
+	if string(sections[0].raw) != main || string(sections[1].raw) != entry {
+		t.Errorf("Unexpected raw sections %q and %q", sections[0].raw, sections[1].raw)
+	}
+	if sections[1].attributes["NAME"] != "x" {
+		t.Errorf("Expected entry name x, got %q", sections[1].attributes["NAME"])
+	}
+}
+
+// TestCheckManifestDigest tests whole-manifest and per-entry digests of a
+// signature file
+func TestCheckManifestDigest(t *testing.T) {
+	section := "Name: a.class\r\nSHA-256-Digest: AAAA\r\n\r\n"
+	manifest := []byte("Manifest-Version: 1.0\r\n\r\n" + section)
+	digest := func(data string) string {
+		sum := sha256.Sum256([]byte(data))
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+	main := "Signature-Version: 1.0\r\n\r\n"
+
+	tests := []struct {
+		name    string
+		sigFile string
+		message string
+	}{
+		{"whole manifest", "Signature-Version: 1.0\r\nSHA-256-Digest-Manifest: " + digest(string(manifest)) + "\r\n\r\n", ""},
+		{"per entry", main + "Name: a.class\r\nSHA-256-Digest: " + digest(section) + "\r\n\r\n", ""},
+		{"per entry mismatch", main + "Name: a.class\r\nSHA-256-Digest: " + digest("other") + "\r\n\r\n", "SHA-256 digest of the MANIFEST.MF section of a.class does not match"},
+		{"entry not in manifest", main + "Name: b.class\r\nSHA-256-Digest: " + digest(section) + "\r\n\r\n", "entry b.class has no section"},
+		{"unknown algorithm", "Signature-Version: 1.0\r\nFOO-Digest-Manifest: AAAA\r\n\r\n", "no supported digest"},
+		{"no digest", main, "no supported digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkManifestDigest([]byte(tt.sigFile), manifest)
+			if tt.message == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.message != "" && (err == nil || !strings.Contains(err.Error(), tt.message)) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
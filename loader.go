@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Input is the content of a loaded file. Bytes returns the whole content,
+// which may be backed by a memory mapping that is only valid until Close.
+type Input interface {
+	io.ReaderAt
+	io.Closer
+	Bytes() []byte
+	Size() int64
+}
+
+// Loader turns a path into an Input. newPlatformLoader selects the best
+// implementation for the build target; MemoryLoader serves tests.
+type Loader interface {
+	Load(path string) (Input, error)
+}
+
+// bytesInput is an Input over a byte slice with an optional release function
+type bytesInput struct {
+	*bytes.Reader
+	data    []byte
+	release func() error
+}
+
+func newBytesInput(data []byte, release func() error) *bytesInput {
+	return &bytesInput{Reader: bytes.NewReader(data), data: data, release: release}
+}
+
+func (bi *bytesInput) Bytes() []byte {
+	return bi.data
+}
+
+func (bi *bytesInput) Close() error {
+	if bi.release == nil {
+		return nil
+	}
+	release := bi.release
+	bi.release = nil
+	return release()
+}
+
+// ReaderAtLoader reads files into memory through io.ReaderAt, which works on
+// every platform. Inputs without a usable size (pipes, devices, procfs) are
+// streamed instead and capped at MaxSize bytes; regular files are read
+// whole, as MmapLoader maps them.
+type ReaderAtLoader struct {
+	MaxSize int64
+}
+
+// Load reads the file at path
+func (rl ReaderAtLoader) Load(path string) (Input, error) {
+	file, stat, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return rl.load(file, stat)
+}
+
+// load reads an already opened file
+func (rl ReaderAtLoader) load(file *os.File, stat os.FileInfo) (Input, error) {
+	if isMappable(stat) {
+		return loadBuffered(io.NewSectionReader(file, 0, stat.Size()), stat.Size())
+	}
+	return loadBuffered(file, rl.maxSize())
+}
+
+func (rl ReaderAtLoader) maxSize() int64 {
+	if rl.MaxSize > 0 {
+		return rl.MaxSize
+	}
+	return DefaultMaxInputSize
+}
+
+// MemoryLoader serves inputs from memory, keyed by path
+type MemoryLoader map[string][]byte
+
+// Load returns the registered content for path
+func (ml MemoryLoader) Load(path string) (Input, error) {
+	data, exists := ml[path]
+	if !exists {
+		return nil, fmt.Errorf("error opening file: %w", &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist})
+	}
+	if err := checkInputSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	return newBytesInput(data, nil), nil
+}
+
+// openInput opens a path for loading and rejects directories
+func openInput(path string) (*os.File, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error getting file stats: %w", err)
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, nil, fmt.Errorf("%s is a directory", path)
+	}
+	return file, stat, nil
+}
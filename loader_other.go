@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !unix
+
+package main
+
+// newPlatformLoader returns the preferred Loader for this platform
+func newPlatformLoader(maxSize int64) Loader {
+	return ReaderAtLoader{MaxSize: maxSize}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMemoryLoader tests the in-memory loader used to feed FileHandler in tests
+func TestMemoryLoader(t *testing.T) {
+	testData := []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+	fh := FileHandler{Loader: MemoryLoader{"signed.efi": testData, "tiny.bin": {0x30}}}
+
+	data, cleanup, err := fh.LoadFile("signed.efi")
+	if err != nil {
+		t.Fatalf("Failed to load from memory: %v", err)
+	}
+	defer cleanup()
+	if !bytes.Equal(data, testData) {
+		t.Errorf("Expected %v, got %v", testData, data)
+	}
+
+	if _, _, err := fh.LoadFile("missing.efi"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+	if _, _, err := fh.LoadFile("tiny.bin"); err == nil {
+		t.Errorf("Expected error for input below %d bytes", MinInputSize)
+	}
+}
+
+// TestReaderAtLoader tests the portable loader on regular files and the size
+// cap on streams
+func TestReaderAtLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signature.der")
+	testData := bytes.Repeat([]byte{0x30, 0x82, 0x01, 0x00}, 16)
+	if err := os.WriteFile(path, testData, 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	input, err := ReaderAtLoader{}.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	defer input.Close()
+	if input.Size() != int64(len(testData)) || !bytes.Equal(input.Bytes(), testData) {
+		t.Errorf("Expected %d bytes, got %d", len(testData), input.Size())
+	}
+
+	// -max-size applies to streams, not to regular files
+	input, err = ReaderAtLoader{MaxSize: 16}.Load(path)
+	if err != nil {
+		t.Fatalf("Expected regular files to ignore the size cap, got %v", err)
+	}
+	input.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	go func() {
+		w.Write(testData)
+		w.Close()
+	}()
+	stat, err := r.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat pipe: %v", err)
+	}
+	if _, err := (ReaderAtLoader{MaxSize: 16}).load(r, stat); err == nil || !strings.Contains(err.Error(), "-max-size") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
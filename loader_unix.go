@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package main
+
+import (
+	"syscall"
+)
+
+// MmapLoader memory-maps regular files read-only and hands everything else
+// (and files the filesystem refuses to map) to ReaderAtLoader
+type MmapLoader struct {
+	Fallback ReaderAtLoader
+}
+
+// newPlatformLoader returns the preferred Loader for this platform
+func newPlatformLoader(maxSize int64) Loader {
+	return MmapLoader{Fallback: ReaderAtLoader{MaxSize: maxSize}}
+}
+
+// Load maps the file at path
+func (ml MmapLoader) Load(path string) (Input, error) {
+	file, stat, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isMappable(stat) {
+		defer file.Close()
+		return ml.Fallback.load(file, stat)
+	}
+	if err := checkInputSize(stat.Size()); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(stat.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		// Some filesystems (and sysfs) refuse mmap on regular files
+		defer file.Close()
+		return ml.Fallback.load(file, stat)
+	}
+
+	return newBytesInput(data, func() error {
+		if err := syscall.Munmap(data); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}), nil
+}
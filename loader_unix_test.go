@@ -0,0 +1,70 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestMmapLoader tests that regular files are mapped and released on Close
+func TestMmapLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signature.der")
+	testData := []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+	if err := os.WriteFile(path, testData, 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	loader := newPlatformLoader(0)
+	if _, ok := loader.(MmapLoader); !ok {
+		t.Fatalf("Expected MmapLoader on this platform, got %T", loader)
+	}
+
+	input, err := loader.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if !bytes.Equal(input.Bytes(), testData) {
+		t.Errorf("Expected %v, got %v", testData, input.Bytes())
+	}
+	buf := make([]byte, 2)
+	if _, err := input.ReadAt(buf, 3); err != nil || !bytes.Equal(buf, testData[3:]) {
+		t.Errorf("Expected ReadAt to return %v, got %v (%v)", testData[3:], buf, err)
+	}
+	if err := input.Close(); err != nil {
+		t.Errorf("Failed to release mapping: %v", err)
+	}
+	if err := input.Close(); err != nil {
+		t.Errorf("Expected second Close to be a no-op, got %v", err)
+	}
+}
+
+// TestLoadFileNamedPipe tests the buffered fallback for files that cannot be mapped
+func TestLoadFileNamedPipe(t *testing.T) {
+	fifo := filepath.Join(t.TempDir(), "input.fifo")
+	if err := syscall.Mkfifo(fifo, 0o600); err != nil {
+		t.Skipf("Named pipes unavailable: %v", err)
+	}
+
+	testData := bytes.Repeat([]byte{0x30, 0x82}, 64)
+	go func() {
+		w, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		w.Write(testData)
+		w.Close()
+	}()
+
+	data, cleanup, err := FileHandler{}.LoadFile(fifo)
+	if err != nil {
+		t.Fatalf("Failed to read named pipe: %v", err)
+	}
+	defer cleanup()
+	if !bytes.Equal(data, testData) {
+		t.Errorf("Expected %d bytes from pipe, got %d", len(testData), len(data))
+	}
+}
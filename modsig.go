@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ModuleSignatureMagic ends files carrying an appended signature (Linux
+// kernel modules, and ELF images such as GRUB for powerpc)
+const ModuleSignatureMagic = "~Module signature appended~\n"
+
+// Appended signature layout (struct module_signature in the kernel's
+// include/linux/module_signature.h)
+const (
+	moduleSigInfoSize = 12
+	// moduleSigIDPKCS7 is the id_type of PKCS#7 signatures; algo, hash,
+	// signer_len and key_id_len are zero for them
+	moduleSigIDPKCS7 = 2
+)
+
+// ModuleSignature is a parsed appended-signature trailer
+type ModuleSignature struct {
+	// Offset is where the signature data starts, i.e. the size of the
+	// unsigned content
+	Offset    int
+	IDType    byte
+	Signature []byte
+}
+
+// errNoAppendedSignature is returned for data without the magic
+var errNoAppendedSignature = errors.New("no appended signature")
+
+// hasModuleSignature reports whether data ends with the appended-signature magic
+func hasModuleSignature(data []byte) bool {
+	return bytes.HasSuffix(data, []byte(ModuleSignatureMagic))
+}
+
+// ParseModuleSignature parses the appended-signature trailer at the end of data
+func ParseModuleSignature(data []byte) (*ModuleSignature, error) {
+	if !hasModuleSignature(data) {
+		return nil, errNoAppendedSignature
+	}
+	info := len(data) - len(ModuleSignatureMagic) - moduleSigInfoSize
+	if info < 0 {
+		return nil, errors.New("truncated module_signature")
+	}
+	signerLen := int(data[info+3])
+	keyIDLen := int(data[info+4])
+	sigLen := int(binary.BigEndian.Uint32(data[info+8:]))
+
+	offset := info - sigLen - signerLen - keyIDLen
+	if sigLen <= 0 || offset < 0 {
+		return nil, fmt.Errorf("invalid module_signature (signature length %d)", sigLen)
+	}
+	return &ModuleSignature{
+		Offset:    offset,
+		IDType:    data[info+2],
+		Signature: data[info-sigLen : info],
+	}, nil
+}
+
+// appendModuleSignature returns content followed by signature and a
+// module_signature descriptor for PKCS#7 and the marker
+func appendModuleSignature(content, signature []byte) []byte {
+	out := make([]byte, 0, len(content)+len(signature)+moduleSigInfoSize+len(ModuleSignatureMagic))
+	out = append(out, content...)
+	out = append(out, signature...)
+	out = append(out, 0, 0, moduleSigIDPKCS7, 0, 0, 0, 0, 0)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(signature)))
+	return append(out, ModuleSignatureMagic...)
+}
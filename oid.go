@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// OID decoding errors; the returned errors wrap these with the byte offset
+var (
+	ErrOIDEmpty      = errors.New("empty object identifier")
+	ErrOIDNonMinimal = errors.New("non-minimal subidentifier encoding")
+	ErrOIDTruncated  = errors.New("truncated subidentifier")
+)
+
+// subidentifier holds one base-128 subidentifier; big is only used when the
+// value does not fit in 57 bits, so the common case stays allocation free
+type subidentifier struct {
+	small uint64
+	big   *big.Int
+}
+
+func (s subidentifier) String() string {
+	if s.big != nil {
+		return s.big.String()
+	}
+	return strconv.FormatUint(s.small, 10)
+}
+
+// decodeSubidentifiers splits OBJECT IDENTIFIER or RELATIVE-OID content into
+// subidentifiers per X.690 8.19.2, rejecting 0x80 leading octets and
+// subidentifiers whose last octet still has the continuation bit set
+func decodeSubidentifiers(content []byte) ([]subidentifier, error) {
+	var subids []subidentifier
+	i := 0
+	for i < len(content) {
+		start := i
+		if content[i] == 0x80 {
+			return nil, fmt.Errorf("%w at byte %d", ErrOIDNonMinimal, start)
+		}
+
+		var sub subidentifier
+		for {
+			if i >= len(content) {
+				return nil, fmt.Errorf("%w at byte %d", ErrOIDTruncated, start)
+			}
+			b := content[i]
+			i++
+
+			if sub.big == nil && sub.small>>57 != 0 {
+				sub.big = new(big.Int).SetUint64(sub.small)
+			}
+			if sub.big != nil {
+				sub.big.Lsh(sub.big, 7)
+				sub.big.Or(sub.big, big.NewInt(int64(b&0x7F)))
+			} else {
+				sub.small = sub.small<<7 | uint64(b&0x7F)
+			}
+
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		subids = append(subids, sub)
+	}
+	return subids, nil
+}
+
+// decodeOID decodes OBJECT IDENTIFIER content into dotted notation. The first
+// subidentifier combines the first two arcs as 40*X+Y, where Y is unbounded
+// when X is 2 (joint-iso-itu-t), so values of 80 and above belong to arc 2.
+func decodeOID(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", ErrOIDEmpty
+	}
+
+	subids, err := decodeSubidentifiers(content)
+	if err != nil {
+		return "", err
+	}
+
+	arcs := make([]string, 0, len(subids)+1)
+	first := subids[0]
+	switch {
+	case first.big == nil && first.small < 40:
+		arcs = append(arcs, "0", strconv.FormatUint(first.small, 10))
+	case first.big == nil && first.small < 80:
+		arcs = append(arcs, "1", strconv.FormatUint(first.small-40, 10))
+	case first.big == nil:
+		arcs = append(arcs, "2", strconv.FormatUint(first.small-80, 10))
+	default:
+		arcs = append(arcs, "2", new(big.Int).Sub(first.big, big.NewInt(80)).String())
+	}
+	for _, sub := range subids[1:] {
+		arcs = append(arcs, sub.String())
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+// decodeRelativeOID decodes RELATIVE-OID content, whose subidentifiers map
+// one-to-one onto arcs relative to an implied base
+func decodeRelativeOID(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", ErrOIDEmpty
+	}
+
+	subids, err := decodeSubidentifiers(content)
+	if err != nil {
+		return "", err
+	}
+
+	arcs := make([]string, len(subids))
+	for i, sub := range subids {
+		arcs[i] = sub.String()
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+// parseOID parses an ASN.1 OBJECT IDENTIFIER, returning an empty string when
+// the content is not a valid encoding
+func parseOID(content []byte) string {
+	oid, err := decodeOID(content)
+	if err != nil {
+		return ""
+	}
+	return oid
+}
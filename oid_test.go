@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDecodeOID tests X.690 OID decoding including large and malformed arcs
+func TestDecodeOID(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "sha256",
+			content:  []byte{0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01},
+			expected: "2.16.840.1.101.3.4.2.1",
+		},
+		{
+			name:     "itu-t arc",
+			content:  []byte{0x00},
+			expected: "0.0",
+		},
+		{
+			name:     "iso arc boundary",
+			content:  []byte{0x4F},
+			expected: "1.39",
+		},
+		{
+			name:     "joint-iso-itu-t 2.999",
+			content:  []byte{0x88, 0x37, 0x03},
+			expected: "2.999.3",
+		},
+		{
+			name:     "joint-iso-itu-t 2.40",
+			content:  []byte{0x78},
+			expected: "2.40",
+		},
+		{
+			name:     "UUID arc 2.25",
+			content:  []byte{0x69, 0x83, 0xF0, 0x9D, 0xA7, 0xEB, 0xCF, 0xDE, 0xE0, 0xC7, 0xA1, 0xA7, 0xB2, 0xC0, 0x94, 0x8C, 0xC8, 0xF9, 0xD7, 0x76},
+			expected: "2.25.329800735698586629295641978511506172918",
+		},
+		{
+			name:     "64-bit overflow arc",
+			content:  []byte{0x2A, 0x82, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00},
+			expected: "1.2.18446744073709551616",
+		},
+		{
+			name:        "Empty",
+			content:     []byte{},
+			expectedErr: ErrOIDEmpty,
+		},
+		{
+			name:        "Non-minimal subidentifier",
+			content:     []byte{0x2A, 0x80, 0x01},
+			expectedErr: ErrOIDNonMinimal,
+		},
+		{
+			name:        "Non-minimal first subidentifier",
+			content:     []byte{0x80, 0x2A},
+			expectedErr: ErrOIDNonMinimal,
+		},
+		{
+			name:        "Truncated",
+			content:     []byte{0x2A, 0x86, 0x48, 0x86},
+			expectedErr: ErrOIDTruncated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeOID(tt.content)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v (%s)", tt.expectedErr, err, result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestDecodeRelativeOID tests RELATIVE-OID decoding
+func TestDecodeRelativeOID(t *testing.T) {
+	result, err := decodeRelativeOID([]byte{0x08, 0x86, 0x48, 0x01})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "8.840.1" {
+		t.Errorf("Expected '8.840.1', got '%s'", result)
+	}
+
+	if _, err := decodeRelativeOID([]byte{0x86}); !errors.Is(err, ErrOIDTruncated) {
+		t.Errorf("Expected truncation error, got %v", err)
+	}
+}
+
+// TestFormatInvalidOID tests that malformed OIDs are reported instead of rendered
+func TestFormatInvalidOID(t *testing.T) {
+	result := formatPrimitiveContent(TagObjectID, []byte{0x55, 0x04, 0x83})
+	if !strings.HasPrefix(result, "INVALID OID") {
+		t.Errorf("Expected invalid OID to be reported, got '%s'", result)
+	}
+}
@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxFuzzyResults limits how many fuzzy name matches the oid subcommand prints
+const maxFuzzyResults = 20
+
+// OIDMatch is a single result of LookupOID
+type OIDMatch struct {
+	OID    string
+	Name   string
+	Source string
+	// Distance is 0 for exact matches and grows with the edit distance of
+	// fuzzy name matches
+	Distance int
+}
+
+// Exact reports whether the match is exact rather than fuzzy
+func (m OIDMatch) Exact() bool {
+	return m.Distance == 0
+}
+
+// LookupOID resolves a dotted OID to its registered name, or a name to the
+// OIDs registered under it. Names are matched case-insensitively ignoring
+// '-', '_' and spaces; when there is no exact match, names containing the
+// query or within a small edit distance are returned, closest first.
+func LookupOID(query string) []OIDMatch {
+	query = strings.TrimSpace(query)
+	if isDottedOID(query) {
+		if name, exists := oidNames[query]; exists {
+			return []OIDMatch{{OID: query, Name: name, Source: oidSource(query)}}
+		}
+		return nil
+	}
+
+	needle := normalizeOIDName(query)
+	if needle == "" {
+		return nil
+	}
+	maxDistance := len(needle) / 4
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var matches []OIDMatch
+	for oid, name := range oidNames {
+		candidate := normalizeOIDName(name)
+		var distance int
+		switch {
+		case candidate == needle:
+			distance = 0
+		case strings.HasPrefix(candidate, needle):
+			distance = 1
+		case strings.Contains(candidate, needle):
+			distance = 2
+		default:
+			distance = levenshtein(needle, candidate)
+			if distance > maxDistance {
+				continue
+			}
+			distance += 2
+		}
+		matches = append(matches, OIDMatch{OID: oid, Name: name, Source: oidSource(oid), Distance: distance})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		if matches[i].Name != matches[j].Name {
+			return matches[i].Name < matches[j].Name
+		}
+		return matches[i].OID < matches[j].OID
+	})
+
+	if len(matches) > 0 && matches[0].Exact() {
+		exact := matches[:0]
+		for _, m := range matches {
+			if m.Exact() {
+				exact = append(exact, m)
+			}
+		}
+		return exact
+	}
+	return matches
+}
+
+// normalizeOIDName folds case and drops separators so that "SHA-256",
+// "sha_256" and "sha256" compare equal
+func normalizeOIDName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// EncodeOID returns the DER encoding (tag, length and content) of a dotted OID
+func EncodeOID(oid string) ([]byte, error) {
+	if !isDottedOID(oid) {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+
+	arcs := strings.Split(oid, ".")
+	values := make([]*big.Int, len(arcs))
+	for i, arc := range arcs {
+		values[i], _ = new(big.Int).SetString(arc, 10)
+	}
+	if values[0].Int64() < 2 && values[1].Cmp(big.NewInt(40)) >= 0 {
+		return nil, fmt.Errorf("invalid OID %q: second arc must be below 40 under arc %s", oid, arcs[0])
+	}
+
+	// The first subidentifier combines the first two arcs as 40*X+Y
+	first := new(big.Int).Mul(values[0], big.NewInt(40))
+	first.Add(first, values[1])
+
+	var content []byte
+	for _, value := range append([]*big.Int{first}, values[2:]...) {
+		content = append(content, encodeBase128(value)...)
+	}
+	return append(encodeDERHeader(TagObjectID, len(content)), content...), nil
+}
+
+// encodeBase128 encodes a subidentifier in big-endian base 128 with
+// continuation bits on all but the last octet
+func encodeBase128(value *big.Int) []byte {
+	if value.Sign() == 0 {
+		return []byte{0}
+	}
+	v := new(big.Int).Set(value)
+	mask := big.NewInt(0x7F)
+	var groups []byte
+	for v.Sign() > 0 {
+		groups = append(groups, byte(new(big.Int).And(v, mask).Int64()))
+		v.Rsh(v, 7)
+	}
+	out := make([]byte, len(groups))
+	for i := range groups {
+		out[i] = groups[len(groups)-1-i]
+		if i < len(groups)-1 {
+			out[i] |= 0x80
+		}
+	}
+	return out
+}
+
+// encodeDERHeader returns the identifier and definite-length octets for a
+// universal primitive element
+func encodeDERHeader(tag int, length int) []byte {
+	header := []byte{byte(tag)}
+	if length < 0x80 {
+		return append(header, byte(length))
+	}
+	var lengthBytes []byte
+	for l := length; l > 0; l >>= 8 {
+		lengthBytes = append([]byte{byte(l)}, lengthBytes...)
+	}
+	header = append(header, 0x80|byte(len(lengthBytes)))
+	return append(header, lengthBytes...)
+}
+
+// DecodeOIDHex decodes a hex string holding either a complete DER OBJECT
+// IDENTIFIER (06 len ...) or just its content octets. Spaces, colons and a
+// leading 0x are ignored.
+func DecodeOIDHex(s string) (string, error) {
+	cleaned := strings.NewReplacer(" ", "", ":", "", "\t", "").Replace(strings.TrimSpace(s))
+	cleaned = strings.TrimPrefix(strings.TrimPrefix(cleaned, "0x"), "0X")
+	data, err := hex.DecodeString(cleaned)
+	if err != nil {
+		return "", fmt.Errorf("invalid hex: %w", err)
+	}
+
+	if len(data) >= 2 && data[0] == TagObjectID {
+		element, bytesRead, err := parseASN1Element(data, 0, 0)
+		if err == nil && bytesRead == len(data) {
+			data = data[element.HeaderLen:]
+		}
+	}
+	return decodeOID(data)
+}
+
+// formatHexBytes renders bytes as space-separated lowercase hex pairs
+func formatHexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, " ")
+}
+
+// runOIDCommand implements the "oid" subcommand and returns the exit code
+func runOIDCommand(args []string) int {
+	fs := flag.NewFlagSet("oid", flag.ContinueOnError)
+	var oidFiles stringList
+	decode := fs.Bool("decode", false, "treat arguments as DER hex and decode them to dotted OIDs")
+	fs.Var(&oidFiles, "oids", "load additional OID names from `file` (text or .json, repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s oid [options] <oid|name|hex>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nResolves dotted OIDs to names and DER encodings, and names (case-insensitive,\n")
+		fmt.Fprintf(os.Stderr, "fuzzy) to OIDs. Exits 0 if every query resolves to a registered name and 1\n")
+		fmt.Fprintf(os.Stderr, "otherwise, including for unknown dotted OIDs.\n")
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s oid 1.3.6.1.4.1.311.10.3.6        # Name and DER bytes of an OID\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s oid SHA-256                       # OID registered for a name\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s oid -decode 0609608648016503040201 # Decode DER hex to an OID\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if err := loadOIDRegistry(oidFiles); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+
+	status := ExitValid
+	for _, query := range fs.Args() {
+		if !printOIDLookup(query, *decode) {
+			status = ExitDifferent
+		}
+	}
+	return status
+}
+
+// printOIDLookup prints the result of one oid subcommand query and reports
+// whether it resolved
+func printOIDLookup(query string, decode bool) bool {
+	if decode {
+		oid, err := DecodeOIDHex(query)
+		if err != nil {
+			fmt.Printf("%s: %v\n", query, err)
+			return false
+		}
+		query = oid
+	}
+
+	if isDottedOID(query) {
+		encoded, err := EncodeOID(query)
+		if err != nil {
+			fmt.Printf("%s: %v\n", query, err)
+			return false
+		}
+		// Unknown OIDs still show their encoding but do not resolve
+		matches := LookupOID(query)
+		if len(matches) == 0 {
+			fmt.Printf("%s → (unknown)\n", query)
+		} else {
+			fmt.Printf("%s → %s  [%s]\n", query, matches[0].Name, matches[0].Source)
+		}
+		fmt.Printf("  DER: %s\n", formatHexBytes(encoded))
+		return len(matches) > 0
+	}
+
+	matches := LookupOID(query)
+	if len(matches) == 0 {
+		fmt.Printf("%s: no matching OID name\n", query)
+		return false
+	}
+	if !matches[0].Exact() {
+		fmt.Printf("%s: no exact match, closest names:\n", query)
+		if len(matches) > maxFuzzyResults {
+			matches = matches[:maxFuzzyResults]
+		}
+		for _, m := range matches {
+			fmt.Printf("  %s → %s  [%s]\n", m.Name, m.OID, m.Source)
+		}
+		return true
+	}
+	for _, m := range matches {
+		fmt.Printf("%s → %s  [%s]\n", m.Name, m.OID, m.Source)
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLookupOID tests forward, reverse and fuzzy OID lookups
+func TestLookupOID(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		firstOID  string
+		firstName string
+		exact     bool
+	}{
+		{"Dotted OID", "1.3.6.1.4.1.311.10.3.6", "1.3.6.1.4.1.311.10.3.6", "spcEncryptedDigestRetryCount", true},
+		{"Exact name", "sha256", "2.16.840.1.101.3.4.2.1", "sha256", true},
+		{"Case and separators", "SHA-256", "2.16.840.1.101.3.4.2.1", "sha256", true},
+		{"Prefix", "spcEncrypted", "1.3.6.1.4.1.311.10.3.6", "spcEncryptedDigestRetryCount", false},
+		{"Typo", "comonName", OIDCommonName, "commonName", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := LookupOID(tt.query)
+			if len(matches) == 0 {
+				t.Fatalf("Expected matches for %q", tt.query)
+			}
+			if matches[0].OID != tt.firstOID || matches[0].Name != tt.firstName {
+				t.Errorf("Expected %s (%s), got %s (%s)", tt.firstOID, tt.firstName, matches[0].OID, matches[0].Name)
+			}
+			if matches[0].Exact() != tt.exact {
+				t.Errorf("Expected exact=%v, got %v", tt.exact, matches[0].Exact())
+			}
+			if matches[0].Source != OIDSourceBuiltin {
+				t.Errorf("Expected built-in source, got %s", matches[0].Source)
+			}
+		})
+	}
+
+	if matches := LookupOID("1.2.3.4.5.6.7"); len(matches) != 0 {
+		t.Errorf("Expected no match for unknown OID, got %+v", matches)
+	}
+	if matches := LookupOID("zzzzzzzzzzzz"); len(matches) != 0 {
+		t.Errorf("Expected no match for unrelated name, got %+v", matches)
+	}
+}
+
+// TestRunOIDCommand tests that unknown dotted OIDs do not count as resolved
+func TestRunOIDCommand(t *testing.T) {
+	var status int
+	output := captureStdout(t, func() { status = runOIDCommand([]string{"1.2.3.4.5.6.7"}) })
+	if status != 1 {
+		t.Errorf("Expected exit code 1 for an unknown OID, got %d", status)
+	}
+	if !strings.Contains(output, "1.2.3.4.5.6.7 → (unknown)\n  DER: 06 06 2a 03 04 05 06 07") {
+		t.Errorf("Expected the encoding of the unknown OID, got:\n%s", output)
+	}
+
+	captureStdout(t, func() { status = runOIDCommand([]string{"1.3.6.1.4.1.311.10.3.6", "sha256"}) })
+	if status != 0 {
+		t.Errorf("Expected exit code 0 when every query resolves, got %d", status)
+	}
+}
+
+// TestEncodeOID tests DER encoding of dotted OIDs and the round trip through decoding
+func TestEncodeOID(t *testing.T) {
+	tests := []struct {
+		oid      string
+		expected []byte
+	}{
+		{"1.2.840.113549.1.1.1", []byte{0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x01, 0x01}},
+		{"2.999.3", []byte{0x06, 0x03, 0x88, 0x37, 0x03}},
+		{"0.0", []byte{0x06, 0x01, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.oid, func(t *testing.T) {
+			encoded, err := EncodeOID(tt.oid)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(encoded, tt.expected) {
+				t.Errorf("Expected % x, got % x", tt.expected, encoded)
+			}
+
+			decoded, err := DecodeOIDHex(formatHexBytes(encoded))
+			if err != nil {
+				t.Fatalf("Unexpected decode error: %v", err)
+			}
+			if decoded != tt.oid {
+				t.Errorf("Expected round trip to %s, got %s", tt.oid, decoded)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"1.40", "3.1", "1", "1..2", "sha256"} {
+		if _, err := EncodeOID(invalid); err == nil {
+			t.Errorf("Expected error encoding %q", invalid)
+		}
+	}
+}
+
+// TestDecodeOIDHex tests the accepted hex input forms
+func TestDecodeOIDHex(t *testing.T) {
+	inputs := []string{
+		"0609608648016503040201",
+		"06 09 60 86 48 01 65 03 04 02 01",
+		"0x608648016503040201",
+		"60:86:48:01:65:03:04:02:01",
+	}
+	for _, input := range inputs {
+		oid, err := DecodeOIDHex(input)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+			continue
+		}
+		if oid != "2.16.840.1.101.3.4.2.1" {
+			t.Errorf("Expected sha256 OID for %q, got %s", input, oid)
+		}
+	}
+
+	if _, err := DecodeOIDHex("zz"); err == nil {
+		t.Error("Expected error for invalid hex")
+	}
+}
+
+// TestLevenshtein tests the edit distance used for fuzzy matching
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"sha256", "sha256", 0},
+		{"sha265", "sha256", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if d := levenshtein(tt.a, tt.b); d != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, d, tt.expected)
+		}
+	}
+}
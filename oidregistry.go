@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OIDSourceBuiltin marks names compiled into the binary
+const OIDSourceBuiltin = "built-in"
+
+// OIDConfigDirName is the directory below the user configuration directory
+// (e.g. ~/.config/autograph-pls/oids.d) scanned for additional OID files
+const OIDConfigDirName = "autograph-pls/oids.d"
+
+// OIDEntry describes a named object identifier loaded from an OID file
+type OIDEntry struct {
+	OID         string `json:"oid"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Source      string `json:"-"`
+}
+
+// oidUserEntries holds entries loaded from OID files, keyed by OID; their
+// names are also merged into oidNames so lookups see a single map
+var oidUserEntries = map[string]OIDEntry{}
+
+// oidSource returns where the name of an OID comes from
+func oidSource(oid string) string {
+	if entry, exists := oidUserEntries[oid]; exists {
+		return entry.Source
+	}
+	return OIDSourceBuiltin
+}
+
+// registerOIDs merges entries over the built-in names; later entries win
+func registerOIDs(entries []OIDEntry) {
+	for _, entry := range entries {
+		oidNames[entry.OID] = entry.Name
+		oidUserEntries[entry.OID] = entry
+	}
+}
+
+// loadOIDRegistry loads every OID file from the configuration directory,
+// followed by the explicitly requested files, and merges them over the
+// built-in names. A missing configuration directory is not an error.
+func loadOIDRegistry(files []string) error {
+	var paths []string
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirFiles, err := listOIDDir(filepath.Join(configDir, OIDConfigDirName))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, dirFiles...)
+	}
+	paths = append(paths, files...)
+
+	for _, path := range paths {
+		entries, err := LoadOIDFile(path)
+		if err != nil {
+			return err
+		}
+		registerOIDs(entries)
+	}
+	return nil
+}
+
+// listOIDDir returns the OID files (*.oids, *.txt, *.json) of a directory in
+// lexical order, so numbered prefixes control precedence
+func listOIDDir(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading OID directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range dirEntries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".oids", ".txt", ".json":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadOIDFile reads an OID file. Files ending in .json hold an array of
+// {"oid", "name", "description", "category"} objects; any other file uses
+// the text format, one entry per line:
+//
+//	# comment
+//	1.3.6.1.4.1.99999.1 examplePolicy "Example Corp code signing policy" policy
+//
+// Fields are separated by whitespace and may be double-quoted.
+func LoadOIDFile(path string) ([]OIDEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OID file: %w", err)
+	}
+
+	var entries []OIDEntry
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("%s: invalid JSON: %w", path, err)
+		}
+		for i, entry := range entries {
+			if err := validateOIDEntry(entry); err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %w", path, i+1, err)
+			}
+		}
+	} else {
+		entries, err = parseOIDText(data, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range entries {
+		entries[i].Source = path
+	}
+	return entries, nil
+}
+
+// parseOIDText parses the line-based OID file format
+func parseOIDText(data []byte, path string) ([]OIDEntry, error) {
+	var entries []OIDEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitOIDFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if len(fields) < 2 || len(fields) > 4 {
+			return nil, fmt.Errorf("%s:%d: expected 'oid name [description] [category]'", path, lineNo)
+		}
+
+		entry := OIDEntry{OID: fields[0], Name: fields[1]}
+		if len(fields) > 2 {
+			entry.Description = fields[2]
+		}
+		if len(fields) > 3 {
+			entry.Category = fields[3]
+		}
+		if err := validateOIDEntry(entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// splitOIDFields splits a line on whitespace, keeping double-quoted fields whole
+func splitOIDFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return fields, nil
+		}
+		if line[0] == '"' {
+			field, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quoted field")
+			}
+			unquoted, _ := strconv.Unquote(field)
+			fields = append(fields, unquoted)
+			line = line[len(field):]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+}
+
+// validateOIDEntry checks that an entry has a name and a well-formed dotted OID
+func validateOIDEntry(entry OIDEntry) error {
+	if entry.Name == "" {
+		return fmt.Errorf("missing name for OID %q", entry.OID)
+	}
+	if !isDottedOID(entry.OID) {
+		return fmt.Errorf("invalid OID %q", entry.OID)
+	}
+	return nil
+}
+
+// isDottedOID reports whether s is a dotted OID with at least two arcs, a
+// first arc of 0, 1 or 2, a second arc below 40 under arcs 0 and 1 (X.660)
+// and no leading zeros
+func isDottedOID(s string) bool {
+	arcs := strings.Split(s, ".")
+	if len(arcs) < 2 {
+		return false
+	}
+	for _, arc := range arcs {
+		if arc == "" || (len(arc) > 1 && arc[0] == '0') {
+			return false
+		}
+		for _, c := range arc {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	switch arcs[0] {
+	case "0", "1":
+		return len(arcs[1]) == 1 || (len(arcs[1]) == 2 && arcs[1] < "40")
+	case "2":
+		return true
+	}
+	return false
+}
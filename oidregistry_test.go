@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// restoreOIDRegistry snapshots the global OID tables and restores them after the test
+func restoreOIDRegistry(t *testing.T) {
+	names := make(map[string]string, len(oidNames))
+	for oid, name := range oidNames {
+		names[oid] = name
+	}
+	entries := make(map[string]OIDEntry, len(oidUserEntries))
+	for oid, entry := range oidUserEntries {
+		entries[oid] = entry
+	}
+	t.Cleanup(func() {
+		oidNames = names
+		oidUserEntries = entries
+	})
+}
+
+// TestLoadOIDFile tests the text and JSON OID file formats
+func TestLoadOIDFile(t *testing.T) {
+	dir := t.TempDir()
+
+	textFile := filepath.Join(dir, "corp.oids")
+	text := "# internal policies\n" +
+		"1.3.6.1.4.1.99999.1 examplePolicy \"Example Corp code signing\" policy\n" +
+		"\n" +
+		"1.3.6.1.4.1.99999.2\tbuildHost\n"
+	if err := os.WriteFile(textFile, []byte(text), 0o644); err != nil {
+		t.Fatalf("Failed to write OID file: %v", err)
+	}
+
+	entries, err := LoadOIDFile(textFile)
+	if err != nil {
+		t.Fatalf("Failed to load text OID file: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	expected := OIDEntry{
+		OID:         "1.3.6.1.4.1.99999.1",
+		Name:        "examplePolicy",
+		Description: "Example Corp code signing",
+		Category:    "policy",
+		Source:      textFile,
+	}
+	if entries[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, entries[0])
+	}
+
+	jsonFile := filepath.Join(dir, "corp.json")
+	jsonData := `[{"oid": "1.3.6.1.4.1.99999.3", "name": "releaseKey", "category": "key"}]`
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0o644); err != nil {
+		t.Fatalf("Failed to write OID file: %v", err)
+	}
+
+	entries, err = LoadOIDFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to load JSON OID file: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "releaseKey" || entries[0].Category != "key" {
+		t.Errorf("Unexpected JSON entries: %+v", entries)
+	}
+}
+
+// TestLoadOIDFileErrors tests that malformed OID files are rejected
+func TestLoadOIDFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"Missing name", "a.oids", "1.2.3\n"},
+		{"Bad OID", "b.oids", "1.2.x name\n"},
+		{"Bad first arc", "c.oids", "3.1 name\n"},
+		{"Second arc too big", "h.oids", "1.40 name\n"},
+		{"Unterminated quote", "d.oids", "1.2.3 name \"open\n"},
+		{"Too many fields", "e.oids", "1.2.3 a b c d\n"},
+		{"Invalid JSON", "f.json", "{"},
+		{"JSON without name", "g.json", `[{"oid": "1.2.3"}]`},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("Failed to write OID file: %v", err)
+			}
+			if _, err := LoadOIDFile(path); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+}
+
+// TestRegisterOIDs tests that loaded names override built-ins and record their source
+func TestRegisterOIDs(t *testing.T) {
+	restoreOIDRegistry(t)
+
+	registerOIDs([]OIDEntry{
+		{OID: "1.3.6.1.4.1.99999.1", Name: "examplePolicy", Source: "corp.oids"},
+		{OID: OIDCommonName, Name: "CN", Source: "corp.oids"},
+	})
+
+	if oidNames["1.3.6.1.4.1.99999.1"] != "examplePolicy" {
+		t.Errorf("Expected new OID to be registered")
+	}
+	if oidNames[OIDCommonName] != "CN" {
+		t.Errorf("Expected built-in name to be overridden, got %s", oidNames[OIDCommonName])
+	}
+	if source := oidSource(OIDCommonName); source != "corp.oids" {
+		t.Errorf("Expected source corp.oids, got %s", source)
+	}
+	if source := oidSource("1.2.840.113549.1.1.1"); source != OIDSourceBuiltin {
+		t.Errorf("Expected built-in source, got %s", source)
+	}
+
+	result := formatPrimitiveContent(TagObjectID, []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0x86, 0x8D, 0x1F, 0x01})
+	if result != "1.3.6.1.4.1.99999.1 (examplePolicy)" {
+		t.Errorf("Expected loaded name in display, got %s", result)
+	}
+}
+
+// TestLoadOIDRegistryConfigDir tests that files in the configuration directory are merged before -oids files
+func TestLoadOIDRegistryConfigDir(t *testing.T) {
+	restoreOIDRegistry(t)
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("HOME", configHome)
+	oidDir := filepath.Join(configHome, OIDConfigDirName)
+	if err := os.MkdirAll(oidDir, 0o755); err != nil {
+		t.Fatalf("Failed to create OID directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(oidDir, "10-corp.oids"), []byte("1.3.6.1.4.1.99999.1 fromConfig\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write OID file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(oidDir, "README"), []byte("not an OID file"), 0o644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	explicit := filepath.Join(t.TempDir(), "override.oids")
+	if err := os.WriteFile(explicit, []byte("1.3.6.1.4.1.99999.1 fromFlag\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write OID file: %v", err)
+	}
+
+	if err := loadOIDRegistry([]string{explicit}); err != nil {
+		t.Fatalf("Failed to load OID registry: %v", err)
+	}
+	if name := oidNames["1.3.6.1.4.1.99999.1"]; name != "fromFlag" {
+		t.Errorf("Expected -oids file to take precedence, got %s", name)
+	}
+}
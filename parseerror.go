@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxElementLength bounds the length of a single element, so corrupted
+// length octets cannot announce gigabytes of content
+const maxElementLength = 50 * 1024 * 1024
+
+// ParseErrorKind classifies why an ASN.1 element failed to parse
+type ParseErrorKind int
+
+const (
+	// ParseTruncated elements end inside their tag or length octets
+	ParseTruncated ParseErrorKind = iota
+	// ParseIndefiniteLength elements use the BER indefinite length form,
+	// which DER forbids
+	ParseIndefiniteLength
+	// ParseExcessiveLength elements announce more than maxElementLength bytes
+	ParseExcessiveLength
+	// ParseOverrun elements extend beyond the data that encloses them
+	ParseOverrun
+	// ParseTooDeep elements are nested deeper than MaxRecursionDepth
+	ParseTooDeep
+)
+
+func (k ParseErrorKind) String() string {
+	switch k {
+	case ParseTruncated:
+		return "truncated"
+	case ParseIndefiniteLength:
+		return "indefinite-length"
+	case ParseExcessiveLength:
+		return "excessive-length"
+	case ParseOverrun:
+		return "overrun"
+	case ParseTooDeep:
+		return "too-deep"
+	}
+	return fmt.Sprintf("ParseErrorKind(%d)", int(k))
+}
+
+// ParseError describes an ASN.1 element that failed to parse. Offsets are
+// absolute file offsets; Expected and Available count bytes and are zero
+// when they do not apply.
+type ParseError struct {
+	Kind ParseErrorKind
+	// Offset is where the element starts and At the byte that broke it:
+	// the tag, or the first length octet for length errors
+	Offset int
+	At     int
+	// Path is the element's position as child indices, e.g. "0.1.3", when
+	// the caller tracks it
+	Path      string
+	Expected  int
+	Available int
+}
+
+func (e *ParseError) Error() string {
+	location := fmt.Sprintf("offset %d", e.Offset)
+	if e.Path != "" {
+		location += fmt.Sprintf(" (element %s)", e.Path)
+	}
+	return location + ": " + e.Reason()
+}
+
+// Reason describes the error without its location
+func (e *ParseError) Reason() string {
+	var reason string
+	switch e.Kind {
+	case ParseTruncated:
+		reason = "insufficient data for the element header"
+	case ParseIndefiniteLength:
+		reason = "indefinite length not supported"
+	case ParseExcessiveLength:
+		reason = fmt.Sprintf("length exceeds the %d MiB limit", maxElementLength>>20)
+	case ParseOverrun:
+		reason = "element extends beyond available data"
+	case ParseTooDeep:
+		reason = "maximum parsing depth exceeded"
+	default:
+		reason = e.Kind.String()
+	}
+	if e.Expected > 0 {
+		reason += fmt.Sprintf(" (%d bytes needed, %d available)", e.Expected, e.Available)
+	}
+	return reason
+}
+
+// ParseErrors collects the parse errors of a structure in file order
+type ParseErrors []*ParseError
+
+func (pe ParseErrors) Error() string {
+	if len(pe) == 1 {
+		return pe[0].Error()
+	}
+	messages := make([]string, len(pe))
+	for i, err := range pe {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d parse errors: %s", len(pe), strings.Join(messages, "; "))
+}
+
+// Unwrap exposes the individual errors to errors.Is and errors.As
+func (pe ParseErrors) Unwrap() []error {
+	errs := make([]error, len(pe))
+	for i, err := range pe {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParseASN1ElementErrors tests the kind, offsets and counts of parse errors
+func TestParseASN1ElementErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     []byte
+		depth     int
+		kind      ParseErrorKind
+		at        int
+		expected  int
+		available int
+	}{
+		{"tag only", []byte{0x30}, 0, ParseTruncated, 40, 2, 1},
+		{"missing length octets", []byte{0x30, 0x82, 0x01}, 0, ParseTruncated, 41, 4, 3},
+		{"indefinite length", []byte{0x30, 0x80, 0x00, 0x00}, 0, ParseIndefiniteLength, 41, 0, 0},
+		{"excessive length", []byte{0x04, 0x84, 0x7F, 0xFF, 0xFF, 0xFF}, 0, ParseExcessiveLength, 41, 0, 0},
+		{"overrun", []byte{0x04, 0x05, 0x01, 0x02}, 0, ParseOverrun, 41, 7, 4},
+		{"too deep", []byte{0x05, 0x00}, MaxRecursionDepth + 1, ParseTooDeep, 40, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseASN1Element(tt.input, tt.depth, 40)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %v", err)
+			}
+			if parseErr.Kind != tt.kind || parseErr.Offset != 40 || parseErr.At != tt.at ||
+				parseErr.Expected != tt.expected || parseErr.Available != tt.available {
+				t.Errorf("Unexpected error %+v", parseErr)
+			}
+		})
+	}
+
+	err := &ParseError{Kind: ParseOverrun, Offset: 108, At: 109, Path: "0.1.0", Expected: 11, Available: 6}
+	if got := err.Error(); got != "offset 108 (element 0.1.0): element extends beyond available data (11 bytes needed, 6 available)" {
+		t.Errorf("Unexpected message %q", got)
+	}
+}
+
+// TestDisplayContinuesPastErrors tests that broken elements do not end the display
+func TestDisplayContinuesPastErrors(t *testing.T) {
+	// SEQUENCE { SEQUENCE { OCTET STRING overrun }, INTEGER 7, SEQUENCE { INTEGER excessive }, NULL },
+	// BOOLEAN
+	der := []byte{
+		0x30, 0x13,
+		0x30, 0x04, 0x04, 0x09, 0x41, 0x42,
+		0x02, 0x01, 0x07,
+		0x30, 0x06, 0x02, 0x84, 0x7F, 0xFF, 0xFF, 0xFF,
+		0x05, 0x00,
+		0x01, 0x01, 0x00,
+	}
+	var err error
+	output := captureStdout(t, func() { err = ASN1Displayer{}.Display(der, 0) })
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected two parse errors, got %v", err)
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Path != "0.0.0" || parseErr.Kind != ParseOverrun {
+		t.Errorf("Expected errors.As to find the first error, got %+v", parseErr)
+	}
+	if errs[1].Path != "0.2.0" || errs[1].Kind != ParseExcessiveLength || errs[1].Offset != 13 {
+		t.Errorf("Unexpected second error %+v", errs[1])
+	}
+	for _, want := range []string{
+		"    [PARSE ERROR]: offset 4 (element 0.0.0): element extends beyond available data (11 bytes needed, 4 available)\n    [HEX DUMP]: 04094142\n",
+		"INTEGER  7 (0x07)",
+		"NULL",
+		"BOOLEAN",
+		"Parse errors: 2\n  [overrun] offset 4 (element 0.0.0)",
+		"  [excessive-length] offset 13 (element 0.2.0): length exceeds the 50 MiB limit\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+// TestDisplayDescendsIntoOverrun tests that the available content of a
+// constructed element that overruns its level is still shown
+func TestDisplayDescendsIntoOverrun(t *testing.T) {
+	// SEQUENCE { SEQUENCE (10 bytes, 3 available) { INTEGER 7 } }
+	der := []byte{0x30, 0x05, 0x30, 0x0A, 0x02, 0x01, 0x07}
+	var err error
+	output := captureStdout(t, func() { err = ASN1Displayer{}.Display(der, 0) })
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != ParseOverrun || parseErr.Path != "0.0" {
+		t.Fatalf("Expected the overrun of element 0.0, got %v", err)
+	}
+	want := "      2:d=1 hl=2 l=10 cons: SEQUENCE\n" +
+		"  [PARSE ERROR]: offset 2 (element 0.0): element extends beyond available data (12 bytes needed, 5 available)\n" +
+		"      4:d=2 hl=2 l=1 prim: INTEGER  7 (0x07)\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected %q in:\n%s", want, output)
+	}
+}
@@ -10,14 +10,18 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
-	"syscall"
 )
 
 // ASN.1 universal tag constants (complete set)
 const (
 	// Basic types
+	TagEOC              = 0
 	TagBoolean          = 1
 	TagInteger          = 2
 	TagBitString        = 3
@@ -67,219 +71,50 @@ const (
 	OIDEmailAddress     = "1.2.840.113549.1.9.1"
 )
 
-// Common OID mappings for display
-var oidNames = map[string]string{
-	// RSA signature algorithms
-	"1.2.840.113549.1.1.1":  "rsaEncryption",
-	"1.2.840.113549.1.1.2":  "md2WithRSAEncryption",
-	"1.2.840.113549.1.1.4":  "md5WithRSAEncryption",
-	"1.2.840.113549.1.1.5":  "sha1WithRSAEncryption",
-	"1.2.840.113549.1.1.10": "rsaPSS",
-	"1.2.840.113549.1.1.11": "sha256WithRSAEncryption",
-	"1.2.840.113549.1.1.12": "sha384WithRSAEncryption",
-	"1.2.840.113549.1.1.13": "sha512WithRSAEncryption",
-	"1.2.840.113549.1.1.14": "sha224WithRSAEncryption",
-	"1.2.840.113549.1.1.15": "sha512-224WithRSAEncryption",
-	"1.2.840.113549.1.1.16": "sha512-256WithRSAEncryption",
-
-	// ECDSA signature algorithms
-	"1.2.840.10045.2.1":   "ecPublicKey",
-	"1.2.840.10045.4.1":   "ecdsa-with-SHA1",
-	"1.2.840.10045.4.3.1": "ecdsa-with-SHA224",
-	"1.2.840.10045.4.3.2": "ecdsa-with-SHA256",
-	"1.2.840.10045.4.3.3": "ecdsa-with-SHA384",
-	"1.2.840.10045.4.3.4": "ecdsa-with-SHA512",
-
-	// DSA signature algorithms
-	"1.2.840.10040.4.1":      "dsaEncryption",
-	"1.2.840.10040.4.3":      "dsa-with-sha1",
-	"2.16.840.1.101.3.4.3.1": "dsa-with-sha224",
-	"2.16.840.1.101.3.4.3.2": "dsa-with-sha256",
-
-	// EdDSA algorithms
-	"1.3.101.112": "Ed25519",
-	"1.3.101.113": "Ed448",
-
-	// GOST signature algorithms (Russian standards)
-	"1.2.643.2.2.19":    "gost3410-2001",
-	"1.2.643.7.1.1.1.1": "gost3410-2012-256",
-	"1.2.643.7.1.1.1.2": "gost3410-2012-512",
-	"1.2.643.2.2.3":     "gost3411-94-with-gost3410-2001",
-	"1.2.643.7.1.1.3.2": "gost3411-2012-256-with-gost3410-2012-256",
-	"1.2.643.7.1.1.3.3": "gost3411-2012-512-with-gost3410-2012-512",
-
-	// Hash algorithms
-	"1.2.840.113549.2.5":      "md5",
-	"1.3.14.3.2.26":           "sha1",
-	"2.16.840.1.101.3.4.2.1":  "sha256",
-	"2.16.840.1.101.3.4.2.2":  "sha384",
-	"2.16.840.1.101.3.4.2.3":  "sha512",
-	"2.16.840.1.101.3.4.2.4":  "sha224",
-	"2.16.840.1.101.3.4.2.5":  "sha512-224",
-	"2.16.840.1.101.3.4.2.6":  "sha512-256",
-	"2.16.840.1.101.3.4.2.7":  "sha3-224",
-	"2.16.840.1.101.3.4.2.8":  "sha3-256",
-	"2.16.840.1.101.3.4.2.9":  "sha3-384",
-	"2.16.840.1.101.3.4.2.10": "sha3-512",
-	"2.16.840.1.101.3.4.2.11": "shake128",
-	"2.16.840.1.101.3.4.2.12": "shake256",
-
-	// GOST hash algorithms
-	"1.2.643.2.2.9":     "gost3411-94",
-	"1.2.643.7.1.1.2.2": "gost3411-2012-256",
-	"1.2.643.7.1.1.2.3": "gost3411-2012-512",
-
-	// Elliptic curves
-	"1.2.840.10045.3.1.1": "prime192v1",
-	"1.2.840.10045.3.1.7": "prime256v1",
-	"1.3.132.0.34":        "secp384r1",
-	"1.3.132.0.35":        "secp521r1",
-	"1.3.132.0.10":        "secp256k1",
-	"1.2.840.10045.3.1.2": "prime192v2",
-	"1.2.840.10045.3.1.3": "prime192v3",
-	"1.2.840.10045.3.1.4": "prime239v1",
-	"1.2.840.10045.3.1.5": "prime239v2",
-	"1.2.840.10045.3.1.6": "prime239v3",
-
-	// Post-quantum algorithms (NIST standardized)
-	"2.16.840.1.101.3.4.3.17":  "ml-dsa-44",
-	"2.16.840.1.101.3.4.3.18":  "ml-dsa-65",
-	"2.16.840.1.101.3.4.3.19":  "ml-dsa-87",
-	"1.3.6.1.4.1.2.267.12.4.4": "falcon-512",
-	"1.3.6.1.4.1.2.267.12.6.5": "falcon-1024",
-	"2.16.840.1.101.3.4.3.20":  "ml-kem-512",
-	"2.16.840.1.101.3.4.3.21":  "ml-kem-768",
-	"2.16.840.1.101.3.4.3.22":  "ml-kem-1024",
-
-	// FIDO/WebAuthn algorithms
-	"1.3.101.110": "X25519",
-	"1.3.101.111": "X448",
-
-	// Additional modern elliptic curves
-	"1.3.36.3.3.2.8.1.1.7":  "brainpoolP256r1",
-	"1.3.36.3.3.2.8.1.1.11": "brainpoolP384r1",
-	"1.3.36.3.3.2.8.1.1.13": "brainpoolP512r1",
-
-	// Microsoft specific OIDs
-	"1.3.6.1.4.1.311.2.1.4":  "spcIndirectDataContent",
-	"1.3.6.1.4.1.311.2.1.15": "spcPEImageData",
-	"1.3.6.1.4.1.311.10.3.6": "spcEncryptedDigestRetryCount",
-	"1.3.6.1.4.1.311.10.3.1": "microsoftCertTrustListSigning",
-	"1.3.6.1.4.1.311.10.3.4": "microsoftEncryptedFileSystem",
-	"1.3.6.1.4.1.311.20.2.2": "microsoftSmartcardLogon",
-	"1.3.6.1.4.1.311.21.19":  "microsoftCertificateTemplate",
-	"1.3.6.1.4.1.311.21.20":  "microsoftCertificateManager",
-
-	// PKCS#7 / CMS content types
-	"1.2.840.113549.1.7.1": "pkcs7-data",
-	"1.2.840.113549.1.7.2": "pkcs7-signedData",
-	"1.2.840.113549.1.7.3": "pkcs7-envelopedData",
-	"1.2.840.113549.1.7.4": "pkcs7-signedAndEnvelopedData",
-	"1.2.840.113549.1.7.5": "pkcs7-digestedData",
-	"1.2.840.113549.1.7.6": "pkcs7-encryptedData",
-
-	// PKCS#9 attributes
-	"1.2.840.113549.1.9.2":  "unstructuredName",
-	"1.2.840.113549.1.9.3":  "contentTypes",
-	"1.2.840.113549.1.9.4":  "messageDigest",
-	"1.2.840.113549.1.9.5":  "signingTime",
-	"1.2.840.113549.1.9.6":  "countersignature",
-	"1.2.840.113549.1.9.7":  "challengePassword",
-	"1.2.840.113549.1.9.8":  "unstructuredAddress",
-	"1.2.840.113549.1.9.9":  "extendedCertificateAttributes",
-	"1.2.840.113549.1.9.14": "extensionReq",
-	"1.2.840.113549.1.9.15": "sMIMECapabilities",
-	"1.2.840.113549.1.9.16": "sMIMEObjectIdentifier",
-	"1.2.840.113549.1.9.20": "friendlyName",
-	"1.2.840.113549.1.9.21": "localKeyID",
-
-	// Certificate extensions
-	"2.5.29.14": "subjectKeyIdentifier",
-	"2.5.29.15": "keyUsage",
-	"2.5.29.17": "subjectAltName",
-	"2.5.29.19": "basicConstraints",
-	"2.5.29.32": "certificatePolicies",
-	"2.5.29.35": "authorityKeyIdentifier",
-	"2.5.29.37": "extKeyUsage",
-
-	// Extended key usage
-	"1.3.6.1.5.5.7.3.1": "serverAuth",
-	"1.3.6.1.5.5.7.3.2": "clientAuth",
-	"1.3.6.1.5.5.7.3.3": "codeSigning",
-	"1.3.6.1.5.5.7.3.4": "emailProtection",
-	"1.3.6.1.5.5.7.3.8": "timeStamping",
-
-	// Distinguished name attributes
-	OIDCommonName:       "commonName",
-	OIDCountryName:      "countryName",
-	OIDLocalityName:     "localityName",
-	OIDOrganizationName: "organizationName",
-	OIDEmailAddress:     "emailAddress",
-	"2.5.4.4":           "surname",
-	"2.5.4.5":           "serialNumber",
-	"2.5.4.8":           "stateOrProvinceName",
-	"2.5.4.9":           "streetAddress",
-	"2.5.4.11":          "organizationalUnitName",
-	"2.5.4.12":          "title",
-	"2.5.4.42":          "givenName",
-	"2.5.4.43":          "initials",
-	"2.5.4.44":          "generationQualifier",
-	"2.5.4.46":          "dnQualifier",
-	"2.5.4.65":          "pseudonym",
-
-	// Symmetric encryption algorithms
-	"2.16.840.1.101.3.4.1.2":  "aes128-cbc",
-	"2.16.840.1.101.3.4.1.6":  "aes128-gcm",
-	"2.16.840.1.101.3.4.1.22": "aes192-cbc",
-	"2.16.840.1.101.3.4.1.26": "aes192-gcm",
-	"2.16.840.1.101.3.4.1.42": "aes256-cbc",
-	"2.16.840.1.101.3.4.1.46": "aes256-gcm",
-	"1.2.840.113549.3.2":      "rc2-cbc",
-	"1.2.840.113549.3.4":      "rc4",
-
-	// ChaCha20-Poly1305 and modern stream ciphers
-	"1.2.840.113549.1.9.16.3.18": "chacha20-poly1305",
-
-	// Certificate policy OIDs
-	"2.23.140.1.2.1": "domain-validated",
-	"2.23.140.1.2.2": "organization-validated",
-	"2.23.140.1.2.3": "individual-validated",
-
-	// FIDO Alliance OIDs
-	"1.3.6.1.4.1.45724.1.1.4": "fido-u2f-transports",
-	"1.3.6.1.4.1.45724.2.1.1": "fido-authenticator-aaguid",
-
-	// GOST encryption algorithms
-	"1.2.643.2.2.21":    "gost28147-89",
-	"1.2.643.7.1.1.5.1": "gost3412-2015-magma",
-	"1.2.643.7.1.1.5.2": "gost3412-2015-kuznyechik",
-
-	// Chinese algorithms (SM series)
-	"1.2.156.10197.1.301":   "sm2",
-	"1.2.156.10197.1.401":   "sm3",
-	"1.2.156.10197.1.104.1": "sm4-ecb",
-	"1.2.156.10197.1.104.2": "sm4-cbc",
-
-	// Japanese algorithms
-	"1.2.392.200011.61.1.1.1.2": "camellia128-cbc",
-	"1.2.392.200011.61.1.1.1.3": "camellia192-cbc",
-	"1.2.392.200011.61.1.1.1.4": "camellia256-cbc",
-
-	// Legacy algorithms
-	"1.2.840.113549.3.7":     "des-ede3-cbc",
-	"2.16.840.1.101.2.1.1.2": "fortezzaDSS",
-	"1.2.840.113549.3.1":     "rc4-40",
-}
-
 const version = "0.0.1"
 
+// errNoValidSignature is returned when no signature with the required
+// certificate fields is found
+var errNoValidSignature = errors.New("no valid signature found")
+
 // Config holds command-line configuration
 type Config struct {
 	FilePath       string
 	SaveFile       bool
 	OutputFile     string
+	Outform        string
+	ExtractDir     string
 	ListAlgorithms bool
+	ListCategory   string
+	Format         string
+	Indent         bool
+	HexDump        bool
 	ShowVersion    bool
+	OIDFiles       stringList
+	MaxInputSize   byteSize
+	FilePaths      []string
+	Recursive      bool
+	Include        stringList
+	Exclude        stringList
+	Jobs           int
+}
+
+// BatchMode reports whether the paths are summarised rather than analysed
+// in detail
+func (c *Config) BatchMode() bool {
+	return len(c.FilePaths) > 1 || c.Recursive
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ",")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
 }
 
 // SignatureValidation holds validation results for signature fields
@@ -363,7 +198,7 @@ func (sp *SignatureParser) FindValidSignature() (*asn1.RawValue, int, error) {
 		}
 	}
 
-	return nil, 0, errors.New("no valid signature found")
+	return nil, 0, errNoValidSignature
 }
 
 // validateSignatureFields checks for required certificate fields in ASN.1 data
@@ -422,6 +257,10 @@ func (sp *SignatureParser) findFieldsInASN1WithDepth(data []byte, validation *Si
 					}
 					valueBytes := data[valueStart:valueEnd]
 					valueContent := string(valueBytes)
+					if valueElement.Class == 0 && isASN1StringTag(valueElement.Tag) {
+						// Display value is kept even when the character set check fails
+						valueContent, _ = decodeASN1String(valueElement.Tag, valueBytes)
+					}
 
 					sp.setValidationField(validation, oid, valueContent)
 				}
@@ -557,31 +396,81 @@ func (dr DisplayResults) printField(name string, hasField bool, value string) {
 // ASN1Displayer handles ASN.1 structure display
 type ASN1Displayer struct{}
 
-// Display parses and displays ASN.1 structure
+// Display parses and displays ASN.1 structure. An element that fails to
+// parse is reported where it occurs, with the rest of its level as a hex
+// dump or, for a constructed element that overruns, with the elements in
+// the bytes available, and the display continues after the enclosing
+// element. All parse errors are listed at the end and returned as
+// ParseErrors.
 func (ad ASN1Displayer) Display(data []byte, baseOffset int) error {
-	return ad.parseAndDisplayASN1(data, 0, baseOffset)
+	var errs ParseErrors
+	ad.parseAndDisplayASN1(data, 0, baseOffset, structureOther, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	fmt.Printf("Parse errors: %d\n", len(errs))
+	for _, err := range errs {
+		fmt.Printf("  [%s] %v\n", err.Kind, err)
+	}
+	return errs
 }
 
-// parseAndDisplayASN1 recursively parses and displays ASN.1 structure
-func (ad ASN1Displayer) parseAndDisplayASN1(data []byte, depth int, baseOffset int) error {
+// parseAndDisplayASN1 recursively parses and displays ASN.1 structure;
+// structure classifies the enclosing element at path, structureOther at the
+// top level. Parse errors are appended to errs.
+func (ad ASN1Displayer) parseAndDisplayASN1(data []byte, depth int, baseOffset int, structure int, path string, errs *ParseErrors) {
+	indent := strings.Repeat("  ", depth)
+
 	// Prevent infinite recursion
 	if depth > MaxRecursionDepth {
-		fmt.Printf("%s[MAX DEPTH REACHED]: Recursion limit exceeded\n", strings.Repeat("  ", depth))
-		return errors.New("maximum recursion depth exceeded")
+		fmt.Printf("%s[MAX DEPTH REACHED]: Recursion limit exceeded\n", indent)
+		*errs = append(*errs, &ParseError{Kind: ParseTooDeep, Offset: baseOffset, At: baseOffset, Path: path})
+		return
 	}
 
 	// Safety check for nil or empty data
 	if data == nil || len(data) == 0 {
-		return nil
+		return
 	}
 
 	offset := 0
 	elementCount := 0
 
 	for offset < len(data) && elementCount < MaxElementsPerLevel {
+		elementPath := strconv.Itoa(elementCount)
+		if path != "" {
+			elementPath = path + "." + elementPath
+		}
+
 		element, bytesRead, err := parseASN1Element(data[offset:], depth, baseOffset+offset)
 		if err != nil {
-			return err
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				fmt.Printf("%s[PARSE ERROR]: %v\n", indent, err)
+				return
+			}
+			parseErr.Path = elementPath
+			*errs = append(*errs, parseErr)
+			// A constructed element that overruns its level still has an
+			// intact header: show what is there of its content
+			if parseErr.Kind == ParseOverrun && element.IsCompound {
+				ad.displayElement(element)
+				fmt.Printf("%s[PARSE ERROR]: %v\n", indent, parseErr)
+				content := data[offset+element.HeaderLen:]
+				ad.parseAndDisplayASN1(content, depth+1, baseOffset+offset+element.HeaderLen, classifyStructure(&element, content), elementPath, errs)
+				return
+			}
+			// Without a usable length the next element cannot be found
+			fmt.Printf("%s[PARSE ERROR]: %v\n", indent, parseErr)
+			fmt.Printf("%s[HEX DUMP]: %s\n", indent, hex.EncodeToString(data[offset:]))
+			return
+		}
+
+		if element.Class == 0 && element.Tag == TagInteger && !element.IsCompound {
+			content := data[offset+element.HeaderLen : offset+bytesRead]
+			if note := integerAnnotation(content, structure, elementCount); note != "" {
+				element.Content += " " + note
+			}
 		}
 
 		ad.displayElement(element)
@@ -594,11 +483,7 @@ func (ad ASN1Displayer) parseAndDisplayASN1(data []byte, depth int, baseOffset i
 				displayEnd := displayStart + element.Length
 				if displayStart >= 0 && displayEnd >= 0 && displayStart < len(data) && displayEnd <= len(data) && displayStart <= displayEnd {
 					content := data[displayStart:displayEnd]
-					if err := ad.parseAndDisplayASN1(content, depth+1, baseOffset+offset+contentStart); err != nil {
-						// If parsing nested content fails, show as hex dump
-						fmt.Printf("%s[HEX DUMP]: %s\n", strings.Repeat("  ", depth+1),
-							hex.EncodeToString(content))
-					}
+					ad.parseAndDisplayASN1(content, depth+1, baseOffset+offset+contentStart, classifyStructure(&element, content), elementPath, errs)
 				}
 			}
 		}
@@ -608,12 +493,10 @@ func (ad ASN1Displayer) parseAndDisplayASN1(data []byte, depth int, baseOffset i
 
 		// Additional safety check to prevent runaway parsing
 		if elementCount >= MaxElementsPerLevel {
-			fmt.Printf("%s[TRUNCATED]: Too many elements at this level\n", strings.Repeat("  ", depth))
+			fmt.Printf("%s[TRUNCATED]: Too many elements at this level\n", indent)
 			break
 		}
 	}
-
-	return nil
 }
 
 // displayElement displays a single ASN.1 element
@@ -639,229 +522,124 @@ func (ad ASN1Displayer) displayElement(element ASN1Element) {
 }
 
 // FileHandler handles file operations
-type FileHandler struct{}
-
-// LoadFile loads and memory-maps a file
-func (fh FileHandler) LoadFile(filePath string) ([]byte, func() error, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error opening file: %w", err)
-	}
-
-	stat, err := file.Stat()
-	if err != nil {
-		file.Close()
-		return nil, nil, fmt.Errorf("error getting file stats: %w", err)
-	}
-
-	fileSize := stat.Size()
-	if fileSize < 4 {
-		file.Close()
-		return nil, nil, errors.New("file too small to contain ASN.1 structure")
-	}
-
-	data, err := syscall.Mmap(int(file.Fd()), 0, int(fileSize), syscall.PROT_READ, syscall.MAP_SHARED)
-	if err != nil {
-		file.Close()
-		return nil, nil, fmt.Errorf("error memory-mapping file: %w", err)
-	}
-
-	cleanup := func() error {
-		if err := syscall.Munmap(data); err != nil {
-			file.Close()
-			return err
-		}
-		return file.Close()
-	}
-
-	return data, cleanup, nil
+type FileHandler struct {
+	// MaxSize caps inputs that have to be read into memory; zero selects
+	// DefaultMaxInputSize
+	MaxSize int64
+	// Stdin is read for the "-" path; nil selects os.Stdin
+	Stdin io.Reader
+	// Loader loads file paths; nil selects the platform loader
+	Loader Loader
 }
 
-// SaveToFile saves data to a file
-func (fh FileHandler) SaveToFile(data []byte, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	_, err = file.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to write data: %w", err)
+// maxSize returns the effective buffered read limit
+func (fh FileHandler) maxSize() int64 {
+	if fh.MaxSize > 0 {
+		return fh.MaxSize
 	}
-
-	return nil
+	return DefaultMaxInputSize
 }
 
-// listSupportedAlgorithms displays all supported cryptographic algorithms and OIDs
-func listSupportedAlgorithms() {
-	fmt.Println("=== SUPPORTED CRYPTOGRAPHIC ALGORITHMS AND OIDs ===")
-	fmt.Println()
-
-	fmt.Println("📋 RSA Signature Algorithms:")
-	rsaOids := []string{
-		"1.2.840.113549.1.1.1", "1.2.840.113549.1.1.2", "1.2.840.113549.1.1.4",
-		"1.2.840.113549.1.1.5", "1.2.840.113549.1.1.10", "1.2.840.113549.1.1.11",
-		"1.2.840.113549.1.1.12", "1.2.840.113549.1.1.13", "1.2.840.113549.1.1.14",
-		"1.2.840.113549.1.1.15", "1.2.840.113549.1.1.16",
-	}
-	for _, oid := range rsaOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
+// Open loads a file, or stdin for "-", as an Input; errors are read errors
+func (fh FileHandler) Open(filePath string) (Input, error) {
+	var input Input
+	var err error
+	if filePath == StdinPath {
+		stdin := fh.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
 		}
-	}
-
-	fmt.Println("\n🔐 ECDSA Signature Algorithms:")
-	ecdsaOids := []string{
-		"1.2.840.10045.2.1", "1.2.840.10045.4.1", "1.2.840.10045.4.3.1",
-		"1.2.840.10045.4.3.2", "1.2.840.10045.4.3.3", "1.2.840.10045.4.3.4",
-	}
-	for _, oid := range ecdsaOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
-	}
-
-	fmt.Println("\n🔑 DSA Signature Algorithms:")
-	dsaOids := []string{
-		"1.2.840.10040.4.1", "1.2.840.10040.4.3", "2.16.840.1.101.3.4.3.1", "2.16.840.1.101.3.4.3.2",
-	}
-	for _, oid := range dsaOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
-	}
-
-	fmt.Println("\n🆕 EdDSA Algorithms:")
-	eddsaOids := []string{"1.3.101.112", "1.3.101.113"}
-	for _, oid := range eddsaOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
+		input, err = loadBuffered(stdin, fh.maxSize())
+	} else {
+		loader := fh.Loader
+		if loader == nil {
+			loader = newPlatformLoader(fh.maxSize())
 		}
+		input, err = loader.Load(filePath)
 	}
-
-	fmt.Println("\n#️⃣ Hash Algorithms:")
-	hashOids := []string{
-		"1.2.840.113549.2.5", "1.3.14.3.2.26", "2.16.840.1.101.3.4.2.1",
-		"2.16.840.1.101.3.4.2.2", "2.16.840.1.101.3.4.2.3", "2.16.840.1.101.3.4.2.4",
-		"2.16.840.1.101.3.4.2.5", "2.16.840.1.101.3.4.2.6", "2.16.840.1.101.3.4.2.7",
-		"2.16.840.1.101.3.4.2.8", "2.16.840.1.101.3.4.2.9", "2.16.840.1.101.3.4.2.10",
-		"2.16.840.1.101.3.4.2.11", "2.16.840.1.101.3.4.2.12",
-	}
-	for _, oid := range hashOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	if err != nil {
+		return nil, readError{err}
 	}
+	return input, nil
+}
 
-	fmt.Println("\n📐 Elliptic Curves:")
-	curveOids := []string{
-		"1.2.840.10045.3.1.1", "1.2.840.10045.3.1.7", "1.3.132.0.34",
-		"1.3.132.0.35", "1.3.132.0.10", "1.2.840.10045.3.1.2",
-		"1.2.840.10045.3.1.3", "1.2.840.10045.3.1.4", "1.2.840.10045.3.1.5",
-		"1.2.840.10045.3.1.6",
-	}
-	for _, oid := range curveOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+// LoadFile loads a file, memory-mapping regular files where the platform
+// allows and reading stdin ("-"), pipes, devices and procfs entries into
+// memory up to MaxSize bytes
+func (fh FileHandler) LoadFile(filePath string) ([]byte, func() error, error) {
+	input, err := fh.Open(filePath)
+	if err != nil {
+		return nil, nil, err
 	}
+	return input.Bytes(), input.Close, nil
+}
 
-	fmt.Println("\n🔮 Post-Quantum Algorithms:")
-	pqOids := []string{
-		"2.16.840.1.101.3.4.3.17", "2.16.840.1.101.3.4.3.18", "2.16.840.1.101.3.4.3.19",
-		"1.3.6.1.4.1.2.267.12.4.4", "1.3.6.1.4.1.2.267.12.6.5",
-		"2.16.840.1.101.3.4.3.20", "2.16.840.1.101.3.4.3.21", "2.16.840.1.101.3.4.3.22",
-	}
-	for _, oid := range pqOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+// SaveToFile saves data to a file atomically: the data is written to a
+// temporary file in the same directory, flushed to disk and renamed over
+// filename, so readers and crashes never see a partially written file
+func (fh FileHandler) SaveToFile(data []byte, filename string) error {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
 	}
-
-	fmt.Println("\n🇷🇺 GOST Algorithms (Russian):")
-	gostOids := []string{
-		"1.2.643.2.2.19", "1.2.643.7.1.1.1.1", "1.2.643.7.1.1.1.2",
-		"1.2.643.2.2.3", "1.2.643.7.1.1.3.2", "1.2.643.7.1.1.3.3",
-		"1.2.643.2.2.9", "1.2.643.7.1.1.2.2", "1.2.643.7.1.1.2.3",
-		"1.2.643.2.2.21", "1.2.643.7.1.1.5.1", "1.2.643.7.1.1.5.2",
+	file, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
 	}
-	for _, oid := range gostOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	tmpName := file.Name()
+	fail := func(format string, err error) error {
+		file.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf(format, err)
 	}
 
-	fmt.Println("\n🇨🇳 Chinese SM Algorithms:")
-	smOids := []string{
-		"1.2.156.10197.1.301", "1.2.156.10197.1.401",
-		"1.2.156.10197.1.104.1", "1.2.156.10197.1.104.2",
+	if _, err := file.Write(data); err != nil {
+		return fail("failed to write data: %w", err)
 	}
-	for _, oid := range smOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	// CreateTemp uses 0600: keep the mode of the file being replaced, or
+	// give a new file the permissions os.Create would
+	mode := newFileMode
+	if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() {
+		mode = info.Mode().Perm()
 	}
-
-	fmt.Println("\n🇯🇵 Japanese Camellia Algorithms:")
-	camelliaOids := []string{
-		"1.2.392.200011.61.1.1.1.2", "1.2.392.200011.61.1.1.1.3", "1.2.392.200011.61.1.1.1.4",
+	if err := file.Chmod(mode); err != nil {
+		return fail("failed to set permissions: %w", err)
 	}
-	for _, oid := range camelliaOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	if err := file.Sync(); err != nil {
+		return fail("failed to flush data: %w", err)
 	}
-
-	fmt.Println("\n🏢 Microsoft Specific:")
-	msOids := []string{
-		"1.3.6.1.4.1.311.2.1.4", "1.3.6.1.4.1.311.2.1.15", "1.3.6.1.4.1.311.10.3.6",
-		"1.3.6.1.4.1.311.10.3.1", "1.3.6.1.4.1.311.10.3.4", "1.3.6.1.4.1.311.20.2.2",
-		"1.3.6.1.4.1.311.21.19", "1.3.6.1.4.1.311.21.20",
+	if err := file.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write data: %w", err)
 	}
-	for _, oid := range msOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", filename, err)
 	}
+	return nil
+}
 
-	fmt.Println("\n🔐 FIDO/WebAuthn:")
-	fidoOids := []string{
-		"1.3.6.1.4.1.45724.1.1.4", "1.3.6.1.4.1.45724.2.1.1",
-		"1.3.101.110", "1.3.101.111",
-	}
-	for _, oid := range fidoOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+// listSupportedAlgorithms writes the OID catalogue in the given format (text,
+// json or csv). A non-empty category restricts the output to entries whose
+// category or family contains it.
+func listSupportedAlgorithms(category, format string) error {
+	entries := filterCatalogue(catalogueEntries(), category)
+	switch format {
+	case "json":
+		return writeCatalogueJSON(os.Stdout, entries)
+	case "csv":
+		return writeCatalogueCSV(os.Stdout, entries)
 	}
 
-	fmt.Println("\n📦 PKCS#7/CMS Content Types:")
-	pkcs7Oids := []string{
-		"1.2.840.113549.1.7.1", "1.2.840.113549.1.7.2", "1.2.840.113549.1.7.3",
-		"1.2.840.113549.1.7.4", "1.2.840.113549.1.7.5", "1.2.840.113549.1.7.6",
-	}
-	for _, oid := range pkcs7Oids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
-	}
-
-	fmt.Println("\n🏷️  Distinguished Name Attributes:")
-	dnOids := []string{
-		"2.5.4.3", "2.5.4.4", "2.5.4.5", "2.5.4.6", "2.5.4.7", "2.5.4.8",
-		"2.5.4.9", "2.5.4.10", "2.5.4.11", "2.5.4.12", "2.5.4.42", "2.5.4.43",
-		"2.5.4.44", "2.5.4.46", "2.5.4.65", "1.2.840.113549.1.9.1",
-	}
-	for _, oid := range dnOids {
-		if name, exists := oidNames[oid]; exists {
-			fmt.Printf("  %s → %s\n", oid, name)
-		}
+	fmt.Println("=== SUPPORTED CRYPTOGRAPHIC ALGORITHMS AND OIDs ===")
+	writeCatalogueText(os.Stdout, entries)
+	if category != "" {
+		fmt.Printf("\nMatching OIDs: %d\n", len(entries))
 	}
-
 	fmt.Printf("\nTotal supported OIDs: %d\n", len(oidNames))
 	fmt.Println("\nℹ️  This tool searches for ASN.1 signature structures in binary files")
 	fmt.Println("   and validates the presence of required certificate fields.")
+	return nil
 }
 
 // parses command line arguments
@@ -869,11 +647,30 @@ func parseArgs() (*Config, error) {
 	config := &Config{}
 	flag.BoolVar(&config.SaveFile, "s", false, "save extracted signature to file (required for file output)")
 	flag.StringVar(&config.OutputFile, "o", "signature.der", "output filename when using -s flag")
+	flag.StringVar(&config.Outform, "outform", OutformDER, "with -s or -x, write signatures and certificates as `format`: der, pem or base64")
+	flag.StringVar(&config.ExtractDir, "x", "", "extract certificates, SignerInfos and signed content into `dir`, with an index.json")
 	flag.BoolVar(&config.ListAlgorithms, "list", false, "display all supported cryptographic algorithms and OIDs")
+	flag.StringVar(&config.ListCategory, "category", "", "with -list, only show entries whose category or family contains `text`")
+	flag.StringVar(&config.Format, "format", "text", "output `format`: text, "+FormatASN1Parse+" (openssl asn1parse layout) or "+FormatHTML+" (self-contained report); with -list text, json or csv")
+	flag.BoolVar(&config.Indent, "i", false, "with -format "+FormatASN1Parse+", indent elements by depth")
+	flag.BoolVar(&config.HexDump, "hexdump", false, "show the structure as hex rows with tag, length and value bytes marked and parse errors highlighted")
 	flag.BoolVar(&config.ShowVersion, "v", false, "display program version")
+	config.MaxInputSize = DefaultMaxInputSize
+	flag.Var(&config.MaxInputSize, "max-size", "read or decompress at most `bytes` (suffix K, M or G) for stdin, pipes and compressed inputs")
+	flag.BoolVar(&config.Recursive, "r", false, "analyze directories recursively (batch mode)")
+	flag.Var(&config.Include, "include", "with -r, only analyze files matching `glob` (repeatable)")
+	flag.Var(&config.Exclude, "exclude", "skip files and directories matching `glob` (repeatable)")
+	flag.IntVar(&config.Jobs, "j", runtime.NumCPU(), "number of files analyzed concurrently in batch mode")
+	flag.Var(&config.OIDFiles, "oids", "load additional OID names from `file` (text or .json, repeatable)")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "autograph-pls - ASN.1 Signature Parser and Validator\n")
-		fmt.Fprintf(os.Stderr, "\nUsage: %s [options] <file_path>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nUsage: %s [options] <file_path|->...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s oid [options] <oid|name|hex>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s strip [options] <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s attach [options] <file> <signature>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s sign -key <key.pem> -cert <cert.pem> [options] <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s diff [options] <file1> <file2>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s browse <file>\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nDESCRIPTION:\n")
 		fmt.Fprintf(os.Stderr, "  Searches for ASN.1 signature structures (0x30 0x82) from the end of files backwards,\n")
 		fmt.Fprintf(os.Stderr, "  validates certificate fields, recognizes cryptographic algorithms, and displays\n")
@@ -885,26 +682,96 @@ func parseArgs() (*Config, error) {
 		fmt.Fprintf(os.Stderr, "  %s myfile.efi                    # Analyze signature (display only)\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -s myfile.exe                # Extract signature to signature.der\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -s -o custom.der myfile.exe  # Extract signature to custom.der\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -s -outform pem myfile.exe   # Extract signature to signature.pem\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s signature.pem                # Analyze PEM or base64 encoded input\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -x parts -outform pem a.efi  # Extract certificates and SignerInfo to parts/\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format asn1parse -i sig.der # Print like 'openssl asn1parse -i'\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format html f.efi > f.html  # Write a self-contained HTML report\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -hexdump broken.der          # Mark every TLV byte and the byte where parsing fails\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  curl -s URL | %s -             # Analyze data read from stdin\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -r -include '*.efi' build/  # Summarize every .efi below build/\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -list                        # Show all supported algorithms\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -list -category hash         # Show only hash algorithms\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -list -format csv            # Export the OID catalogue as CSV\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s oid 1.2.840.113549.1.1.11    # Look up an OID (see '%s oid -h')\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s strip myfile.efi             # Write the unsigned image (see '%s strip -h')\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s attach myfile.efi sig.p7s    # Embed a detached signature (see '%s attach -h')\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sign -key k.pem -cert c.pem f.efi # Sign with a key file (see '%s sign -h')\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s diff old.efi new.efi          # Compare two signatures (see '%s diff -h')\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s browse shimx64.efi            # Explore the structure interactively\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -v                           # Show program version\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -oids corp.oids myfile.efi   # Name internal OIDs from corp.oids\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOID FILES:\n")
+		fmt.Fprintf(os.Stderr, "  Files in <config dir>/%s and -oids files are merged over the built-in names.\n", OIDConfigDirName)
+		fmt.Fprintf(os.Stderr, "  Text format, one per line: oid name [\"description\"] [category]; .json: array of\n")
+		fmt.Fprintf(os.Stderr, "  {\"oid\", \"name\", \"description\", \"category\"} objects.\n")
+		fmt.Fprintf(os.Stderr, "\nEXIT CODES:\n")
+		printExitCodes(os.Stderr)
 	}
 	flag.Parse()
 
 	// Handle list algorithms flag
-	if config.ListAlgorithms || config.ShowVersion {
+	if config.ListAlgorithms {
+		switch config.Format {
+		case "text", "json", "csv":
+		default:
+			return nil, fmt.Errorf("unsupported -list format %q (use text, json or csv)", config.Format)
+		}
+		return config, nil
+	}
+	if config.ShowVersion {
 		return config, nil
 	}
 
 	args := flag.Args()
-	if len(args) != 1 {
-		return nil, errors.New("please provide exactly one file path")
+	if len(args) == 0 {
+		return nil, errors.New("please provide at least one file path")
 	}
 
 	config.FilePath = args[0]
+	config.FilePaths = args
+	switch config.Format {
+	case "text":
+		if config.HexDump && config.BatchMode() {
+			return nil, errors.New("-hexdump annotates a single file and cannot be combined with several paths or -r")
+		}
+	case FormatASN1Parse, FormatHTML:
+		if config.HexDump {
+			return nil, errors.New("-hexdump is a text view and cannot be combined with -format " + config.Format)
+		}
+		if config.BatchMode() || config.SaveFile || config.ExtractDir != "" {
+			return nil, errors.New("-format " + config.Format + " prints a single file and cannot be combined with several paths, -r, -s or -x")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported -format %q (use text, %s or %s)", config.Format, FormatASN1Parse, FormatHTML)
+	}
+	if _, err := encodeOutput(nil, config.Outform); err != nil {
+		return nil, err
+	}
+	// Without -o, the default file name follows the output format
+	outputSet := false
+	flag.Visit(func(f *flag.Flag) {
+		outputSet = outputSet || f.Name == "o"
+	})
+	if !outputSet {
+		config.OutputFile = "signature" + outputExtension(config.Outform)
+	}
+	if config.BatchMode() && (config.SaveFile || config.ExtractDir != "") {
+		return nil, errors.New("-s and -x extract from a single file and cannot be combined with several paths or -r")
+	}
+	if config.Jobs < 1 {
+		return nil, fmt.Errorf("-j must be at least 1, got %d", config.Jobs)
+	}
 	return config, nil
 }
 
 func main() {
+	os.Exit(run())
+}
+
+// run analyses the input and returns the exit status, so that deferred
+// cleanup such as closing the input happens before main exits
+func run() (status int) {
 	// Add panic recovery to handle crashes gracefully
 	defer func() {
 		if r := recover(); r != nil {
@@ -916,49 +783,128 @@ func main() {
 			fmt.Printf("1. File is not corrupted or truncated\n")
 			fmt.Printf("2. File actually contains ASN.1 signature data\n")
 			fmt.Printf("3. File is not a binary file without signatures\n")
-			os.Exit(2)
+			status = ExitInternal
 		}
 	}()
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "oid":
+			return runOIDCommand(os.Args[2:])
+		case "strip":
+			return runStripCommand(os.Args[2:])
+		case "attach":
+			return runAttachCommand(os.Args[2:])
+		case "sign":
+			return runSignCommand(os.Args[2:])
+		case "diff":
+			return runDiffCommand(os.Args[2:])
+		case "browse":
+			return runBrowseCommand(os.Args[2:])
+		}
+	}
+
 	config, err := parseArgs()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		flag.Usage()
-		os.Exit(1)
+		return ExitUsage
+	}
+
+	// Handle version flag
+	if config.ShowVersion {
+		fmt.Printf("autograph-pls version %s\n", version)
+		return ExitValid
+	}
+
+	if err := loadOIDRegistry(config.OIDFiles); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
 	}
 
 	// Handle list algorithms option
 	if config.ListAlgorithms {
-		listSupportedAlgorithms()
-		return
+		if err := listSupportedAlgorithms(config.ListCategory, config.Format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return ExitIOError
+		}
+		return ExitValid
 	}
 
-	// Handle version flag
-	if config.ShowVersion {
-		fmt.Printf("autograph-pls version %s\n", version)
-		return
+	fileHandler := FileHandler{MaxSize: int64(config.MaxInputSize)}
+	batch := Batch{Handler: fileHandler, Workers: config.Jobs, Include: config.Include, Exclude: config.Exclude}
+	if config.BatchMode() {
+		items := collectFiles(config.FilePaths, config.Recursive, config.Include, config.Exclude)
+		return batch.Run(os.Stdout, items).ExitCode
 	}
 
-	fileHandler := FileHandler{}
-	data, cleanup, err := fileHandler.LoadFile(config.FilePath)
+	input, err := fileHandler.Open(config.FilePath)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return errorExitCode(err)
 	}
 	defer func() {
-		if err := cleanup(); err != nil {
+		if err := input.Close(); err != nil {
 			fmt.Printf("Warning: failed to cleanup file resources: %v\n", err)
 		}
 	}()
 
-	fmt.Printf("Analyzing file: %s\n", config.FilePath)
-	fmt.Printf("File size: %d bytes\n", len(data))
+	data, layers, err := decompressLayers(input.Bytes(), int64(config.MaxInputSize))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitMalformed
+	}
+
+	// Only the structure, for scripts written against openssl asn1parse
+	if config.Format == FormatASN1Parse {
+		return printASN1ParseInput(os.Stdout, data, config.Indent)
+	}
+	// A single self-contained document, e.g. as audit evidence
+	if config.Format == FormatHTML {
+		return writeHTMLInput(os.Stdout, inputName(config.FilePath), input.Size(), data, layers)
+	}
+
+	// Archives are summarised member by member like a batch run
+	if format := detectArchive(data); format != "" {
+		fmt.Printf("Analyzing %s archive: %s\n", format, inputName(config.FilePath))
+		fmt.Println("========================================")
+		return reportResults(os.Stdout, batch.analyzeArchive(inputName(config.FilePath), format, data, 0)).ExitCode
+	}
+
+	// PEM and base64 input is decoded; several blocks are summarised
+	blocks, err := decodeTextInput(data)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitMalformed
+	}
+	if len(blocks) > 1 {
+		fmt.Printf("Analyzing %d PEM blocks: %s\n", len(blocks), inputName(config.FilePath))
+		fmt.Println("========================================")
+		return reportResults(os.Stdout, analyzeBlocks(inputName(config.FilePath), blocks)).ExitCode
+	}
+
+	fmt.Printf("Analyzing file: %s\n", inputName(config.FilePath))
+	fmt.Printf("File size: %d bytes\n", input.Size())
+	for _, layer := range layers {
+		fmt.Printf("Container: %s, %d bytes → %d bytes decompressed\n", layer.Format, layer.CompressedSize, layer.DecompressedSize)
+	}
+	if len(blocks) == 1 {
+		data = blocks[0].Bytes
+		if blocks[0].Type == TextBase64 {
+			fmt.Printf("Encoding: base64, %d bytes decoded\n", len(data))
+		} else {
+			fmt.Printf("Encoding: PEM %s block, %d bytes decoded\n", blocks[0].Type, len(data))
+		}
+	}
 	fmt.Println("========================================")
 
 	// Validate input data before processing
 	if len(data) < 10 {
 		fmt.Printf("Error: File too small (%d bytes) to contain meaningful ASN.1 signatures\n", len(data))
-		os.Exit(1)
+		if len(data) == 0 {
+			return ExitIOError
+		}
+		return ExitMalformed
 	}
 
 	parser := NewSignatureParser(data)
@@ -966,12 +912,12 @@ func main() {
 	// Wrap signature finding in additional error handling
 	var raw *asn1.RawValue
 	var offset int
-	func() {
+	if code := func() (code int) {
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Printf("Error during signature search: %v\n", r)
 				fmt.Printf("File appears to contain malformed ASN.1 data\n")
-				os.Exit(1)
+				code = ExitMalformed
 			}
 		}()
 
@@ -979,20 +925,28 @@ func main() {
 		raw, offset, findErr = parser.FindValidSignature()
 		if findErr != nil {
 			fmt.Printf("Error: %v\n", findErr)
+			if config.HexDump {
+				start := hexDumpCandidate(data)
+				fmt.Printf("\nHex dump from offset %d:\n", start)
+				(HexDumper{W: os.Stdout}).Dump(data[start:], start)
+			}
 			fmt.Printf("\nTroubleshooting suggestions:\n")
 			fmt.Printf("1. Verify this file contains digital signatures\n")
 			fmt.Printf("2. Check if file is corrupted or truncated\n")
 			fmt.Printf("3. Ensure file format supports embedded signatures\n")
-			os.Exit(1)
+			return missingSignatureExitCode(data)
 		}
-	}()
+		return ExitValid
+	}(); code != ExitValid {
+		return code
+	}
 
 	if raw == nil || raw.FullBytes == nil {
 		fmt.Printf("Error: No valid signature data found\n")
-		os.Exit(1)
+		return ExitUnsigned
 	}
 
-	fmt.Printf("Valid ASN.1 signature found at offset %d\n", offset)
+	fmt.Printf("Valid ASN.1 signature found at %s\n", describeOffset(offset, layers))
 	fmt.Printf("Structure size: %d bytes\n", len(raw.FullBytes))
 
 	// Display validation results with error handling
@@ -1016,6 +970,11 @@ func main() {
 		Size:       len(raw.FullBytes),
 	}
 	results.Print()
+	// Later failures raise the status; the highest one wins
+	status = ExitValid
+	if !validation.IsValid() {
+		status = ExitInvalid
+	}
 
 	fmt.Println("========================================")
 
@@ -1026,6 +985,7 @@ func main() {
 			if r := recover(); r != nil {
 				fmt.Printf("Error displaying ASN.1 structure: %v\n", r)
 				fmt.Printf("Showing hex dump instead...\n")
+				status = ExitInternal
 				if len(raw.FullBytes) > 256 {
 					fmt.Printf("Raw data (first 256 bytes): %s...\n", hex.EncodeToString(raw.FullBytes[:256]))
 				} else {
@@ -1034,13 +994,17 @@ func main() {
 			}
 		}()
 
-		if err := displayer.Display(raw.FullBytes, offset); err != nil {
-			fmt.Printf("Error parsing ASN.1 structure: %v\n", err)
-			if len(raw.FullBytes) > 256 {
-				fmt.Printf("Raw data (first 256 bytes): %s...\n", hex.EncodeToString(raw.FullBytes[:256]))
-			} else {
-				fmt.Printf("Raw data (hex): %s\n", hex.EncodeToString(raw.FullBytes))
+		if config.HexDump {
+			if err := (HexDumper{W: os.Stdout}).Dump(raw.FullBytes, offset); err != nil {
+				fmt.Printf("Error parsing ASN.1 structure: %v\n", err)
+				status = max(status, ExitMalformed)
 			}
+			return
+		}
+		// Display lists the parse errors after the structure
+		if err := displayer.Display(raw.FullBytes, offset); err != nil {
+			fmt.Printf("Run with -hexdump to see the bytes around the errors\n")
+			status = max(status, ExitMalformed)
 		}
 	}()
 
@@ -1056,32 +1020,62 @@ func main() {
 	// Save to file if requested with error handling
 	if config.SaveFile {
 		filename := config.OutputFile
+		var saveErr error
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
 					fmt.Printf("Error saving to file: %v\n", r)
-					return
+					status = ExitInternal
 				}
 			}()
 
-			if err := fileHandler.SaveToFile(raw.FullBytes, filename); err != nil {
-				fmt.Printf("Error saving to file: %v\n", err)
-				os.Exit(1)
+			encoded, err := encodeOutput(raw.FullBytes, config.Outform)
+			if err == nil {
+				err = fileHandler.SaveToFile(encoded, filename)
+			}
+			if err != nil {
+				saveErr = err
+				return
 			}
-			fmt.Printf("ASN.1 structure saved to: %s\n", filename)
+			fmt.Printf("ASN.1 structure saved to: %s (%s)\n", filename, strings.ToUpper(config.Outform))
 		}()
+		if saveErr != nil {
+			fmt.Printf("Error saving to file: %v\n", saveErr)
+			return ExitIOError
+		}
 	}
+
+	// Extract the parts of the SignedData if requested
+	if config.ExtractDir != "" {
+		p7, p7Offset, err := FindPKCS7(data)
+		if err != nil {
+			fmt.Printf("Error extracting SignedData: %v\n", err)
+			return ExitMalformed
+		}
+		files, err := extractPKCS7(fileHandler, p7, config.ExtractDir, config.Outform)
+		if err != nil {
+			fmt.Printf("Error extracting SignedData: %v\n", err)
+			return ExitIOError
+		}
+		fmt.Printf("SignedData at %s extracted to %s:\n", describeOffset(p7Offset, layers), config.ExtractDir)
+		for _, file := range files {
+			fmt.Printf("  %-12s %s (%d bytes)\n", file.Kind, file.File, file.Size)
+		}
+		fmt.Printf("  %-12s %s\n", "index", ExtractIndexName)
+	}
+	return status
 }
 
-// parseASN1Element parses a single ASN.1 element
+// parseASN1Element parses a single ASN.1 element at the absolute file
+// offset. Errors are always *ParseError, without a Path.
 func parseASN1Element(data []byte, depth int, offset int) (ASN1Element, int, error) {
 	// Prevent parsing at excessive depths
 	if depth > MaxRecursionDepth {
-		return ASN1Element{}, 0, errors.New("maximum parsing depth exceeded")
+		return ASN1Element{}, 0, &ParseError{Kind: ParseTooDeep, Offset: offset, At: offset}
 	}
 
 	if len(data) < 2 {
-		return ASN1Element{}, 0, errors.New("insufficient data for ASN.1 element")
+		return ASN1Element{}, 0, &ParseError{Kind: ParseTruncated, Offset: offset, At: offset, Expected: 2, Available: len(data)}
 	}
 
 	element := ASN1Element{
@@ -1109,22 +1103,19 @@ func parseASN1Element(data []byte, depth int, offset int) (ASN1Element, int, err
 		// Long form
 		lengthOctets := int(lengthByte & 0x7F)
 		if lengthOctets == 0 {
-			return element, 0, errors.New("indefinite length not supported")
+			return element, 0, &ParseError{Kind: ParseIndefiniteLength, Offset: offset, At: offset + 1}
 		}
 		if len(data) < bytesRead+lengthOctets {
-			return element, 0, errors.New("insufficient data for length octets")
+			return element, 0, &ParseError{Kind: ParseTruncated, Offset: offset, At: offset + 1, Expected: bytesRead + lengthOctets, Available: len(data)}
 		}
 
 		element.Length = 0
 		for i := 0; i < lengthOctets; i++ {
-			if bytesRead >= len(data) {
-				return element, 0, errors.New("insufficient data for length octets")
-			}
 			element.Length = (element.Length << 8) | int(data[bytesRead])
 			bytesRead++
 			// Check for unreasonably large lengths that could cause overflow or DoS
-			if element.Length < 0 || element.Length > 50*1024*1024 { // 50MB limit
-				return element, 0, errors.New("invalid or excessive length value")
+			if element.Length < 0 || element.Length > maxElementLength {
+				return element, 0, &ParseError{Kind: ParseExcessiveLength, Offset: offset, At: offset + 1}
 			}
 		}
 		element.HeaderLen = bytesRead
@@ -1145,7 +1136,7 @@ func parseASN1Element(data []byte, depth int, offset int) (ASN1Element, int, err
 
 	totalBytes := element.HeaderLen + element.Length
 	if totalBytes > len(data) {
-		return element, 0, errors.New("element extends beyond available data")
+		return element, 0, &ParseError{Kind: ParseOverrun, Offset: offset, At: offset + 1, Expected: totalBytes, Available: len(data)}
 	}
 
 	return element, totalBytes, nil
@@ -1294,22 +1285,7 @@ func formatPrimitiveContent(tag int, content []byte) string {
 		return hex.EncodeToString(content)
 
 	case TagInteger: // INTEGER
-		if len(content) <= 8 {
-			// Small integers - show as decimal and hex
-			var value int64
-			for _, b := range content {
-				value = (value << 8) | int64(b)
-			}
-			if len(content) > 0 && content[0]&0x80 != 0 {
-				// Handle negative numbers
-				for i := len(content); i < 8; i++ {
-					value |= int64(0xFF) << (8 * (7 - i))
-				}
-			}
-			return fmt.Sprintf("%d (0x%X)", value, content)
-		}
-		// Large integers - show as hex
-		return hex.EncodeToString(content)
+		return formatInteger(content)
 
 	case TagBitString: // BIT STRING
 		if len(content) > 0 {
@@ -1332,18 +1308,24 @@ func formatPrimitiveContent(tag int, content []byte) string {
 		return ""
 
 	case TagObjectID: // OBJECT IDENTIFIER
-		oid := parseOID(content)
+		oid, err := decodeOID(content)
+		if err != nil {
+			return fmt.Sprintf("INVALID OID (%v): %s", err, hex.EncodeToString(content))
+		}
 		if name, exists := oidNames[oid]; exists {
 			return fmt.Sprintf("%s (%s)", oid, name)
 		}
 		return oid
 
 	case TagReal: // REAL
-		// Simple representation for REAL values - could be enhanced for proper IEEE 754 decoding
-		if len(content) > 32 {
-			return fmt.Sprintf("REAL: %s... (%d bytes)", hex.EncodeToString(content[:32]), len(content))
+		value, err := decodeReal(content)
+		if err != nil {
+			if len(content) > 32 {
+				return fmt.Sprintf("REAL: invalid (%v) %s... (%d bytes)", err, hex.EncodeToString(content[:32]), len(content))
+			}
+			return fmt.Sprintf("REAL: invalid (%v) %s", err, hex.EncodeToString(content))
 		}
-		return fmt.Sprintf("REAL: %s", hex.EncodeToString(content))
+		return fmt.Sprintf("REAL: %s", value)
 
 	case TagEnumerated: // ENUMERATED
 		if len(content) <= 8 {
@@ -1357,21 +1339,11 @@ func formatPrimitiveContent(tag int, content []byte) string {
 
 	case TagRelativeOID: // RELATIVE-OID
 		// Similar to OID but without the first two arcs
-		var oid []string
-		i := 0
-		for i < len(content) {
-			var value uint64
-			for i < len(content) {
-				b := content[i]
-				i++
-				value = (value << 7) | uint64(b&0x7F)
-				if b&0x80 == 0 {
-					break
-				}
-			}
-			oid = append(oid, fmt.Sprintf("%d", value))
+		oid, err := decodeRelativeOID(content)
+		if err != nil {
+			return fmt.Sprintf("INVALID RELATIVE-OID (%v): %s", err, hex.EncodeToString(content))
 		}
-		return strings.Join(oid, ".")
+		return oid
 
 	case TagObjectDescriptor: // ObjectDescriptor
 		return fmt.Sprintf("ObjectDescriptor: %q", string(content))
@@ -1382,23 +1354,15 @@ func formatPrimitiveContent(tag int, content []byte) string {
 	case TagEmbeddedPDV: // EMBEDDED PDV
 		return fmt.Sprintf("EMBEDDED PDV (%d bytes)", len(content))
 
-	// String types
+	// String types, including BMPString (UCS-2) and UniversalString (UCS-4)
 	case TagUTF8String, TagPrintable, TagT61String, TagIA5String,
 		TagNumericString, TagVideotexString, TagGraphicString,
-		TagVisibleString, TagGeneralString: // Various string types
-		return fmt.Sprintf("%q", string(content))
-
-	case TagUniversalString: // UniversalString (4-byte chars)
-		if len(content)%4 != 0 {
-			return fmt.Sprintf("Invalid UniversalString: %s", hex.EncodeToString(content))
-		}
-		return fmt.Sprintf("UniversalString: %s", hex.EncodeToString(content))
-
-	case TagBMPString: // BMPString (2-byte chars)
-		if len(content)%2 != 0 {
-			return fmt.Sprintf("Invalid BMPString: %s", hex.EncodeToString(content))
+		TagVisibleString, TagGeneralString, TagUniversalString, TagBMPString:
+		text, err := decodeASN1String(tag, content)
+		if err != nil {
+			return fmt.Sprintf("%q [invalid %s: %v]", text, getUniversalTagName(tag, false), err)
 		}
-		return fmt.Sprintf("BMPString: %s", hex.EncodeToString(content))
+		return fmt.Sprintf("%q", text)
 
 	case TagCharacterString: // CHARACTER STRING
 		return fmt.Sprintf("CHARACTER STRING (%d bytes)", len(content))
@@ -1414,36 +1378,3 @@ func formatPrimitiveContent(tag int, content []byte) string {
 		return hex.EncodeToString(content)
 	}
 }
-
-// parses an ASN.1 OBJECT IDENTIFIER
-func parseOID(content []byte) string {
-	if len(content) == 0 {
-		return ""
-	}
-
-	var oid []string
-
-	// First subidentifier encodes first two arc values
-	if len(content) > 0 {
-		first := content[0]
-		oid = append(oid, fmt.Sprintf("%d", first/40))
-		oid = append(oid, fmt.Sprintf("%d", first%40))
-	}
-
-	// Remaining subidentifiers
-	i := 1
-	for i < len(content) {
-		var value uint64
-		for i < len(content) {
-			b := content[i]
-			i++
-			value = (value << 7) | uint64(b&0x7F)
-			if b&0x80 == 0 {
-				break
-			}
-		}
-		oid = append(oid, fmt.Sprintf("%d", value))
-	}
-
-	return strings.Join(oid, ".")
-}
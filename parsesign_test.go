@@ -369,6 +369,40 @@ func TestFileHandling(t *testing.T) {
 		if !bytes.Equal(savedData, testData) {
 			t.Errorf("Expected saved data %v, got %v", testData, savedData)
 		}
+		if info, _ := os.Stat(outputFile); info.Mode().Perm() != newFileMode {
+			t.Errorf("Expected mode %v under the umask, got %v", newFileMode, info.Mode().Perm())
+		}
+	})
+
+	t.Run("SaveToFileAtomic", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "out.der")
+		if err := os.WriteFile(outputFile, []byte("previous content"), 0o600); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := fh.SaveToFile(testData, outputFile); err != nil {
+			t.Fatalf("Failed to replace file: %v", err)
+		}
+
+		savedData, _ := os.ReadFile(outputFile)
+		if !bytes.Equal(savedData, testData) {
+			t.Errorf("Expected replaced data %q, got %q", testData, savedData)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("Expected no temporary files to remain, got %d entries", len(entries))
+		}
+		if info, _ := os.Stat(outputFile); info.Mode().Perm() != 0o600 {
+			t.Errorf("Expected the mode 0600 of the replaced file, got %v", info.Mode().Perm())
+		}
+
+		// A directory in the way fails the rename and leaves nothing behind
+		if err := fh.SaveToFile(testData, dir); err == nil {
+			t.Errorf("Expected error replacing a directory")
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("Expected failed save to clean up, got %d entries", len(entries))
+		}
 	})
 
 	// Test error cases
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PE/COFF layout constants (Microsoft PE format specification)
+const (
+	peHeaderPointerOffset = 0x3C
+	peCOFFHeaderSize      = 20
+	peMagicPE32           = 0x10B
+	peMagicPE32Plus       = 0x20B
+	// Offsets inside the optional header
+	peChecksumOffset        = 64
+	peDataDirOffsetPE32     = 96
+	peDataDirOffsetPE32Plus = 112
+	peNumberOfRvaOffset     = 92
+	peNumberOfRvaOffsetPlus = 108
+	peDataDirEntrySize      = 8
+	peSecurityDirIndex      = 4
+	// Section table entries and the offsets of their raw data fields
+	peSectionHeaderSize       = 40
+	peSectionRawSizeOffset    = 16
+	peSectionRawPointerOffset = 20
+)
+
+// WIN_CERTIFICATE constants
+const (
+	winCertHeaderSize  = 8
+	winCertAlignment   = 8
+	winCertRevision2   = 0x0200
+	winCertTypePKCS7   = 0x0002
+	winCertMaxTableLen = 64 << 20
+)
+
+// Magic bytes of PE images
+var (
+	magicMZ = []byte("MZ")
+	magicPE = []byte("PE\x00\x00")
+)
+
+// PEImage locates the fields of a PE/COFF image that signing touches
+type PEImage struct {
+	data []byte
+	// ChecksumOffset is the file offset of the optional header CheckSum
+	ChecksumOffset int
+	// SecurityDirOffset is the file offset of the IMAGE_DIRECTORY_ENTRY_SECURITY
+	// data directory entry
+	SecurityDirOffset int
+	// CertTableOffset and CertTableSize describe the attribute certificate
+	// table; both are zero for unsigned images. Unlike other data
+	// directories, the security entry holds a file offset, not an RVA.
+	CertTableOffset int
+	CertTableSize   int
+	// SectionDataEnd is the end of the raw data of the last section in the
+	// file, zero if the image has no sections
+	SectionDataEnd int
+}
+
+// WinCertificate is one entry of the attribute certificate table
+type WinCertificate struct {
+	Offset   int
+	Revision uint16
+	Type     uint16
+	Data     []byte
+}
+
+// isPE reports whether data starts like a PE image
+func isPE(data []byte) bool {
+	if !bytes.HasPrefix(data, magicMZ) || len(data) < peHeaderPointerOffset+4 {
+		return false
+	}
+	peOffset := int(binary.LittleEndian.Uint32(data[peHeaderPointerOffset:]))
+	return peOffset >= 0 && peOffset+len(magicPE) <= len(data) && bytes.Equal(data[peOffset:peOffset+len(magicPE)], magicPE)
+}
+
+// ParsePE locates the checksum and security directory of a PE image
+func ParsePE(data []byte) (*PEImage, error) {
+	if !isPE(data) {
+		return nil, errors.New("not a PE image")
+	}
+	peOffset := int(binary.LittleEndian.Uint32(data[peHeaderPointerOffset:]))
+	coff := peOffset + len(magicPE)
+	if coff+peCOFFHeaderSize > len(data) {
+		return nil, errors.New("truncated COFF header")
+	}
+	optionalSize := int(binary.LittleEndian.Uint16(data[coff+16:]))
+	optional := coff + peCOFFHeaderSize
+	if optionalSize < 2 || optional+optionalSize > len(data) {
+		return nil, errors.New("truncated optional header")
+	}
+
+	var dataDir, rvaCount int
+	switch magic := binary.LittleEndian.Uint16(data[optional:]); magic {
+	case peMagicPE32:
+		dataDir, rvaCount = peDataDirOffsetPE32, peNumberOfRvaOffset
+	case peMagicPE32Plus:
+		dataDir, rvaCount = peDataDirOffsetPE32Plus, peNumberOfRvaOffsetPlus
+	default:
+		return nil, fmt.Errorf("unknown optional header magic %#x", magic)
+	}
+	if rvaCount+4 > optionalSize {
+		return nil, errors.New("truncated optional header")
+	}
+	entries := int(binary.LittleEndian.Uint32(data[optional+rvaCount:]))
+	security := optional + dataDir + peSecurityDirIndex*peDataDirEntrySize
+	if entries <= peSecurityDirIndex || security+peDataDirEntrySize > optional+optionalSize {
+		return nil, errors.New("image has no security data directory")
+	}
+
+	pe := &PEImage{
+		data:              data,
+		ChecksumOffset:    optional + peChecksumOffset,
+		SecurityDirOffset: security,
+		CertTableOffset:   int(binary.LittleEndian.Uint32(data[security:])),
+		CertTableSize:     int(binary.LittleEndian.Uint32(data[security+4:])),
+		SectionDataEnd:    sectionDataEnd(data, int(binary.LittleEndian.Uint16(data[coff+2:])), optional+optionalSize),
+	}
+	if pe.CertTableSize == 0 {
+		pe.CertTableOffset = 0
+	} else if pe.CertTableOffset <= security || pe.CertTableOffset+pe.CertTableSize > len(data) || pe.CertTableSize > winCertMaxTableLen {
+		return nil, fmt.Errorf("certificate table (offset %d, %d bytes) lies outside the image", pe.CertTableOffset, pe.CertTableSize)
+	}
+	return pe, nil
+}
+
+// sectionDataEnd returns the end of the furthest section raw data that lies
+// within the image, reading count entries of the section table at offset
+func sectionDataEnd(data []byte, count, offset int) int {
+	end := 0
+	for i := 0; i < count && offset+peSectionHeaderSize <= len(data); i++ {
+		size := int(binary.LittleEndian.Uint32(data[offset+peSectionRawSizeOffset:]))
+		pointer := int(binary.LittleEndian.Uint32(data[offset+peSectionRawPointerOffset:]))
+		if size > 0 && pointer+size <= len(data) && pointer+size > end {
+			end = pointer + size
+		}
+		offset += peSectionHeaderSize
+	}
+	return end
+}
+
+// Signed reports whether the image has an attribute certificate table
+func (pe *PEImage) Signed() bool {
+	return pe.CertTableSize > 0
+}
+
+// Certificates parses the attribute certificate table
+func (pe *PEImage) Certificates() ([]WinCertificate, error) {
+	var certs []WinCertificate
+	end := pe.CertTableOffset + pe.CertTableSize
+	for offset := pe.CertTableOffset; offset+winCertHeaderSize <= end; {
+		length := int(binary.LittleEndian.Uint32(pe.data[offset:]))
+		if length < winCertHeaderSize || offset+length > end {
+			return nil, fmt.Errorf("invalid WIN_CERTIFICATE length %d at offset %d", length, offset)
+		}
+		certs = append(certs, WinCertificate{
+			Offset:   offset,
+			Revision: binary.LittleEndian.Uint16(pe.data[offset+4:]),
+			Type:     binary.LittleEndian.Uint16(pe.data[offset+6:]),
+			Data:     pe.data[offset+winCertHeaderSize : offset+length],
+		})
+		offset += alignUp(length, winCertAlignment)
+	}
+	return certs, nil
+}
+
+// Checksum returns the stored optional header checksum
+func (pe *PEImage) Checksum() uint32 {
+	return binary.LittleEndian.Uint32(pe.data[pe.ChecksumOffset:])
+}
+
+// Strip returns a copy of the image without its certificate table and, when
+// it can be told apart, the padding Attach puts in front of it, with the security directory
+// zeroed and the checksum updated. Data following the table, which signing
+// tools never produce, is kept.
+func (pe *PEImage) Strip() []byte {
+	end := pe.unpaddedLength()
+	out := make([]byte, 0, len(pe.data)-pe.CertTableSize)
+	out = append(out, pe.data[:end]...)
+	out = append(out, pe.data[pe.CertTableOffset+pe.CertTableSize:]...)
+	clear(out[pe.SecurityDirOffset : pe.SecurityDirOffset+peDataDirEntrySize])
+	pe.updateChecksum(out)
+	return out
+}
+
+// unpaddedLength returns the length of the image before it was padded to 8
+// bytes for the certificate table. The padding is only known when the
+// section data ends within 7 zero bytes of the table; with other data after
+// the sections the original length is not recorded, and the table offset is
+// used.
+func (pe *PEImage) unpaddedLength() int {
+	end := pe.SectionDataEnd
+	if end >= pe.CertTableOffset || end <= pe.CertTableOffset-winCertAlignment {
+		return pe.CertTableOffset
+	}
+	for _, b := range pe.data[end:pe.CertTableOffset] {
+		if b != 0 {
+			return pe.CertTableOffset
+		}
+	}
+	return end
+}
+
+// Attach returns a copy of the unsigned image with signature appended as a
+// WIN_CERTIFICATE (revision 2.0, PKCS#7 SignedData). The image is padded to
+// 8 bytes first, the entry is padded to 8 bytes, and the security directory
+// and checksum are updated.
+func (pe *PEImage) Attach(signature []byte) []byte {
+	offset := alignUp(len(pe.data), winCertAlignment)
+	length := winCertHeaderSize + len(signature)
+	out := make([]byte, offset, offset+alignUp(length, winCertAlignment))
+	copy(out, pe.data)
+
+	out = binary.LittleEndian.AppendUint32(out, uint32(length))
+	out = binary.LittleEndian.AppendUint16(out, winCertRevision2)
+	out = binary.LittleEndian.AppendUint16(out, winCertTypePKCS7)
+	out = append(out, signature...)
+	out = append(out, make([]byte, alignUp(length, winCertAlignment)-length)...)
+
+	binary.LittleEndian.PutUint32(out[pe.SecurityDirOffset:], uint32(offset))
+	binary.LittleEndian.PutUint32(out[pe.SecurityDirOffset+4:], uint32(len(out)-offset))
+	pe.updateChecksum(out)
+	return out
+}
+
+// updateChecksum recomputes the checksum of a modified copy of the image.
+// Images whose checksum is zero (most EFI binaries never set it) keep it
+// zero, so stripping reproduces the unsigned build byte for byte.
+func (pe *PEImage) updateChecksum(out []byte) {
+	if pe.Checksum() == 0 {
+		return
+	}
+	binary.LittleEndian.PutUint32(out[pe.ChecksumOffset:], peChecksum(out, pe.ChecksumOffset))
+}
+
+// peChecksum computes the PE image checksum: a 16-bit one's-complement style
+// sum of the file, with the checksum field counted as zero, plus the file
+// length. The field is normally 2-byte aligned, but e_lfanew does not have
+// to be, so an odd offset is handled too.
+func peChecksum(data []byte, checksumOffset int) uint32 {
+	var sum uint64
+	for i := 0; i < len(data); i += 2 {
+		var word uint64
+		if i+1 < len(data) {
+			word = uint64(binary.LittleEndian.Uint16(data[i:]))
+		} else {
+			word = uint64(data[i])
+		}
+		// Mask out checksum bytes falling in this word
+		for b := 0; b < 2; b++ {
+			if i+b >= checksumOffset && i+b < checksumOffset+4 {
+				word &^= 0xFF << (8 * b)
+			}
+		}
+		sum += word
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+	sum = (sum & 0xFFFF) + (sum >> 16)
+	return uint32(sum) + uint32(len(data))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// buildTestPE builds a minimal PE32+ image: DOS header, PE signature, COFF
+// header and an optional header with 16 data directories, followed by body
+func buildTestPE(body []byte) []byte {
+	const peOffset = 0x40
+	image := make([]byte, peOffset)
+	copy(image, magicMZ)
+	binary.LittleEndian.PutUint32(image[peHeaderPointerOffset:], peOffset)
+	image = append(image, magicPE...)
+
+	coff := make([]byte, peCOFFHeaderSize)
+	binary.LittleEndian.PutUint16(coff[0:], 0x8664)
+	binary.LittleEndian.PutUint16(coff[16:], peDataDirOffsetPE32Plus+16*peDataDirEntrySize)
+	image = append(image, coff...)
+
+	optional := make([]byte, peDataDirOffsetPE32Plus+16*peDataDirEntrySize)
+	binary.LittleEndian.PutUint16(optional[0:], peMagicPE32Plus)
+	binary.LittleEndian.PutUint32(optional[peNumberOfRvaOffsetPlus:], 16)
+	image = append(image, optional...)
+	return append(image, body...)
+}
+
+// buildTestPEWithSection builds a PE image whose only section holds body, so
+// that the image ends with the section data
+func buildTestPEWithSection(body []byte) []byte {
+	image := buildTestPE(nil)
+	pe, _ := ParsePE(image)
+	coff := pe.SecurityDirOffset - peSecurityDirIndex*peDataDirEntrySize - peDataDirOffsetPE32Plus - peCOFFHeaderSize
+	binary.LittleEndian.PutUint16(image[coff+2:], 1)
+
+	section := make([]byte, peSectionHeaderSize)
+	copy(section, ".data")
+	binary.LittleEndian.PutUint32(section[peSectionRawSizeOffset:], uint32(len(body)))
+	binary.LittleEndian.PutUint32(section[peSectionRawPointerOffset:], uint32(len(image)+peSectionHeaderSize))
+	image = append(image, section...)
+	return append(image, body...)
+}
+
+// appendTestCertTable appends an 8-byte aligned WIN_CERTIFICATE holding
+// signature and points the security directory at it
+func appendTestCertTable(image, signature []byte) []byte {
+	pe, _ := ParsePE(image)
+	signed := append([]byte{}, image...)
+	signed = append(signed, make([]byte, alignUp(len(signed), winCertAlignment)-len(signed))...)
+	offset := len(signed)
+	length := winCertHeaderSize + len(signature)
+	signed = binary.LittleEndian.AppendUint32(signed, uint32(length))
+	signed = binary.LittleEndian.AppendUint16(signed, winCertRevision2)
+	signed = binary.LittleEndian.AppendUint16(signed, winCertTypePKCS7)
+	signed = append(signed, signature...)
+	signed = append(signed, make([]byte, alignUp(length, winCertAlignment)-length)...)
+	binary.LittleEndian.PutUint32(signed[pe.SecurityDirOffset:], uint32(offset))
+	binary.LittleEndian.PutUint32(signed[pe.SecurityDirOffset+4:], uint32(len(signed)-offset))
+	return signed
+}
+
+// TestParsePE tests header parsing and the certificate table
+func TestParsePE(t *testing.T) {
+	image := buildTestPE(bytes.Repeat([]byte{0x90}, 100))
+	pe, err := ParsePE(image)
+	if err != nil {
+		t.Fatalf("ParsePE failed: %v", err)
+	}
+	if pe.Signed() || pe.ChecksumOffset != 0x40+4+20+64 {
+		t.Errorf("Unexpected unsigned image: signed %v, checksum offset %d", pe.Signed(), pe.ChecksumOffset)
+	}
+
+	signed := appendTestCertTable(image, []byte{0x30, 0x03, 0x02, 0x01, 0x01})
+	pe, err = ParsePE(signed)
+	if err != nil {
+		t.Fatalf("ParsePE failed on signed image: %v", err)
+	}
+	certs, err := pe.Certificates()
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("Expected one WIN_CERTIFICATE, got %d (%v)", len(certs), err)
+	}
+	if certs[0].Revision != winCertRevision2 || certs[0].Type != winCertTypePKCS7 || len(certs[0].Data) != 5 {
+		t.Errorf("Unexpected WIN_CERTIFICATE %+v", certs[0])
+	}
+
+	for name, data := range map[string][]byte{
+		"no MZ":        bytes.Repeat([]byte{0}, 512),
+		"truncated":    image[:0x50],
+		"table beyond": append(append([]byte{}, image[:pe.SecurityDirOffset]...), append([]byte{0xFF, 0xFF, 0, 0, 8, 0, 0, 0}, image[pe.SecurityDirOffset+8:]...)...),
+	} {
+		if _, err := ParsePE(data); err == nil {
+			t.Errorf("Expected error for %s image", name)
+		}
+	}
+}
+
+// TestPEStrip tests that stripping restores the unsigned image
+func TestPEStrip(t *testing.T) {
+	for _, withChecksum := range []bool{false, true} {
+		// Signing tools pad the image to 8 bytes before the certificate table
+		image := buildTestPE(bytes.Repeat([]byte{0xCC}, 104))
+		if withChecksum {
+			pe, _ := ParsePE(image)
+			binary.LittleEndian.PutUint32(image[pe.ChecksumOffset:], peChecksum(image, pe.ChecksumOffset))
+		}
+
+		signed := appendTestCertTable(image, bytes.Repeat([]byte{0x30}, 13))
+		pe, _ := ParsePE(signed)
+		if stripped := pe.Strip(); !bytes.Equal(stripped, image) {
+			t.Errorf("Expected stripped image to equal the unsigned image (checksum %v)", withChecksum)
+		}
+	}
+
+	// Unaligned section data ending in zero bytes, which stay
+	image := buildTestPEWithSection(append(bytes.Repeat([]byte{0xCC}, 98), 0, 0, 0))
+	pe, _ := ParsePE(image)
+	if len(image)%winCertAlignment == 0 || pe.SectionDataEnd != len(image) {
+		t.Fatalf("Expected unaligned section data up to %d, got %d", len(image), pe.SectionDataEnd)
+	}
+	signed := pe.Attach(bytes.Repeat([]byte{0x30}, 13))
+	pe, _ = ParsePE(signed)
+	if stripped := pe.Strip(); !bytes.Equal(stripped, image) {
+		t.Errorf("Expected attach and strip to round-trip the unaligned image, got %d bytes instead of %d", len(stripped), len(image))
+	}
+
+	data, err := os.ReadFile("testfiles/good/MokManager.efi")
+	if err != nil {
+		t.Skip("MokManager.efi not available")
+	}
+	pe, err = ParsePE(data)
+	if err != nil {
+		t.Fatalf("ParsePE failed: %v", err)
+	}
+	stripped := pe.Strip()
+	if len(stripped) != pe.CertTableOffset {
+		t.Errorf("Expected stripped size %d, got %d", pe.CertTableOffset, len(stripped))
+	}
+	// The signing tool kept the checksum of the unsigned image
+	if got := binary.LittleEndian.Uint32(stripped[pe.ChecksumOffset:]); got != pe.Checksum() {
+		t.Errorf("Expected recomputed checksum %#x, got %#x", pe.Checksum(), got)
+	}
+}
+
+// TestPEChecksumOddOffset tests that the checksum field counts as zero at
+// even and odd offsets
+func TestPEChecksumOddOffset(t *testing.T) {
+	data := bytes.Repeat([]byte{0x12, 0x34, 0x56}, 11)
+	for _, offset := range []int{4, 5} {
+		zeroed := append([]byte{}, data...)
+		clear(zeroed[offset : offset+4])
+		if got, want := peChecksum(data, offset), peChecksum(zeroed, len(data)); got != want {
+			t.Errorf("Expected checksum %#x with the field at offset %d counted as zero, got %#x", want, offset, got)
+		}
+	}
+}
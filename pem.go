@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Output encodings selected with -outform
+const (
+	OutformDER    = "der"
+	OutformPEM    = "pem"
+	OutformBase64 = "base64"
+)
+
+// TextBase64 labels input that was plain base64 without PEM armour
+const TextBase64 = "BASE64"
+
+// PEM labels written by -outform pem; pemASN1Type covers structures that
+// are neither a ContentInfo nor a certificate, such as a bare SignerInfo
+const (
+	pemPKCS7Type       = "PKCS7"
+	pemCertificateType = "CERTIFICATE"
+	pemASN1Type        = "ASN1"
+)
+
+// base64LineLength matches the line length of PEM bodies
+const base64LineLength = 64
+
+// pemInputTypes are the PEM labels decoded from text input
+var pemInputTypes = []string{pemPKCS7Type, "CMS", pemCertificateType, "PKCS #7 SIGNED DATA", pemASN1Type}
+
+// pemBegin marks PEM armour
+var pemBegin = []byte("-----BEGIN ")
+
+// TextBlock is one DER structure decoded from PEM or base64 input
+type TextBlock struct {
+	Type  string
+	Bytes []byte
+}
+
+// decodeTextInput decodes PEM or raw base64 input into DER blocks. It
+// returns no blocks for input that is not text-encoded, so binary files pass
+// through unchanged; a NUL byte marks binary data even when it happens to
+// contain PEM armour.
+func decodeTextInput(data []byte) ([]TextBlock, error) {
+	if bytes.Contains(data, pemBegin) && bytes.IndexByte(data, 0) < 0 {
+		return decodePEMBlocks(data)
+	}
+
+	der, ok := decodeBase64Input(data)
+	if !ok {
+		return nil, nil
+	}
+	return []TextBlock{{Type: TextBase64, Bytes: der}}, nil
+}
+
+// decodePEMBlocks decodes every signature and certificate block, skipping
+// other blocks such as private keys
+func decodePEMBlocks(data []byte) ([]TextBlock, error) {
+	var blocks []TextBlock
+	var skipped []string
+	for rest := data; ; {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = next
+		if !isPEMInputType(block.Type) {
+			skipped = append(skipped, block.Type)
+			continue
+		}
+		blocks = append(blocks, TextBlock{Type: block.Type, Bytes: block.Bytes})
+	}
+
+	if len(blocks) == 0 {
+		if len(skipped) > 0 {
+			return nil, fmt.Errorf("PEM input has no %s block (found %s)", strings.Join(pemInputTypes, ", "), strings.Join(skipped, ", "))
+		}
+		return nil, fmt.Errorf("malformed PEM input")
+	}
+	return blocks, nil
+}
+
+// isPEMInputType reports whether a PEM label holds a signature or certificate
+func isPEMInputType(label string) bool {
+	for _, t := range pemInputTypes {
+		if label == t {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64Input decodes input consisting only of base64 and whitespace
+// whose content starts like a DER SEQUENCE
+func decodeBase64Input(data []byte) ([]byte, bool) {
+	compact := make([]byte, 0, len(data))
+	for _, c := range data {
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '+', c == '/', c == '=':
+			compact = append(compact, c)
+		default:
+			return nil, false
+		}
+	}
+
+	der := make([]byte, base64.StdEncoding.DecodedLen(len(compact)))
+	n, err := base64.StdEncoding.Decode(der, compact)
+	if err != nil || n < MinInputSize || der[0] != 0x30 {
+		return nil, false
+	}
+	return der[:n], true
+}
+
+// encodeOutput encodes DER data for writing in the given -outform
+func encodeOutput(der []byte, outform string) ([]byte, error) {
+	switch outform {
+	case OutformDER:
+		return der, nil
+	case OutformPEM:
+		return pem.EncodeToMemory(&pem.Block{Type: pemLabel(der), Bytes: der}), nil
+	case OutformBase64:
+		encoded := base64.StdEncoding.EncodeToString(der)
+		var buf bytes.Buffer
+		for len(encoded) > base64LineLength {
+			buf.WriteString(encoded[:base64LineLength] + "\n")
+			encoded = encoded[base64LineLength:]
+		}
+		buf.WriteString(encoded + "\n")
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported output format %q (use der, pem or base64)", outform)
+}
+
+// pemLabel picks the PEM label matching the structure in der
+func pemLabel(der []byte) string {
+	if _, err := ParsePKCS7(der); err == nil {
+		return pemPKCS7Type
+	}
+	if _, err := x509.ParseCertificate(der); err == nil {
+		return pemCertificateType
+	}
+	return pemASN1Type
+}
+
+// outputExtension returns the conventional file extension of an -outform
+func outputExtension(outform string) string {
+	switch outform {
+	case OutformPEM:
+		return ".pem"
+	case OutformBase64:
+		return ".b64"
+	}
+	return ".der"
+}
+
+// describeTextBlock names a decoded block for headers and result keys
+func describeTextBlock(block TextBlock, index int) string {
+	return fmt.Sprintf("%s#%d", strings.ReplaceAll(block.Type, " ", "-"), index+1)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// TestDecodeTextInput tests PEM and base64 detection and decoding
+func TestDecodeTextInput(t *testing.T) {
+	ts := newTestRSASigner(t)
+	signature := ts.signDetached(t, []byte("content"), false)
+
+	multi := append(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: signature}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1, 2, 3}})...)
+	multi = append(multi, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.cert.Raw})...)
+
+	blocks, err := decodeTextInput(multi)
+	if err != nil {
+		t.Fatalf("Failed to decode PEM input: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Type != "PKCS7" || blocks[1].Type != "CERTIFICATE" {
+		t.Fatalf("Expected PKCS7 and CERTIFICATE blocks, got %+v", blocks)
+	}
+	if !bytes.Equal(blocks[0].Bytes, signature) || !bytes.Equal(blocks[1].Bytes, ts.cert.Raw) {
+		t.Errorf("Decoded blocks differ from the encoded DER")
+	}
+
+	encoded, _ := encodeOutput(signature, OutformBase64)
+	blocks, err = decodeTextInput(encoded)
+	if err != nil || len(blocks) != 1 || blocks[0].Type != TextBase64 || !bytes.Equal(blocks[0].Bytes, signature) {
+		t.Errorf("Expected base64 input to decode to the signature, got %d blocks, %v", len(blocks), err)
+	}
+
+	for name, data := range map[string][]byte{
+		"binary DER":      signature,
+		"plain text":      []byte("hello, world\n"),
+		"base64 not DER":  []byte("aGVsbG8gd29ybGQ=\n"),
+		"short base64":    []byte("MAA=\n"),
+		"empty":           {},
+		"invalid padding": []byte("MII=A\n"),
+		"binary with PEM": append([]byte("\x00\x01-----BEGIN CERTIFICATE-----\n"), signature...),
+	} {
+		if blocks, err := decodeTextInput(data); blocks != nil || err != nil {
+			t.Errorf("Expected %s input to pass through, got %d blocks, %v", name, len(blocks), err)
+		}
+	}
+
+	if _, err := decodeTextInput(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}})); err == nil ||
+		!strings.Contains(err.Error(), "found PRIVATE KEY") {
+		t.Errorf("Expected error naming the skipped block, got %v", err)
+	}
+	if _, err := decodeTextInput([]byte("-----BEGIN PKCS7-----\nnot base64\n")); err == nil {
+		t.Errorf("Expected error for malformed PEM")
+	}
+}
+
+// TestEncodeOutput tests the -outform encodings and PEM labels
+func TestEncodeOutput(t *testing.T) {
+	ts := newTestRSASigner(t)
+	signature := ts.signDetached(t, []byte("content"), false)
+
+	if out, _ := encodeOutput(signature, OutformDER); !bytes.Equal(out, signature) {
+		t.Errorf("Expected DER output to be unchanged")
+	}
+
+	for _, tt := range []struct {
+		der   []byte
+		label string
+	}{
+		{signature, "PKCS7"},
+		{ts.cert.Raw, "CERTIFICATE"},
+		{[]byte{0x30, 0x03, 0x02, 0x01, 0x01}, "ASN1"},
+	} {
+		out, err := encodeOutput(tt.der, OutformPEM)
+		if err != nil {
+			t.Fatalf("PEM encoding failed: %v", err)
+		}
+		block, _ := pem.Decode(out)
+		if block == nil || block.Type != tt.label || !bytes.Equal(block.Bytes, tt.der) {
+			t.Errorf("Expected a %s PEM block round-tripping the DER, got %q", tt.label, out)
+		}
+	}
+
+	out, _ := encodeOutput(signature, OutformBase64)
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if len(line) > base64LineLength {
+			t.Errorf("Expected base64 lines of at most %d characters, got %d", base64LineLength, len(line))
+		}
+	}
+
+	if _, err := encodeOutput(signature, "hex"); err == nil {
+		t.Errorf("Expected error for unsupported output format")
+	}
+	if outputExtension(OutformPEM) != ".pem" || outputExtension(OutformDER) != ".der" {
+		t.Errorf("Unexpected output extensions")
+	}
+}
+
+// TestAnalyzeBlocks tests per-block results for multi-block PEM input
+func TestAnalyzeBlocks(t *testing.T) {
+	ts := newTestRSASigner(t)
+	blocks := []TextBlock{{Type: "CERTIFICATE", Bytes: ts.cert.Raw}, {Type: "PKCS7", Bytes: []byte{0x30, 0x00, 0x00, 0x00}}}
+
+	results := analyzeBlocks("chain.pem", blocks)
+	if len(results) != 2 || results[0].Path != "chain.pem!CERTIFICATE#1" || results[1].Path != "chain.pem!PKCS7#2" {
+		t.Fatalf("Expected block-keyed results, got %+v", results)
+	}
+	if results[0].Status != FileSigned || results[1].Status != FileUnsigned {
+		t.Errorf("Expected SIGNED and UNSIGNED, got %s and %s", results[0].Status, results[1].Status)
+	}
+	if results := analyzeBlocks("one.pem", blocks[:1]); results[0].Path != "one.pem" {
+		t.Errorf("Expected a single block to keep the file name, got %s", results[0].Path)
+	}
+}
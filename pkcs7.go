@@ -0,0 +1,408 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/md5" // register hashes used by digest algorithm OIDs
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha3"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PKCS#7 / CMS object identifiers used when parsing and verifying SignedData
+var (
+	oidContentTypeData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidContentTypeSigned    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttributeContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeDigest      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// Errors returned while verifying SignedData
+var (
+	ErrPKCS7NoSigner            = errors.New("SignedData has no SignerInfo")
+	ErrPKCS7NoCertificate       = errors.New("signer certificate not found in SignedData")
+	ErrPKCS7DigestMismatch      = errors.New("messageDigest attribute does not match the content")
+	ErrPKCS7ContentTypeMismatch = errors.New("contentType attribute does not match the encapsulated content type")
+	ErrPKCS7UnsupportedDigest   = errors.New("unsupported digest algorithm")
+)
+
+// digestAlgorithms maps digest algorithm OIDs to hash functions
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.2.840.113549.2.5":      crypto.MD5,
+	"1.3.14.3.2.26":           crypto.SHA1,
+	"2.16.840.1.101.3.4.2.4":  crypto.SHA224,
+	"2.16.840.1.101.3.4.2.1":  crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2":  crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3":  crypto.SHA512,
+	"2.16.840.1.101.3.4.2.5":  crypto.SHA512_224,
+	"2.16.840.1.101.3.4.2.6":  crypto.SHA512_256,
+	"2.16.840.1.101.3.4.2.8":  crypto.SHA3_256,
+	"2.16.840.1.101.3.4.2.9":  crypto.SHA3_384,
+	"2.16.840.1.101.3.4.2.10": crypto.SHA3_512,
+}
+
+// contentInfo is the outer PKCS#7 / CMS wrapper
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// signedData is the SignedData content (RFC 5652 5.1)
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// encapsulatedContentInfo carries the signed content, absent when detached
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo is one signature (RFC 5652 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// issuerAndSerialNumber identifies a certificate by issuer and serial
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// pkcs7Attribute is a signed or unsigned attribute
+type pkcs7Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// PKCS7 is a parsed SignedData structure
+type PKCS7 struct {
+	Raw []byte
+	// ContentType and Content describe the encapsulated content; Content is
+	// nil for detached signatures, and holds the OCTET STRING value or, for
+	// content such as Authenticode's SpcIndirectDataContent, the raw element
+	ContentType     asn1.ObjectIdentifier
+	Content         []byte
+	Certificates    []*x509.Certificate
+	RawCertificates [][]byte
+	Signers         []PKCS7Signer
+	// digested is the part of the encapsulated content covered by the
+	// messageDigest: the OCTET STRING value, or the value of other elements
+	// without their tag and length, as Authenticode hashes it
+	digested []byte
+}
+
+// PKCS7Signer is a parsed SignerInfo
+type PKCS7Signer struct {
+	Raw                []byte
+	Issuer             []byte
+	SerialNumber       *big.Int
+	SubjectKeyID       []byte
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	// SignedAttributes is the DER of the signed attributes re-tagged as a
+	// SET, which is what the signature covers; nil when there are none
+	SignedAttributes []byte
+	Attributes       []pkcs7Attribute
+	Signature        []byte
+}
+
+// ParsePKCS7 parses a DER ContentInfo holding SignedData
+func ParsePKCS7(der []byte) (*PKCS7, error) {
+	var info contentInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ContentInfo: %w", err)
+	}
+	if !info.ContentType.Equal(oidContentTypeSigned) {
+		return nil, fmt.Errorf("content type %s is not signedData", info.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("invalid SignedData: %w", err)
+	}
+
+	p7 := &PKCS7{
+		Raw:         der[:len(der)-len(rest)],
+		ContentType: sd.EncapContentInfo.EContentType,
+	}
+	// RawValue fields keep their explicit [0] tag, so Bytes is the element
+	if eContent := sd.EncapContentInfo.EContent.Bytes; len(eContent) > 0 {
+		var element asn1.RawValue
+		if _, err := asn1.Unmarshal(eContent, &element); err != nil {
+			return nil, fmt.Errorf("invalid encapsulated content: %w", err)
+		}
+		p7.Content, p7.digested = element.FullBytes, element.Bytes
+		if element.Class == asn1.ClassUniversal && element.Tag == asn1.TagOctetString {
+			p7.Content = element.Bytes
+		}
+	}
+
+	for certs := sd.Certificates.Bytes; len(certs) > 0; {
+		var raw asn1.RawValue
+		next, err := asn1.Unmarshal(certs, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate set: %w", err)
+		}
+		certs = next
+		// Skip the obsolete extended and attribute certificate choices
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		p7.Certificates = append(p7.Certificates, cert)
+		p7.RawCertificates = append(p7.RawCertificates, raw.FullBytes)
+	}
+
+	for i, rawSigner := range sd.SignerInfos {
+		signer, err := parseSignerInfo(rawSigner.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("SignerInfo %d: %w", i+1, err)
+		}
+		p7.Signers = append(p7.Signers, signer)
+	}
+	return p7, nil
+}
+
+// parseSignerInfo parses one SignerInfo
+func parseSignerInfo(der []byte) (PKCS7Signer, error) {
+	var si signerInfo
+	if _, err := asn1.Unmarshal(der, &si); err != nil {
+		return PKCS7Signer{}, err
+	}
+
+	signer := PKCS7Signer{
+		Raw:                der,
+		DigestAlgorithm:    si.DigestAlgorithm,
+		SignatureAlgorithm: si.SignatureAlgorithm,
+		Signature:          si.Signature,
+	}
+
+	switch {
+	case si.SID.Class == asn1.ClassUniversal && si.SID.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(si.SID.FullBytes, &ias); err != nil {
+			return PKCS7Signer{}, fmt.Errorf("invalid IssuerAndSerialNumber: %w", err)
+		}
+		signer.Issuer = ias.Issuer.FullBytes
+		signer.SerialNumber = ias.SerialNumber
+	case si.SID.Class == asn1.ClassContextSpecific && si.SID.Tag == 0:
+		signer.SubjectKeyID = si.SID.Bytes
+	default:
+		return PKCS7Signer{}, errors.New("unknown signer identifier")
+	}
+
+	if len(si.SignedAttrs.FullBytes) > 0 {
+		// The signature covers the attributes with an explicit SET OF tag
+		// instead of the [0] IMPLICIT tag they are stored with
+		signer.SignedAttributes = append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+		if _, err := asn1.UnmarshalWithParams(signer.SignedAttributes, &signer.Attributes, "set"); err != nil {
+			return PKCS7Signer{}, fmt.Errorf("invalid signed attributes: %w", err)
+		}
+	}
+	return signer, nil
+}
+
+// Attribute returns the first value of a signed attribute
+func (s PKCS7Signer) Attribute(oid asn1.ObjectIdentifier) (asn1.RawValue, bool) {
+	for _, attr := range s.Attributes {
+		if attr.Type.Equal(oid) && len(attr.Values) > 0 {
+			return attr.Values[0], true
+		}
+	}
+	return asn1.RawValue{}, false
+}
+
+// Certificate returns the certificate identified by the signer
+func (p7 *PKCS7) Certificate(signer PKCS7Signer) (*x509.Certificate, error) {
+	for _, cert := range p7.Certificates {
+		if signer.SubjectKeyID != nil {
+			if bytes.Equal(cert.SubjectKeyId, signer.SubjectKeyID) {
+				return cert, nil
+			}
+			continue
+		}
+		if cert.SerialNumber.Cmp(signer.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, signer.Issuer) {
+			return cert, nil
+		}
+	}
+	return nil, ErrPKCS7NoCertificate
+}
+
+// Verify checks every signer against content, which is the encapsulated
+// content or, for detached signatures, the externally supplied data. With
+// signed attributes the messageDigest attribute must match the content and
+// the signature covers the attributes; otherwise it covers the content.
+// The certificate chain is not validated.
+func (p7 *PKCS7) Verify(content []byte) error {
+	if len(p7.Signers) == 0 {
+		return ErrPKCS7NoSigner
+	}
+	for _, signer := range p7.Signers {
+		if err := p7.verifySigner(signer, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyEmbedded checks every signer against the encapsulated content
+func (p7 *PKCS7) VerifyEmbedded() error {
+	if p7.Content == nil {
+		return errors.New("detached signature has no encapsulated content")
+	}
+	return p7.Verify(p7.digested)
+}
+
+// verifySigner verifies one SignerInfo
+func (p7 *PKCS7) verifySigner(signer PKCS7Signer, content []byte) error {
+	hash, ok := digestAlgorithms[signer.DigestAlgorithm.Algorithm.String()]
+	if !ok || !hash.Available() {
+		return fmt.Errorf("%w %s", ErrPKCS7UnsupportedDigest, signer.DigestAlgorithm.Algorithm)
+	}
+	cert, err := p7.Certificate(signer)
+	if err != nil {
+		return err
+	}
+	algorithm, err := x509SignatureAlgorithm(signer.DigestAlgorithm.Algorithm, signer.SignatureAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	signed := content
+	if signer.SignedAttributes != nil {
+		value, found := signer.Attribute(oidAttributeContentType)
+		if !found {
+			return fmt.Errorf("%w: signed attributes lack contentType", ErrPKCS7ContentTypeMismatch)
+		}
+		var contentType asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(value.FullBytes, &contentType); err != nil {
+			return fmt.Errorf("invalid contentType: %w", err)
+		}
+		if !contentType.Equal(p7.ContentType) {
+			return fmt.Errorf("%w: %s, not %s", ErrPKCS7ContentTypeMismatch, contentType, p7.ContentType)
+		}
+
+		value, found = signer.Attribute(oidAttributeDigest)
+		if !found {
+			return errors.New("signed attributes lack messageDigest")
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(value.FullBytes, &digest); err != nil {
+			return fmt.Errorf("invalid messageDigest: %w", err)
+		}
+		h := hash.New()
+		h.Write(content)
+		if !bytes.Equal(h.Sum(nil), digest) {
+			return ErrPKCS7DigestMismatch
+		}
+		signed = signer.SignedAttributes
+	}
+
+	if err := cert.CheckSignature(algorithm, signed, signer.Signature); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	return nil
+}
+
+// x509SignatureAlgorithm combines a SignerInfo digest algorithm with its
+// signature algorithm, which is often just the key type (rsaEncryption,
+// ecPublicKey)
+func x509SignatureAlgorithm(digest, signature asn1.ObjectIdentifier) (x509.SignatureAlgorithm, error) {
+	byDigest := func(choices map[string]x509.SignatureAlgorithm) (x509.SignatureAlgorithm, error) {
+		if algorithm, ok := choices[digest.String()]; ok {
+			return algorithm, nil
+		}
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported digest %s for signature algorithm %s", digest, signature)
+	}
+
+	switch signature.String() {
+	case "1.2.840.113549.1.1.1": // rsaEncryption
+		return byDigest(map[string]x509.SignatureAlgorithm{
+			"1.2.840.113549.2.5":     x509.MD5WithRSA,
+			"1.3.14.3.2.26":          x509.SHA1WithRSA,
+			"2.16.840.1.101.3.4.2.1": x509.SHA256WithRSA,
+			"2.16.840.1.101.3.4.2.2": x509.SHA384WithRSA,
+			"2.16.840.1.101.3.4.2.3": x509.SHA512WithRSA,
+		})
+	case "1.2.840.113549.1.1.10": // rsaPSS
+		return byDigest(map[string]x509.SignatureAlgorithm{
+			"2.16.840.1.101.3.4.2.1": x509.SHA256WithRSAPSS,
+			"2.16.840.1.101.3.4.2.2": x509.SHA384WithRSAPSS,
+			"2.16.840.1.101.3.4.2.3": x509.SHA512WithRSAPSS,
+		})
+	case "1.2.840.10045.2.1": // ecPublicKey
+		return byDigest(map[string]x509.SignatureAlgorithm{
+			"1.3.14.3.2.26":          x509.ECDSAWithSHA1,
+			"2.16.840.1.101.3.4.2.1": x509.ECDSAWithSHA256,
+			"2.16.840.1.101.3.4.2.2": x509.ECDSAWithSHA384,
+			"2.16.840.1.101.3.4.2.3": x509.ECDSAWithSHA512,
+		})
+	case "1.2.840.113549.1.1.4":
+		return x509.MD5WithRSA, nil
+	case "1.2.840.113549.1.1.5":
+		return x509.SHA1WithRSA, nil
+	case "1.2.840.113549.1.1.11":
+		return x509.SHA256WithRSA, nil
+	case "1.2.840.113549.1.1.12":
+		return x509.SHA384WithRSA, nil
+	case "1.2.840.113549.1.1.13":
+		return x509.SHA512WithRSA, nil
+	case "1.2.840.10045.4.1":
+		return x509.ECDSAWithSHA1, nil
+	case "1.2.840.10045.4.3.2":
+		return x509.ECDSAWithSHA256, nil
+	case "1.2.840.10045.4.3.3":
+		return x509.ECDSAWithSHA384, nil
+	case "1.2.840.10045.4.3.4":
+		return x509.ECDSAWithSHA512, nil
+	case "1.3.101.112":
+		return x509.PureEd25519, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported signature algorithm %s", signature)
+}
+
+// FindPKCS7 returns the first SignedData ContentInfo embedded in data and
+// its offset. Scanning forwards finds an outer signature before any
+// timestamp tokens nested inside it.
+func FindPKCS7(data []byte) (*PKCS7, int, error) {
+	lastErr := errors.New("no PKCS#7 SignedData found")
+	for i := 0; ; {
+		j := bytes.Index(data[i:], oidSignedDataDER)
+		if j < 0 {
+			return nil, 0, lastErr
+		}
+		i += j + len(oidSignedDataDER)
+		offset, _ := findSignedData(data[:i])
+		p7, err := ParsePKCS7(data[offset:])
+		if err == nil {
+			return p7, offset, nil
+		}
+		lastErr = err
+	}
+}
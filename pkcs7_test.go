@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testSigner is a key with a self-signed certificate carrying every field
+// validateSignatureFields requires
+type testSigner struct {
+	key  crypto.Signer
+	cert *x509.Certificate
+}
+
+func newTestSigner(t *testing.T, key crypto.Signer) testSigner {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(4711),
+		Subject: pkix.Name{
+			CommonName:   "Test Signer",
+			Country:      []string{"DE"},
+			Locality:     []string{"Nuremberg"},
+			Organization: []string{"Example"},
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, Value: "signer@example.com"},
+			},
+		},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		SubjectKeyId: []byte{1, 2, 3, 4},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return testSigner{key: key, cert: cert}
+}
+
+func newTestRSASigner(t *testing.T) testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return newTestSigner(t, key)
+}
+
+// signDetached builds a detached SHA-256 SignedData over content, with signed
+// attributes unless plain is set
+func (ts testSigner) signDetached(t *testing.T, content []byte, plain bool) []byte {
+	t.Helper()
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}}
+	signatureID := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}}
+	if _, ok := ts.key.(*ecdsa.PrivateKey); ok {
+		signatureID = pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}}
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(content)
+	si := signerInfo{
+		Version:            1,
+		DigestAlgorithm:    sha256ID,
+		SignatureAlgorithm: signatureID,
+	}
+	si.SID.FullBytes, _ = asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: ts.cert.RawIssuer},
+		SerialNumber: ts.cert.SerialNumber,
+	})
+
+	signed := content
+	if !plain {
+		contentType, _ := asn1.Marshal(oidContentTypeData)
+		messageDigest, _ := asn1.Marshal(digest.Sum(nil))
+		attrs, err := asn1.MarshalWithParams([]pkcs7Attribute{
+			{Type: oidAttributeContentType, Values: []asn1.RawValue{{FullBytes: contentType}}},
+			{Type: oidAttributeDigest, Values: []asn1.RawValue{{FullBytes: messageDigest}}},
+		}, "set")
+		if err != nil {
+			t.Fatalf("Failed to marshal attributes: %v", err)
+		}
+		signed = attrs
+		si.SignedAttrs.FullBytes = append([]byte{0xA0}, attrs[1:]...)
+	}
+	h := crypto.SHA256.New()
+	h.Write(signed)
+	signature, err := ts.key.Sign(rand.Reader, h.Sum(nil), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	si.Signature = signature
+
+	rawSigner, err := asn1.Marshal(si)
+	if err != nil {
+		t.Fatalf("Failed to marshal SignerInfo: %v", err)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256ID},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidContentTypeData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: ts.cert.Raw},
+		SignerInfos:      []asn1.RawValue{{FullBytes: rawSigner}},
+	}
+	rawSD, err := asn1.Marshal(sd)
+	if err != nil {
+		t.Fatalf("Failed to marshal SignedData: %v", err)
+	}
+	der, err := asn1.Marshal(contentInfo{
+		ContentType: oidContentTypeSigned,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawSD},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal ContentInfo: %v", err)
+	}
+	return der
+}
+
+// TestParsePKCS7 tests parsing of certificates and signer fields
+func TestParsePKCS7(t *testing.T) {
+	ts := newTestRSASigner(t)
+	der := ts.signDetached(t, []byte("content"), false)
+
+	p7, err := ParsePKCS7(der)
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	if !p7.ContentType.Equal(oidContentTypeData) || p7.Content != nil {
+		t.Errorf("Expected detached data content, got %s with %d bytes", p7.ContentType, len(p7.Content))
+	}
+	if len(p7.Certificates) != 1 || p7.Certificates[0].Subject.CommonName != "Test Signer" {
+		t.Fatalf("Expected the signer certificate, got %d certificates", len(p7.Certificates))
+	}
+	if len(p7.Signers) != 1 {
+		t.Fatalf("Expected one signer, got %d", len(p7.Signers))
+	}
+	signer := p7.Signers[0]
+	if signer.SerialNumber.Int64() != 4711 || signer.SignedAttributes[0] != 0x31 {
+		t.Errorf("Unexpected signer: serial %v, attributes tag %#x", signer.SerialNumber, signer.SignedAttributes[0])
+	}
+	if _, found := signer.Attribute(oidAttributeDigest); !found {
+		t.Errorf("Expected messageDigest attribute")
+	}
+	if cert, err := p7.Certificate(signer); err != nil || cert != p7.Certificates[0] {
+		t.Errorf("Expected signer certificate lookup to succeed, got %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":       {},
+		"not signed":  append([]byte{0x30, 0x0B}, []byte{0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x01}...),
+		"truncated":   der[:len(der)/2],
+		"no sequence": {0x04, 0x02, 0x00, 0x00},
+	} {
+		if _, err := ParsePKCS7(data); err == nil {
+			t.Errorf("Expected error for %s input", name)
+		}
+	}
+}
+
+// TestPKCS7Verify tests detached verification with and without signed attributes
+func TestPKCS7Verify(t *testing.T) {
+	content := []byte("Signature-Version: 1.0\r\n\r\n")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	for name, ts := range map[string]testSigner{
+		"rsa":   newTestRSASigner(t),
+		"ecdsa": newTestSigner(t, ecKey),
+	} {
+		for _, plain := range []bool{false, true} {
+			p7, err := ParsePKCS7(ts.signDetached(t, content, plain))
+			if err != nil {
+				t.Fatalf("%s: ParsePKCS7 failed: %v", name, err)
+			}
+			if err := p7.Verify(content); err != nil {
+				t.Errorf("%s (plain %v): expected valid signature, got %v", name, plain, err)
+			}
+			err = p7.Verify([]byte("tampered"))
+			if err == nil {
+				t.Errorf("%s (plain %v): expected tampered content to fail", name, plain)
+			}
+			if !plain && !errors.Is(err, ErrPKCS7DigestMismatch) {
+				t.Errorf("%s: expected digest mismatch, got %v", name, err)
+			}
+		}
+	}
+
+	ts := newTestRSASigner(t)
+	p7, _ := ParsePKCS7(ts.signDetached(t, content, false))
+	p7.ContentType = oidContentTypeSigned
+	if err := p7.Verify(content); !errors.Is(err, ErrPKCS7ContentTypeMismatch) {
+		t.Errorf("Expected contentType mismatch error, got %v", err)
+	}
+	p7.ContentType = oidContentTypeData
+	p7.Certificates = nil
+	if err := p7.Verify(content); !errors.Is(err, ErrPKCS7NoCertificate) {
+		t.Errorf("Expected missing certificate error, got %v", err)
+	}
+	p7.Signers = nil
+	if err := p7.Verify(content); !errors.Is(err, ErrPKCS7NoSigner) {
+		t.Errorf("Expected no signer error, got %v", err)
+	}
+}
+
+// TestFindPKCS7 tests locating a SignedData embedded in other data
+func TestFindPKCS7(t *testing.T) {
+	ts := newTestRSASigner(t)
+	der := ts.signDetached(t, []byte("content"), false)
+
+	// A stray OID without a ContentInfo before the real signature is skipped
+	data := append([]byte("header"), oidSignedDataDER...)
+	data = append(data, 0x00, 0x00)
+	offset := len(data)
+	data = append(data, der...)
+	data = append(data, "trailer"...)
+
+	p7, found, err := FindPKCS7(data)
+	if err != nil {
+		t.Fatalf("FindPKCS7 failed: %v", err)
+	}
+	if found != offset || len(p7.Raw) != len(der) {
+		t.Errorf("Expected SignedData at %d (%d bytes), got %d (%d bytes)", offset, len(der), found, len(p7.Raw))
+	}
+
+	if _, _, err := FindPKCS7([]byte("no signature here")); err == nil {
+		t.Errorf("Expected error without SignedData")
+	}
+}
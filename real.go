@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// REAL special values (X.690 8.5.9)
+const (
+	RealPlusInfinity  = 0x40
+	RealMinusInfinity = 0x41
+	RealNotANumber    = 0x42
+	RealMinusZero     = 0x43
+)
+
+// maxRealBinaryExponent bounds the power of two evaluated for binary REALs;
+// anything larger is shown symbolically instead of being expanded
+const maxRealBinaryExponent = 1 << 20
+
+// ISO 6093 numerical representations used by decimal REAL encodings
+var (
+	realNR1 = regexp.MustCompile(`^ *[+-]?[0-9]+$`)
+	realNR2 = regexp.MustCompile(`^ *[+-]?([0-9]+[.,][0-9]*|[.,][0-9]+)$`)
+	realNR3 = regexp.MustCompile(`^ *[+-]?([0-9]+[.,]?[0-9]*|[.,][0-9]+)[Ee][+-]?[0-9]+$`)
+)
+
+// decodeReal decodes REAL content per X.690 8.5 and returns a readable value
+// followed by a description of the encoding that was used
+func decodeReal(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "0", nil
+	}
+
+	first := content[0]
+	switch {
+	case first&0x80 != 0:
+		return decodeBinaryReal(content)
+	case first&0x40 != 0:
+		if len(content) != 1 {
+			return "", errors.New("special REAL value must be a single octet")
+		}
+		switch first {
+		case RealPlusInfinity:
+			return "PLUS-INFINITY", nil
+		case RealMinusInfinity:
+			return "MINUS-INFINITY", nil
+		case RealNotANumber:
+			return "NOT-A-NUMBER", nil
+		case RealMinusZero:
+			return "-0", nil
+		}
+		return "", fmt.Errorf("reserved special REAL value 0x%02X", first)
+	default:
+		return decodeDecimalReal(first&0x3F, content[1:])
+	}
+}
+
+// decodeBinaryReal decodes the binary encoding: S × N × 2^F × B^E
+func decodeBinaryReal(content []byte) (string, error) {
+	first := content[0]
+	negative := first&0x40 != 0
+	scale := int((first >> 2) & 0x03)
+
+	var base, bitsPerDigit int
+	switch (first >> 4) & 0x03 {
+	case 0:
+		base, bitsPerDigit = 2, 1
+	case 1:
+		base, bitsPerDigit = 8, 3
+	case 2:
+		base, bitsPerDigit = 16, 4
+	default:
+		return "", errors.New("reserved REAL base")
+	}
+
+	rest := content[1:]
+	var expLen int
+	switch first & 0x03 {
+	case 0, 1, 2:
+		expLen = int(first&0x03) + 1
+	default:
+		if len(rest) == 0 {
+			return "", errors.New("missing REAL exponent length octet")
+		}
+		expLen = int(rest[0])
+		rest = rest[1:]
+		if expLen == 0 {
+			return "", errors.New("zero-length REAL exponent")
+		}
+	}
+	if len(rest) < expLen {
+		return "", fmt.Errorf("REAL exponent needs %d octets, %d available", expLen, len(rest))
+	}
+
+	exponent := decodeInteger(rest[:expLen])
+	mantissa := new(big.Int).SetBytes(rest[expLen:])
+
+	description := fmt.Sprintf("binary, base %d, F=%d, mantissa %s, exponent %s", base, scale, mantissa.String(), exponent.String())
+	if base != 2 || scale != 0 || (mantissa.Sign() != 0 && mantissa.Bit(0) == 0) {
+		description += ", not DER canonical"
+	}
+
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	// B^E = 2^(bitsPerDigit*E), so the value is ±N × 2^(F + bitsPerDigit*E)
+	power := new(big.Int).Mul(exponent, big.NewInt(int64(bitsPerDigit)))
+	power.Add(power, big.NewInt(int64(scale)))
+	if !power.IsInt64() || power.Int64() > maxRealBinaryExponent || power.Int64() < -maxRealBinaryExponent {
+		return fmt.Sprintf("%s%s × 2^%s (%s)", sign, mantissa.String(), power.String(), description), nil
+	}
+
+	prec := uint(mantissa.BitLen())
+	if prec < 64 {
+		prec = 64
+	}
+	value := new(big.Float).SetPrec(prec).SetInt(mantissa)
+	value.SetMantExp(value, int(power.Int64()))
+	if negative {
+		value.Neg(value)
+	}
+	return fmt.Sprintf("%s (%s)", value.Text('g', -1), description), nil
+}
+
+// decodeDecimalReal decodes the ISO 6093 NR1, NR2 and NR3 character forms
+func decodeDecimalReal(form byte, digits []byte) (string, error) {
+	text := string(digits)
+	var pattern *regexp.Regexp
+	switch form {
+	case 1:
+		pattern = realNR1
+	case 2:
+		pattern = realNR2
+	case 3:
+		pattern = realNR3
+	default:
+		return "", fmt.Errorf("reserved decimal REAL form %d", form)
+	}
+	if !pattern.MatchString(text) {
+		return "", fmt.Errorf("%q is not a valid NR%d number", text, form)
+	}
+
+	normalized := strings.Replace(strings.TrimLeft(text, " "), ",", ".", 1)
+	value, ok := new(big.Float).SetPrec(128).SetString(normalized)
+	if !ok {
+		return "", fmt.Errorf("cannot evaluate %q", text)
+	}
+	return fmt.Sprintf("%s (decimal NR%d %q)", value.Text('g', -1), form, text), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestDecodeReal tests REAL decoding for binary, decimal and special encodings
+func TestDecodeReal(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Zero",
+			content:  []byte{},
+			expected: "0",
+		},
+		{
+			name:     "Plus infinity",
+			content:  []byte{RealPlusInfinity},
+			expected: "PLUS-INFINITY",
+		},
+		{
+			name:     "Minus infinity",
+			content:  []byte{RealMinusInfinity},
+			expected: "MINUS-INFINITY",
+		},
+		{
+			name:     "NaN",
+			content:  []byte{RealNotANumber},
+			expected: "NOT-A-NUMBER",
+		},
+		{
+			name:     "Minus zero",
+			content:  []byte{RealMinusZero},
+			expected: "-0",
+		},
+		{
+			name:     "Binary 0.15625",
+			content:  []byte{0x80, 0xFB, 0x05}, // 5 × 2^-5
+			expected: "0.15625 (binary, base 2, F=0, mantissa 5, exponent -5)",
+		},
+		{
+			name:     "Binary negative",
+			content:  []byte{0xC0, 0x01, 0x03}, // -3 × 2^1
+			expected: "-6 (binary, base 2, F=0, mantissa 3, exponent 1)",
+		},
+		{
+			name:     "Base 8 with scale",
+			content:  []byte{0x94, 0x01, 0x01}, // 1 × 2^1 × 8^1
+			expected: "16 (binary, base 8, F=1, mantissa 1, exponent 1, not DER canonical)",
+		},
+		{
+			name:     "Base 16 two-octet exponent",
+			content:  []byte{0xA1, 0x00, 0x02, 0x03}, // 3 × 16^2
+			expected: "768 (binary, base 16, F=0, mantissa 3, exponent 2, not DER canonical)",
+		},
+		{
+			name:     "Long form exponent",
+			content:  []byte{0x83, 0x01, 0x02, 0x01}, // 1 × 2^2
+			expected: "4 (binary, base 2, F=0, mantissa 1, exponent 2)",
+		},
+		{
+			name:     "Huge exponent stays symbolic",
+			content:  []byte{0x82, 0x7F, 0xFF, 0xFF, 0x01},
+			expected: "1 × 2^8388607 (binary, base 2, F=0, mantissa 1, exponent 8388607)",
+		},
+		{
+			name:     "NR1",
+			content:  append([]byte{0x01}, " -42"...),
+			expected: "-42 (decimal NR1 \" -42\")",
+		},
+		{
+			name:     "NR2 with comma",
+			content:  append([]byte{0x02}, "1,5"...),
+			expected: "1.5 (decimal NR2 \"1,5\")",
+		},
+		{
+			name:     "NR3",
+			content:  append([]byte{0x03}, "25E-1"...),
+			expected: "2.5 (decimal NR3 \"25E-1\")",
+		},
+		{
+			name:        "NR1 with decimal mark",
+			content:     append([]byte{0x01}, "1.5"...),
+			expectError: true,
+		},
+		{
+			name:        "Truncated exponent",
+			content:     []byte{0x81, 0x01},
+			expectError: true,
+		},
+		{
+			name:        "Reserved base",
+			content:     []byte{0xB0, 0x01, 0x01},
+			expectError: true,
+		},
+		{
+			name:        "Reserved special value",
+			content:     []byte{0x44},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeReal(tt.content)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none (%s)", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
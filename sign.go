@@ -0,0 +1,457 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Authenticode object identifiers
+var (
+	oidSpcIndirectData = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 4}
+	oidSpcPEImageData  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 15}
+	oidSpcSpOpusInfo   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 12}
+)
+
+// spcPEImageData is SpcPeImageData with no flags and an empty file link,
+// as written by pesign: SEQUENCE { BIT STRING {}, [0] { [2] { [0] "" } } }
+var spcPEImageData = []byte{0x30, 0x09, 0x03, 0x01, 0x00, 0xA0, 0x04, 0xA2, 0x02, 0x80, 0x00}
+
+// Signing hash algorithms selected with -hash
+var signingHashes = map[string]struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	"sha256": {crypto.SHA256, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}},
+	"sha384": {crypto.SHA384, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}},
+	"sha512": {crypto.SHA512, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}},
+}
+
+// spcIndirectDataContent is the Authenticode content that is signed
+type spcIndirectDataContent struct {
+	Data          spcAttributeTypeAndValue
+	MessageDigest digestInfo
+}
+
+// spcAttributeTypeAndValue names the kind of signed object
+type spcAttributeTypeAndValue struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// digestInfo holds the digest of the signed object
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// Signer signs images with a file-based key
+type Signer struct {
+	Key crypto.Signer
+	// Certificates holds the signing certificate first, then any chain
+	// certificates to embed
+	Certificates []*x509.Certificate
+	// Hash is the digest algorithm name, sha256 when empty
+	Hash string
+	// Now returns the signing time; nil selects time.Now
+	Now func() time.Time
+}
+
+// LoadSigner reads a PEM private key (PKCS#8, PKCS#1 or SEC 1) and a PEM
+// certificate file whose first certificate belongs to the key
+func LoadSigner(keyPath, certPath string) (*Signer, error) {
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for rest := certPEM; ; {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = next
+		if block.Type != pemCertificateType {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", certPath, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no PEM certificate found", certPath)
+	}
+
+	if !publicKeysEqual(key.Public(), certs[0].PublicKey) {
+		return nil, fmt.Errorf("%s: certificate does not match the private key", certPath)
+	}
+	return &Signer{Key: key, Certificates: certs}, nil
+}
+
+// parsePrivateKey decodes the first private key block of a PEM file
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	for rest := data; ; {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no PEM private key found")
+		}
+		rest = next
+
+		var key any
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return key.(crypto.Signer), nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// publicKeysEqual compares two public keys of any supported type
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// hash resolves the configured digest algorithm
+func (s *Signer) hash() (crypto.Hash, asn1.ObjectIdentifier, error) {
+	name := s.Hash
+	if name == "" {
+		name = "sha256"
+	}
+	// Ed25519 in CMS is defined with SHA-512 only (RFC 8419)
+	if _, ok := s.Key.(ed25519.PrivateKey); ok {
+		name = "sha512"
+	}
+	h, ok := signingHashes[strings.ToLower(name)]
+	if !ok {
+		return 0, nil, fmt.Errorf("unsupported hash %q (use sha256, sha384 or sha512)", name)
+	}
+	return h.hash, h.oid, nil
+}
+
+// signatureAlgorithm returns the SignerInfo signature algorithm for the key
+func (s *Signer) signatureAlgorithm(hash crypto.Hash) (pkix.AlgorithmIdentifier, error) {
+	switch s.Key.(type) {
+	case *rsa.PrivateKey:
+		return pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}, Parameters: asn1.NullRawValue}, nil
+	case *ecdsa.PrivateKey:
+		oids := map[crypto.Hash]asn1.ObjectIdentifier{
+			crypto.SHA256: {1, 2, 840, 10045, 4, 3, 2},
+			crypto.SHA384: {1, 2, 840, 10045, 4, 3, 3},
+			crypto.SHA512: {1, 2, 840, 10045, 4, 3, 4},
+		}
+		return pkix.AlgorithmIdentifier{Algorithm: oids[hash]}, nil
+	case ed25519.PrivateKey:
+		return pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 101, 112}}, nil
+	}
+	return pkix.AlgorithmIdentifier{}, fmt.Errorf("unsupported key type %T", s.Key)
+}
+
+// SignData builds a SignedData over content with contentType, messageDigest
+// and signingTime signed attributes plus any extra ones. The content is
+// embedded as eContent unless detached is set. digested is the part of the
+// content covered by the messageDigest.
+func (s *Signer) SignData(contentType asn1.ObjectIdentifier, eContent, digested []byte, detached bool, extra ...pkcs7Attribute) ([]byte, error) {
+	hash, _, err := s.hash()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	h := hash.New()
+	h.Write(digested)
+	attributes := []pkcs7Attribute{
+		newAttribute(oidAttributeContentType, contentType),
+		newAttribute(oidAttributeSigningTime, now().UTC()),
+		newAttribute(oidAttributeDigest, h.Sum(nil)),
+	}
+	// encoding/asn1 sorts SET OF elements into DER order
+	signedAttrs, err := asn1.MarshalWithParams(append(attributes, extra...), "set")
+	if err != nil {
+		return nil, err
+	}
+	return s.signedData(contentType, eContent, detached, signedAttrs, signedAttrs)
+}
+
+// SignDetached builds a detached SignedData of id-data whose signature
+// covers content itself, without signed attributes, as sign-file produces
+// for kernel modules: the kernel rejects module signatures with them.
+func (s *Signer) SignDetached(content []byte) ([]byte, error) {
+	return s.signedData(oidContentTypeData, nil, true, nil, content)
+}
+
+// signedData signs message, the DER of signedAttrs or the content itself
+// when signedAttrs is nil, and wraps the signature in a ContentInfo
+func (s *Signer) signedData(contentType asn1.ObjectIdentifier, eContent []byte, detached bool, signedAttrs, message []byte) ([]byte, error) {
+	hash, hashOID, err := s.hash()
+	if err != nil {
+		return nil, err
+	}
+	signatureAlgorithm, err := s.signatureAlgorithm(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	if _, ok := s.Key.(ed25519.PrivateKey); ok {
+		signature, err = s.Key.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		h := hash.New()
+		h.Write(message)
+		signature, err = s.Key.Sign(rand.Reader, h.Sum(nil), hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
+	}
+
+	var attrs asn1.RawValue
+	if signedAttrs != nil {
+		attrs.FullBytes = append([]byte{0xA0}, signedAttrs[1:]...)
+	}
+	cert := s.Certificates[0]
+	sid, err := asn1.Marshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber})
+	if err != nil {
+		return nil, err
+	}
+	signer, err := asn1.Marshal(signerInfo{
+		Version:            1,
+		SID:                asn1.RawValue{FullBytes: sid},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+		SignedAttrs:        attrs,
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rawCerts []byte
+	for _, c := range s.Certificates {
+		rawCerts = append(rawCerts, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: hashOID, Parameters: asn1.NullRawValue}},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: contentType},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos:      []asn1.RawValue{{FullBytes: signer}},
+	}
+	if !detached {
+		sd.EncapContentInfo.EContent = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: eContent}
+	}
+	rawSD, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidContentTypeSigned,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawSD},
+	})
+}
+
+// newAttribute builds a single-valued attribute; values that cannot be
+// marshalled are programming errors
+func newAttribute(oid asn1.ObjectIdentifier, value any) pkcs7Attribute {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return pkcs7Attribute{Type: oid, Values: []asn1.RawValue{{FullBytes: der}}}
+}
+
+// authenticodeDigest hashes a PE image the way Authenticode does: the whole
+// file except the checksum, the security directory entry and the
+// certificate table
+func authenticodeDigest(pe *PEImage, hash crypto.Hash) []byte {
+	end := len(pe.data)
+	if pe.Signed() {
+		end = pe.CertTableOffset
+	}
+	h := hash.New()
+	h.Write(pe.data[:pe.ChecksumOffset])
+	h.Write(pe.data[pe.ChecksumOffset+4 : pe.SecurityDirOffset])
+	h.Write(pe.data[pe.SecurityDirOffset+peDataDirEntrySize : end])
+	return h.Sum(nil)
+}
+
+// SignImage signs a PE image with Authenticode or a kernel module or ELF
+// image with an appended signature, replacing any existing signature
+func (s *Signer) SignImage(data []byte) ([]byte, string, error) {
+	hash, hashOID, err := s.hash()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var signature []byte
+	switch detectContainer(data) {
+	case ContainerPE:
+		unsigned, err := unsignedPE(data)
+		if err != nil {
+			return nil, "", err
+		}
+		pe, err := ParsePE(unsigned)
+		if err != nil {
+			return nil, "", err
+		}
+		content, err := asn1.Marshal(spcIndirectDataContent{
+			Data: spcAttributeTypeAndValue{Type: oidSpcPEImageData, Value: asn1.RawValue{FullBytes: spcPEImageData}},
+			MessageDigest: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+				Digest:    authenticodeDigest(pe, hash),
+			},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		// Authenticode's messageDigest covers the content without its
+		// SEQUENCE header
+		var element asn1.RawValue
+		asn1.Unmarshal(content, &element)
+		opus := pkcs7Attribute{Type: oidSpcSpOpusInfo, Values: []asn1.RawValue{{FullBytes: []byte{0x30, 0x00}}}}
+		if signature, err = s.SignData(oidSpcIndirectData, content, element.Bytes, false, opus); err != nil {
+			return nil, "", err
+		}
+		data = unsigned
+	case ContainerModule:
+		if hasModuleSignature(data) {
+			sig, err := ParseModuleSignature(data)
+			if err != nil {
+				return nil, "", err
+			}
+			data = data[:sig.Offset]
+		}
+		if signature, err = s.SignDetached(data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	signed, description, err := attachSignature(data, signature)
+	if err != nil {
+		return nil, "", err
+	}
+	return signed, description, nil
+}
+
+// unsignedPE strips any certificate table and pads the image to 8 bytes,
+// since the padding Attach adds is covered by the Authenticode digest.
+// Attach updates the checksum afterwards.
+func unsignedPE(data []byte) ([]byte, error) {
+	pe, err := ParsePE(data)
+	if err != nil {
+		return nil, err
+	}
+	if pe.Signed() {
+		data = pe.Strip()
+	}
+	padded := make([]byte, alignUp(len(data), winCertAlignment))
+	copy(padded, data)
+	return padded, nil
+}
+
+// runSignCommand implements "autograph-pls sign", returning the exit status
+func runSignCommand(args []string) int {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "PEM private key `file` (RSA, ECDSA or Ed25519)")
+	certPath := fs.String("cert", "", "PEM certificate `file`: the signing certificate, then any chain to embed")
+	hashName := fs.String("hash", "sha256", "digest `algorithm`: sha256, sha384 or sha512 (Ed25519 always uses sha512)")
+	output := fs.String("o", "", "output `file` (default: <file>"+SignedSuffix+")")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s sign -key <key.pem> -cert <cert.pem> [options] <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nSigns a PE/EFI image with Authenticode, or a kernel module or ELF image with an\n")
+		fmt.Fprintf(os.Stderr, "appended PKCS#7 signature, using a key file instead of an HSM. Meant for test\n")
+		fmt.Fprintf(os.Stderr, "fixtures and CI; an existing signature is replaced.\n")
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s sign -key test.key -cert test.crt grubx64.efi\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sign -key ec.key -cert ec.crt -hash sha384 -o signed.ko module.ko\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 || *keyPath == "" || *certPath == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	path := fs.Arg(0)
+	if *output == "" {
+		if path == StdinPath {
+			fmt.Printf("Error: -o is required when reading stdin\n")
+			return ExitUsage
+		}
+		*output = path + SignedSuffix
+	}
+
+	signer, err := LoadSigner(*keyPath, *certPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+	signer.Hash = *hashName
+
+	fh := FileHandler{}
+	input, err := fh.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+	signed, description, err := signer.SignImage(input.Bytes())
+	input.Close()
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(path), err)
+		return errorExitCode(err)
+	}
+	if err := fh.SaveToFile(signed, *output); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitIOError
+	}
+	fmt.Printf("%s: signed as %s, %s\n", inputName(path), signer.Certificates[0].Subject, description)
+	fmt.Printf("Signed image written to: %s (%d bytes)\n", *output, len(signed))
+	return verifyOutput(fh, *output, signed)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestKeyPair writes the signer's PKCS#8 key and certificate as PEM
+func writeTestKeyPair(t *testing.T, dir string, ts testSigner) (string, string) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(ts.key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	keyPath, certPath := filepath.Join(dir, "test.key"), filepath.Join(dir, "test.crt")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.cert.Raw}), 0o644)
+	return keyPath, certPath
+}
+
+// TestAuthenticodeDigest checks the image digest against a real signature
+func TestAuthenticodeDigest(t *testing.T) {
+	data, err := os.ReadFile("testfiles/good/MokManager.efi")
+	if err != nil {
+		t.Skip("MokManager.efi not available")
+	}
+	pe, err := ParsePE(data)
+	if err != nil {
+		t.Fatalf("ParsePE failed: %v", err)
+	}
+	certs, err := pe.Certificates()
+	if err != nil || len(certs) == 0 {
+		t.Fatalf("Expected a certificate table (%v)", err)
+	}
+	p7, err := ParsePKCS7(certs[0].Data)
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	var content spcIndirectDataContent
+	if _, err := asn1.Unmarshal(p7.Content, &content); err != nil {
+		t.Fatalf("Expected SpcIndirectDataContent: %v", err)
+	}
+	if got := authenticodeDigest(pe, crypto.SHA256); !bytes.Equal(got, content.MessageDigest.Digest) {
+		t.Errorf("Expected digest %x, got %x", content.MessageDigest.Digest, got)
+	}
+}
+
+// TestSignImage signs fresh PE and module fixtures with every key type
+func TestSignImage(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signers := map[string]*Signer{}
+	for name, ts := range map[string]testSigner{
+		"rsa":     newTestRSASigner(t),
+		"ecdsa":   newTestSigner(t, ecKey),
+		"ed25519": newTestSigner(t, edKey),
+	} {
+		signers[name] = &Signer{Key: ts.key, Certificates: []*x509.Certificate{ts.cert}}
+	}
+	signers["ecdsa"].Hash = "sha384"
+
+	image := buildTestPE(bytes.Repeat([]byte{0xCC}, 101))
+	module := []byte("\x7fELF module content")
+	for name, signer := range signers {
+		signed, _, err := signer.SignImage(image)
+		if err != nil {
+			t.Fatalf("%s: SignImage failed on PE: %v", name, err)
+		}
+		if result := analyzeData(signed); result.Status != FileSigned {
+			t.Errorf("%s: expected signed PE, got %s", name, result.Status)
+		}
+		p7, _, err := FindPKCS7(signed)
+		if err != nil {
+			t.Fatalf("%s: FindPKCS7 failed: %v", name, err)
+		}
+		if err := p7.VerifyEmbedded(); err != nil {
+			t.Errorf("%s: expected valid Authenticode signature, got %v", name, err)
+		}
+		resigned, _, err := signer.SignImage(signed)
+		if err != nil {
+			t.Fatalf("%s: re-signing failed: %v", name, err)
+		}
+		pe, _ := ParsePE(resigned)
+		if certs, _ := pe.Certificates(); len(certs) != 1 {
+			t.Errorf("%s: expected re-signing to replace the signature, got %d", name, len(certs))
+		}
+
+		signed, _, err = signer.SignImage(module)
+		if err != nil {
+			t.Fatalf("%s: SignImage failed on module: %v", name, err)
+		}
+		sig, err := ParseModuleSignature(signed)
+		if err != nil || sig.Offset != len(module) {
+			t.Fatalf("%s: expected a module signature trailer (%v)", name, err)
+		}
+		p7, err = ParsePKCS7(sig.Signature)
+		if err != nil {
+			t.Fatalf("%s: ParsePKCS7 failed: %v", name, err)
+		}
+		if err := p7.Verify(module); err != nil {
+			t.Errorf("%s: expected valid module signature, got %v", name, err)
+		}
+		// The kernel rejects module signatures with authenticated attributes
+		if len(p7.Signers) != 1 || p7.Signers[0].SignedAttributes != nil {
+			t.Errorf("%s: expected one signer without signed attributes, got %+v", name, p7.Signers)
+		}
+	}
+
+	if _, _, err := signers["rsa"].SignImage([]byte("plain data")); err == nil {
+		t.Errorf("Expected error for unknown containers")
+	}
+	if _, _, err := (&Signer{Key: signers["rsa"].Key, Certificates: signers["rsa"].Certificates, Hash: "md5"}).SignImage(module); err == nil {
+		t.Errorf("Expected error for an unsupported hash")
+	}
+}
+
+// TestRunSignCommand tests signing from key files end to end
+func TestRunSignCommand(t *testing.T) {
+	ts := newTestRSASigner(t)
+	dir := t.TempDir()
+	keyPath, certPath := writeTestKeyPair(t, dir, ts)
+	input := filepath.Join(dir, "test.efi")
+	os.WriteFile(input, buildTestPE(bytes.Repeat([]byte{0x90}, 64)), 0o644)
+
+	var status int
+	output := captureStdout(t, func() { status = runSignCommand([]string{"-key", keyPath, "-cert", certPath, input}) })
+	if status != 0 {
+		t.Fatalf("Expected exit status 0, got %d:\n%s", status, output)
+	}
+	if !strings.Contains(output, "✓ SIGNED") {
+		t.Errorf("Expected the output to be re-analysed as signed, got:\n%s", output)
+	}
+
+	other := newTestRSASigner(t)
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.cert.Raw}), 0o644)
+	output = captureStdout(t, func() { status = runSignCommand([]string{"-key", keyPath, "-cert", certPath, input}) })
+	if status != ExitMalformed || !strings.Contains(output, "does not match") {
+		t.Errorf("Expected a key mismatch error, got %d:\n%s", status, output)
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Signature containers handled by strip, attach and sign
+const (
+	ContainerPE     = "PE"
+	ContainerModule = "module"
+)
+
+// UnsignedSuffix is appended to the input name for the default strip output
+const UnsignedSuffix = ".unsigned"
+
+// Errors of images that carry no signature
+var (
+	errNoCertificateTable   = errors.New("PE image has no certificate table")
+	errNoSignatureContainer = errors.New("no PE certificate table or appended signature found")
+)
+
+// magicELF starts ELF files, the container of kernel modules
+var magicELF = []byte("\x7fELF")
+
+// detectContainer identifies the signature container of an image: PE, or
+// an ELF file or other file with an appended signature
+func detectContainer(data []byte) string {
+	switch {
+	case isPE(data):
+		return ContainerPE
+	case bytes.HasPrefix(data, magicELF), hasModuleSignature(data):
+		return ContainerModule
+	}
+	return ""
+}
+
+// stripSignature removes the signature from a PE image or an image with an
+// appended signature, describing what was removed
+func stripSignature(data []byte) ([]byte, string, error) {
+	switch detectContainer(data) {
+	case ContainerPE:
+		pe, err := ParsePE(data)
+		if err != nil {
+			return nil, "", err
+		}
+		if !pe.Signed() {
+			return nil, "", errNoCertificateTable
+		}
+		out := pe.Strip()
+		return out, fmt.Sprintf("removed %d-byte certificate table at offset %d, checksum %#08x → %#08x",
+			pe.CertTableSize, pe.CertTableOffset, pe.Checksum(), binary.LittleEndian.Uint32(out[pe.ChecksumOffset:])), nil
+	case ContainerModule:
+		sig, err := ParseModuleSignature(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return data[:sig.Offset], fmt.Sprintf("removed %d-byte appended signature at offset %d", len(data)-sig.Offset, sig.Offset), nil
+	}
+	return nil, "", errNoSignatureContainer
+}
+
+// runStripCommand implements "autograph-pls strip", returning the exit status
+func runStripCommand(args []string) int {
+	fs := flag.NewFlagSet("strip", flag.ContinueOnError)
+	output := fs.String("o", "", "output `file` (default: <file>"+UnsignedSuffix+")")
+	maxSize := byteSize(DefaultMaxInputSize)
+	fs.Var(&maxSize, "max-size", "read at most `bytes` (suffix K, M or G) from stdin and pipes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s strip [options] <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nRemoves the signature and writes the unsigned image: PE/EFI files lose their\n")
+		fmt.Fprintf(os.Stderr, "WIN_CERTIFICATE table, security directory entry and stale checksum; kernel\n")
+		fmt.Fprintf(os.Stderr, "modules and ELF images lose their appended signature trailer.\n")
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s strip grubx64.efi                 # Write grubx64.efi%s\n", os.Args[0], UnsignedSuffix)
+		fmt.Fprintf(os.Stderr, "  %s strip -o plain.ko module.ko       # Write the unsigned module to plain.ko\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	path := fs.Arg(0)
+	if *output == "" {
+		if path == StdinPath {
+			fmt.Printf("Error: -o is required when reading stdin\n")
+			return ExitUsage
+		}
+		*output = path + UnsignedSuffix
+	}
+
+	fh := FileHandler{MaxSize: int64(maxSize)}
+	input, err := fh.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+	defer input.Close()
+
+	unsigned, description, err := stripSignature(input.Bytes())
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(path), err)
+		return errorExitCode(err)
+	}
+	if err := fh.SaveToFile(unsigned, *output); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitIOError
+	}
+	fmt.Printf("%s: %s\n", inputName(path), description)
+	fmt.Printf("Unsigned image written to: %s (%d bytes)\n", *output, len(unsigned))
+	return ExitValid
+}
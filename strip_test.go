@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildTestModule appends a PKCS#7 module_signature trailer to content
+func buildTestModule(content, signature []byte) []byte {
+	module := append(append([]byte{}, content...), signature...)
+	module = append(module, 0, 0, moduleSigIDPKCS7, 0, 0, 0, 0, 0)
+	module = binary.BigEndian.AppendUint32(module, uint32(len(signature)))
+	return append(module, ModuleSignatureMagic...)
+}
+
+// captureStdout runs fn with os.Stdout redirected and returns the output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	done := make(chan bool)
+	go func() {
+		buf.ReadFrom(r)
+		done <- true
+	}()
+
+	fn()
+
+	w.Close()
+	os.Stdout = oldStdout
+	<-done
+	return buf.String()
+}
+
+// TestParseModuleSignature tests the appended signature trailer
+func TestParseModuleSignature(t *testing.T) {
+	content := []byte("\x7fELF module content")
+	signature := []byte{0x30, 0x03, 0x02, 0x01, 0x01}
+	sig, err := ParseModuleSignature(buildTestModule(content, signature))
+	if err != nil {
+		t.Fatalf("ParseModuleSignature failed: %v", err)
+	}
+	if sig.Offset != len(content) || sig.IDType != moduleSigIDPKCS7 || !bytes.Equal(sig.Signature, signature) {
+		t.Errorf("Unexpected trailer %+v", sig)
+	}
+
+	oversized := buildTestModule(nil, signature)
+	binary.BigEndian.PutUint32(oversized[len(oversized)-len(ModuleSignatureMagic)-4:], 1000)
+	for name, data := range map[string][]byte{
+		"no magic":  content,
+		"truncated": []byte(ModuleSignatureMagic),
+		"oversized": oversized,
+	} {
+		if _, err := ParseModuleSignature(data); err == nil {
+			t.Errorf("Expected error for %s trailer", name)
+		}
+	}
+}
+
+// TestStripSignature tests stripping PE and appended signatures
+func TestStripSignature(t *testing.T) {
+	content := []byte("\x7fELF module content")
+	unsigned, description, err := stripSignature(buildTestModule(content, []byte{0x30, 0x00}))
+	if err != nil || !bytes.Equal(unsigned, content) || !strings.Contains(description, "appended signature") {
+		t.Errorf("Expected module content back, got %q (%s, %v)", unsigned, description, err)
+	}
+
+	image := buildTestPE(bytes.Repeat([]byte{0xCC}, 96))
+	unsigned, description, err = stripSignature(appendTestCertTable(image, []byte{0x30, 0x00}))
+	if err != nil || !bytes.Equal(unsigned, image) || !strings.Contains(description, "certificate table") {
+		t.Errorf("Expected PE image back, got %d bytes (%s, %v)", len(unsigned), description, err)
+	}
+
+	if _, _, err := stripSignature(image); err == nil {
+		t.Errorf("Expected error for unsigned PE image")
+	}
+	if _, _, err := stripSignature(content); err == nil {
+		t.Errorf("Expected error for data without a signature container")
+	}
+}
+
+// TestRunStripCommand tests the strip command end to end
+func TestRunStripCommand(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("\x7fELF module content")
+	input := filepath.Join(dir, "module.ko")
+	if err := os.WriteFile(input, buildTestModule(content, []byte{0x30, 0x00}), 0o644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+
+	var status int
+	captureStdout(t, func() { status = runStripCommand([]string{input}) })
+	if status != 0 {
+		t.Fatalf("Expected exit status 0, got %d", status)
+	}
+	if stripped, _ := os.ReadFile(input + UnsignedSuffix); !bytes.Equal(stripped, content) {
+		t.Errorf("Expected unsigned module in %s, got %q", input+UnsignedSuffix, stripped)
+	}
+
+	captureStdout(t, func() { status = runStripCommand([]string{input + UnsignedSuffix}) })
+	if status != ExitUnsigned {
+		t.Errorf("Expected exit status %d for an unsigned input, got %d", ExitUnsigned, status)
+	}
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// errNoTerminal is returned where raw terminal mode is not implemented
+var errNoTerminal = errors.New("raw terminal mode is not supported on this platform")
+
+// makeRaw is not available on this platform
+func makeRaw(fd int) (func() error, error) {
+	return nil, errNoTerminal
+}
+
+// terminalSize is not available on this platform
+func terminalSize(fd int) (int, int, error) {
+	return 0, 0, errNoTerminal
+}
+
+// notifyResize does nothing on this platform
+func notifyResize(ch chan<- os.Signal) {}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// ioctl issues a terminal ioctl with a pointer argument
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw switches the terminal on fd to raw mode, like cfmakeraw, and
+// returns a function restoring the old mode. It fails when fd is not a
+// terminal.
+func makeRaw(fd int) (func() error, error) {
+	var old syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&old))
+	}, nil
+}
+
+// terminalSize returns the columns and rows of the terminal on fd
+func terminalSize(fd int) (int, int, error) {
+	var size struct {
+		Rows, Cols, XPixel, YPixel uint16
+	}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil {
+		return 0, 0, err
+	}
+	return int(size.Cols), int(size.Rows), nil
+}
+
+// notifyResize delivers a signal on ch whenever the terminal is resized
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
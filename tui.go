@@ -0,0 +1,604 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Browser layout defaults, used when the terminal size is unknown
+const (
+	browserDefaultWidth  = 80
+	browserDefaultHeight = 24
+	// browserCollapseDepth is the depth from which constructed elements start
+	// collapsed, so a SignedData opens with its certificates folded
+	browserCollapseDepth = 4
+)
+
+// ANSI sequences used by the browser
+const (
+	ansiReverse   = "\x1b[7m"
+	ansiHeader    = "\x1b[1;4m"
+	ansiReset     = "\x1b[0m"
+	ansiClearLine = "\x1b[K"
+)
+
+// browserEntry is an element of the tree in pre-order
+type browserEntry struct {
+	node   *ASN1Node
+	depth  int
+	parent int // index of the enclosing element, -1 at the top level
+	path   string
+}
+
+// Browser is the state of the interactive ASN.1 browser: the element tree
+// with folding and selection, and a hex pane following the selection
+type Browser struct {
+	Name      string
+	data      []byte
+	base      int
+	entries   []browserEntry
+	collapsed []bool
+	selected  int
+	top       int
+	// Width and Height are the terminal size the next Render fills
+	Width  int
+	Height int
+
+	searching bool
+	query     string
+	message   string
+}
+
+// NewBrowser builds a browser for the structure data found at offset base
+// of the file called name
+func NewBrowser(name string, data []byte, base int) (*Browser, error) {
+	nodes, err := parseASN1Tree(data, base, 0)
+	if err != nil {
+		return nil, err
+	}
+	b := &Browser{Name: name, data: data, base: base, Width: browserDefaultWidth, Height: browserDefaultHeight}
+	b.addEntries(nodes, 0, -1, "")
+	for i, entry := range b.entries {
+		b.collapsed[i] = entry.depth >= browserCollapseDepth && len(entry.node.Children) > 0
+	}
+	return b, nil
+}
+
+func (b *Browser) addEntries(nodes []*ASN1Node, depth, parent int, path string) {
+	for i, node := range nodes {
+		childPath := strconv.Itoa(i)
+		if path != "" {
+			childPath = path + "." + childPath
+		}
+		b.entries = append(b.entries, browserEntry{node: node, depth: depth, parent: parent, path: childPath})
+		b.collapsed = append(b.collapsed, false)
+		b.addEntries(node.Children, depth+1, len(b.entries)-1, childPath)
+	}
+}
+
+// hidden reports whether an ancestor of entry i is collapsed
+func (b *Browser) hidden(i int) bool {
+	for p := b.entries[i].parent; p >= 0; p = b.entries[p].parent {
+		if b.collapsed[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns the indices of the entries shown in the tree pane
+func (b *Browser) visible() []int {
+	var rows []int
+	for i := 0; i < len(b.entries); i++ {
+		rows = append(rows, i)
+		if b.collapsed[i] {
+			// Skip the subtree
+			for i+1 < len(b.entries) && b.entries[i+1].depth > b.entries[i].depth {
+				i++
+			}
+		}
+	}
+	return rows
+}
+
+// layout returns the heights of the tree and hex panes and the bytes per
+// hex row for the current size
+func (b *Browser) layout() (treeRows, hexRows, bytesPerRow int) {
+	hexRows = 8
+	if b.Height < 20 {
+		hexRows = max(2, b.Height/4)
+	}
+	bytesPerRow = 16
+	if b.Width < 78 {
+		bytesPerRow = 8
+	}
+	// Title, separator and status lines
+	return max(1, b.Height-hexRows-3), hexRows, bytesPerRow
+}
+
+// HandleKey applies a key as returned by readKey and reports whether the
+// browser should quit
+func (b *Browser) HandleKey(key string) bool {
+	if b.searching {
+		switch key {
+		case "enter":
+			b.searching = false
+			b.search(1)
+		case "esc":
+			b.searching = false
+		case "backspace":
+			if _, size := utf8.DecodeLastRuneInString(b.query); size > 0 {
+				b.query = b.query[:len(b.query)-size]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				b.query += key
+			}
+		}
+		return false
+	}
+
+	b.message = ""
+	rows := b.visible()
+	row := 0
+	for i, entry := range rows {
+		if entry == b.selected {
+			row = i
+		}
+	}
+	treeRows, _, _ := b.layout()
+	entry := b.entries[b.selected]
+	hasChildren := len(entry.node.Children) > 0
+
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		b.selected = rows[max(0, row-1)]
+	case "down", "j":
+		b.selected = rows[min(len(rows)-1, row+1)]
+	case "pgup":
+		b.selected = rows[max(0, row-treeRows)]
+	case "pgdn":
+		b.selected = rows[min(len(rows)-1, row+treeRows)]
+	case "home", "g":
+		b.selected = rows[0]
+	case "end", "G":
+		b.selected = rows[len(rows)-1]
+	case "left", "h":
+		if hasChildren && !b.collapsed[b.selected] {
+			b.collapsed[b.selected] = true
+		} else if entry.parent >= 0 {
+			b.selected = entry.parent
+		}
+	case "right", "l":
+		if b.collapsed[b.selected] {
+			b.collapsed[b.selected] = false
+		} else if hasChildren {
+			b.selected++
+		}
+	case "enter", " ":
+		if hasChildren {
+			b.collapsed[b.selected] = !b.collapsed[b.selected]
+		}
+	case "e":
+		clear(b.collapsed)
+	case "c":
+		for i, entry := range b.entries {
+			b.collapsed[i] = entry.depth > 0 && len(entry.node.Children) > 0
+		}
+		for b.hidden(b.selected) {
+			b.selected = b.entries[b.selected].parent
+		}
+	case "/":
+		b.searching, b.query = true, ""
+	case "n":
+		b.search(1)
+	case "N":
+		b.search(-1)
+	}
+	return false
+}
+
+// search selects the next (dir 1) or previous (dir -1) element after the
+// selection whose tag, content or path contains the query, ignoring case,
+// and unfolds its ancestors
+func (b *Browser) search(dir int) {
+	if b.query == "" {
+		return
+	}
+	query := strings.ToLower(b.query)
+	var matches []int
+	for i, entry := range b.entries {
+		if strings.Contains(strings.ToLower(entry.node.describe()+" "+entry.path), query) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		b.message = fmt.Sprintf("no match for %q", b.query)
+		return
+	}
+
+	pick := 0
+	if dir > 0 {
+		for pick < len(matches) && matches[pick] <= b.selected {
+			pick++
+		}
+		pick %= len(matches)
+	} else {
+		pick = len(matches) - 1
+		for pick >= 0 && matches[pick] >= b.selected {
+			pick--
+		}
+		if pick < 0 {
+			pick = len(matches) - 1
+		}
+	}
+	b.selected = matches[pick]
+	for p := b.entries[b.selected].parent; p >= 0; p = b.entries[p].parent {
+		b.collapsed[p] = false
+	}
+	b.message = fmt.Sprintf("match %d of %d for %q", pick+1, len(matches), b.query)
+}
+
+// Render returns the screen as Height lines for a Width-column terminal
+func (b *Browser) Render() []string {
+	treeRows, hexRows, bytesPerRow := b.layout()
+	rows := b.visible()
+	row := 0
+	for i, entry := range rows {
+		if entry == b.selected {
+			row = i
+		}
+	}
+	if row < b.top {
+		b.top = row
+	}
+	if row >= b.top+treeRows {
+		b.top = row - treeRows + 1
+	}
+	b.top = min(b.top, max(0, len(rows)-treeRows))
+
+	lines := []string{ansiReverse + padRunes(fmt.Sprintf(" %s: %d bytes at offset %d", b.Name, len(b.data), b.base), b.Width) + ansiReset}
+	for i := b.top; i < b.top+treeRows; i++ {
+		if i >= len(rows) {
+			lines = append(lines, "")
+			continue
+		}
+		entry := b.entries[rows[i]]
+		marker := "  "
+		if len(entry.node.Children) > 0 {
+			marker = "▾ "
+			if b.collapsed[rows[i]] {
+				marker = "▸ "
+			}
+		}
+		text := fmt.Sprintf("%7d %s%s%s", entry.node.Offset, strings.Repeat("  ", entry.depth), marker, entry.node.describe())
+		if rows[i] == b.selected {
+			lines = append(lines, ansiReverse+padRunes(text, b.Width)+ansiReset)
+		} else {
+			lines = append(lines, truncateRunes(text, b.Width))
+		}
+	}
+
+	node := b.entries[b.selected].node
+	info := fmt.Sprintf("── offset %d  hl=%d  l=%d  path %s ", node.Offset, node.HeaderLen, node.Length, b.entries[b.selected].path)
+	lines = append(lines, truncateRunes(info+strings.Repeat("─", max(0, b.Width-utf8.RuneCountInString(info))), b.Width))
+	lines = append(lines, b.hexPane(node, hexRows, bytesPerRow)...)
+
+	switch {
+	case b.searching:
+		lines = append(lines, truncateRunes("/"+b.query+"█", b.Width))
+	case b.message != "":
+		lines = append(lines, truncateRunes(b.message, b.Width))
+	default:
+		lines = append(lines, truncateRunes("↑↓ move  ←→ fold  ⏎ toggle  e/c expand/collapse all  / search  n/N next/prev  q quit", b.Width))
+	}
+	return lines
+}
+
+// hexPane dumps rows of data from the row holding the element's first
+// byte, underlining its header and highlighting its content
+func (b *Browser) hexPane(node *ASN1Node, rows, bytesPerRow int) []string {
+	start := node.Offset - b.base
+	headerEnd := start + node.HeaderLen
+	end := start + len(node.Raw)
+
+	var lines []string
+	for r := 0; r < rows; r++ {
+		from := (start/bytesPerRow + r) * bytesPerRow
+		if from >= len(b.data) {
+			lines = append(lines, "")
+			continue
+		}
+		var hexPart, textPart strings.Builder
+		for i := from; i < from+bytesPerRow; i++ {
+			if i >= len(b.data) {
+				hexPart.WriteString("   ")
+				continue
+			}
+			style := ""
+			switch {
+			case i >= start && i < headerEnd:
+				style = ansiHeader
+			case i >= headerEnd && i < end:
+				style = ansiReverse
+			}
+			c := b.data[i]
+			char := "."
+			if c >= 0x20 && c < 0x7F {
+				char = string(rune(c))
+			}
+			if style != "" {
+				fmt.Fprintf(&hexPart, "%s%02x%s ", style, c, ansiReset)
+				textPart.WriteString(style + char + ansiReset)
+			} else {
+				fmt.Fprintf(&hexPart, "%02x ", c)
+				textPart.WriteString(char)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%08x  %s |%s|", b.base+from, hexPart.String(), textPart.String()))
+	}
+	return lines
+}
+
+// truncateRunes cuts s to at most width runes
+func truncateRunes(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:max(0, width)])
+}
+
+// padRunes cuts or pads s to exactly width runes
+func padRunes(s string, width int) string {
+	s = truncateRunes(s, width)
+	return s + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(s)))
+}
+
+// terminalDevice is the controlling terminal, which "browse -" reads keys
+// from since stdin holds the input
+const terminalDevice = "/dev/tty"
+
+// escapeTimeout is how long readKey waits for the rest of an escape
+// sequence, which can arrive in pieces over ssh or a slow serial line
+const escapeTimeout = 100 * time.Millisecond
+
+// keyReader hands out the bytes of a terminal read in the background, so
+// that readKey can wait for the next one with a timeout
+type keyReader struct {
+	bytes chan byte
+	// err is the read error, set before bytes is closed
+	err error
+}
+
+// newKeyReader starts reading r in the background
+func newKeyReader(r io.Reader) *keyReader {
+	k := &keyReader{bytes: make(chan byte, 64)}
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Read(buf)
+			for _, c := range buf[:n] {
+				k.bytes <- c
+			}
+			if err != nil {
+				k.err = err
+				close(k.bytes)
+				return
+			}
+		}
+	}()
+	return k
+}
+
+// next returns the next byte, waiting at most timeout unless it is zero;
+// ok is false when the timeout expires
+func (k *keyReader) next(timeout time.Duration) (c byte, ok bool, err error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case c, open := <-k.bytes:
+		if !open {
+			return 0, false, k.err
+		}
+		return c, true, nil
+	case <-expired:
+		return 0, false, nil
+	}
+}
+
+// readKey reads one key press from a raw terminal: a single character, or
+// the name of an editing or cursor key. An escape not followed by the rest
+// of a sequence within escapeTimeout is the Esc key; unknown escape
+// sequences return "".
+func readKey(k *keyReader) (string, error) {
+	c, _, err := k.next(0)
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case 0x1B:
+		if next, ok, _ := k.next(escapeTimeout); !ok || (next != '[' && next != 'O') {
+			return "esc", nil
+		}
+		var seq []byte
+		for {
+			c, ok, _ := k.next(escapeTimeout)
+			if !ok {
+				break
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7E {
+				break
+			}
+		}
+		switch string(seq) {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "C":
+			return "right", nil
+		case "D":
+			return "left", nil
+		case "H", "1~", "7~":
+			return "home", nil
+		case "F", "4~", "8~":
+			return "end", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdn", nil
+		}
+		return "", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7F, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	}
+
+	// Collect the continuation bytes of a multi-byte UTF-8 character
+	char := []byte{c}
+	for !utf8.FullRune(char) {
+		c, ok, err := k.next(escapeTimeout)
+		if !ok || err != nil {
+			break
+		}
+		char = append(char, c)
+	}
+	r, _ := utf8.DecodeRune(char)
+	return string(r), nil
+}
+
+// runBrowser runs the browser on a terminal until the user quits
+func runBrowser(in, out *os.File, b *Browser) error {
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("browse needs an interactive terminal: %w", err)
+	}
+	defer restore()
+	// Alternate screen without cursor, restored on exit
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		reader := newKeyReader(in)
+		for {
+			key, err := readKey(reader)
+			if err != nil {
+				errs <- err
+				return
+			}
+			keys <- key
+		}
+	}()
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	for {
+		if width, height, err := terminalSize(int(out.Fd())); err == nil && width > 0 && height > 0 {
+			b.Width, b.Height = width, height
+		}
+		fmt.Fprint(out, "\x1b[H"+strings.Join(b.Render(), ansiClearLine+"\r\n")+ansiClearLine+"\x1b[J")
+
+		select {
+		case key := <-keys:
+			if b.HandleKey(key) {
+				return nil
+			}
+		case <-resize:
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// runBrowseCommand implements "autograph-pls browse", returning the exit status
+func runBrowseCommand(args []string) int {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s browse <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOpens an interactive tree of the signature in a file (or of DER, PEM or base64\n")
+		fmt.Fprintf(os.Stderr, "input) in the terminal, with a hex pane following the selected element. With\n")
+		fmt.Fprintf(os.Stderr, "\"-\" the input is read from stdin and keys from %s.\n", terminalDevice)
+		fmt.Fprintf(os.Stderr, "\nKEYS:\n")
+		fmt.Fprintf(os.Stderr, "  ↑/↓ k/j        move              PgUp/PgDn Home/End g/G   scroll\n")
+		fmt.Fprintf(os.Stderr, "  ← h            fold or go to parent    → l   unfold or enter\n")
+		fmt.Fprintf(os.Stderr, "  Enter/Space    toggle folding    e/c   expand/collapse all\n")
+		fmt.Fprintf(os.Stderr, "  /text Enter    search tags, values (OID names) and paths   n/N   next/previous match\n")
+		fmt.Fprintf(os.Stderr, "  q Ctrl-C       quit\n")
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  %s browse shimx64.efi\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s browse signature.pem\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	path := fs.Arg(0)
+	input, err := FileHandler{}.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errorExitCode(err)
+	}
+	defer input.Close()
+	data, _, err := decompressLayers(input.Bytes(), DefaultMaxInputSize)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitMalformed
+	}
+	der, offset, err := locateStructure(data)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(path), err)
+		return locateExitCode(data, err)
+	}
+	browser, err := NewBrowser(inputName(path), der, offset)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", inputName(path), err)
+		return ExitMalformed
+	}
+	// Keys come from the terminal when the input took up stdin
+	keys := os.Stdin
+	if path == StdinPath {
+		if keys, err = os.Open(terminalDevice); err != nil {
+			fmt.Printf("Error: browse - reads keys from the terminal: %v\n", err)
+			return ExitIOError
+		}
+		defer keys.Close()
+	}
+	if err := runBrowser(keys, os.Stdout, browser); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return ExitIOError
+	}
+	return ExitValid
+}
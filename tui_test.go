@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBrowserDER is SEQUENCE { OID signedData, [0] { SEQUENCE { SEQUENCE { SEQUENCE { INTEGER 5 } } } } }
+var testBrowserDER = []byte{
+	0x30, 0x18,
+	0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x02,
+	0xA0, 0x0B, 0x30, 0x09, 0x30, 0x07, 0x30, 0x05, 0x30, 0x03, 0x02, 0x01, 0x05,
+}
+
+// TestBrowserNavigation tests moving, folding and searching
+func TestBrowserNavigation(t *testing.T) {
+	b, err := NewBrowser("test.der", testBrowserDER[:10], 0)
+	if err == nil {
+		t.Fatalf("Expected error for truncated input")
+	}
+	b, err = NewBrowser("test.der", testBrowserDER, 0)
+	if err != nil {
+		t.Fatalf("NewBrowser failed: %v", err)
+	}
+	// The SEQUENCE at depth 4 starts collapsed, hiding the INTEGER
+	if rows := b.visible(); len(rows) != 6 {
+		t.Fatalf("Expected 6 visible rows, got %d", len(rows))
+	}
+
+	b.HandleKey("down")
+	if b.selected != 1 || !strings.Contains(b.entries[1].node.describe(), "signedData") {
+		t.Errorf("Expected the OID with its name selected, got %d %q", b.selected, b.entries[b.selected].node.describe())
+	}
+	b.HandleKey("down")
+	b.HandleKey("left")
+	if !b.collapsed[2] || len(b.visible()) != 3 {
+		t.Errorf("Expected [0] folded, got %d rows", len(b.visible()))
+	}
+	b.HandleKey("left")
+	if b.selected != 0 {
+		t.Errorf("Expected left on a folded element to select the parent, got %d", b.selected)
+	}
+	b.HandleKey("end")
+	if b.selected != 2 {
+		t.Errorf("Expected end to select the last visible row, got %d", b.selected)
+	}
+	b.HandleKey("right")
+	b.HandleKey("right")
+	if b.collapsed[2] || b.selected != 3 {
+		t.Errorf("Expected right to unfold and then enter, got %d", b.selected)
+	}
+
+	for _, key := range []string{"/", "I", "N", "T", "enter"} {
+		b.HandleKey(key)
+	}
+	if b.selected != 7 || b.hidden(7) {
+		t.Errorf("Expected the folded INTEGER found and shown, got %d", b.selected)
+	}
+	if b.message != `match 1 of 1 for "INT"` {
+		t.Errorf("Unexpected search message %q", b.message)
+	}
+	for _, key := range []string{"/", "x", "backspace", "z", "enter"} {
+		b.HandleKey(key)
+	}
+	if b.selected != 7 || !strings.HasPrefix(b.message, "no match") {
+		t.Errorf("Expected no match to keep the selection, got %d %q", b.selected, b.message)
+	}
+
+	b.HandleKey("c")
+	if len(b.visible()) != 3 || b.selected != 2 {
+		t.Errorf("Expected collapse all to select the visible ancestor, got %d rows", len(b.visible()))
+	}
+	b.HandleKey("e")
+	if len(b.visible()) != len(b.entries) {
+		t.Errorf("Expected expand all to show every element")
+	}
+	if !b.HandleKey("q") {
+		t.Errorf("Expected q to quit")
+	}
+}
+
+// TestBrowserRender tests the screen layout and hex pane
+func TestBrowserRender(t *testing.T) {
+	b, err := NewBrowser("test.der", testBrowserDER, 100)
+	if err != nil {
+		t.Fatalf("NewBrowser failed: %v", err)
+	}
+	b.Width, b.Height = 80, 12
+	b.HandleKey("down")
+	lines := b.Render()
+	if len(lines) != b.Height {
+		t.Fatalf("Expected %d lines, got %d", b.Height, len(lines))
+	}
+	if !strings.Contains(lines[0], "test.der: 26 bytes at offset 100") {
+		t.Errorf("Unexpected title %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], ansiReverse+"    102     OBJECT IDENTIFIER 1.2.840.113549.1.7.2 (pkcs7-signedData)") {
+		t.Errorf("Expected the selected OID highlighted, got %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[1], "    100 ▾ SEQUENCE") || !strings.Contains(lines[6], "▸ SEQUENCE") {
+		t.Errorf("Expected fold markers, got %q and %q", lines[1], lines[6])
+	}
+
+	// Six tree rows, the separator, then hex rows from the row holding offset 102
+	if !strings.Contains(lines[7], "offset 102  hl=2  l=9  path 0.0") {
+		t.Errorf("Unexpected separator %q", lines[7])
+	}
+	if !strings.HasPrefix(lines[8], "00000064  30 18 "+ansiHeader+"06"+ansiReset+" "+ansiHeader+"09"+ansiReset+" "+ansiReverse+"2a") {
+		t.Errorf("Unexpected hex row %q", lines[8])
+	}
+	if !strings.HasPrefix(lines[9], "00000074  ") || lines[10] != "" {
+		t.Errorf("Expected a second hex row at 0x74 and the end of the data, got %q", lines[9:11])
+	}
+
+	b.Width = 40
+	for _, line := range b.Render() {
+		if strings.HasPrefix(line, "00000064  ") && strings.Count(line, " ") > 40 {
+			t.Errorf("Expected 8 bytes per row on a narrow terminal, got %q", line)
+		}
+	}
+}
+
+// TestReadKey tests decoding of terminal input
+func TestReadKey(t *testing.T) {
+	input := "j\x1b[A\x1b[6~\x1bOH\r\x7f\x03é\x1b[99X"
+	expected := []string{"j", "up", "pgdn", "home", "enter", "backspace", "ctrl-c", "é", ""}
+	r := newKeyReader(strings.NewReader(input))
+	for _, want := range expected {
+		got, err := readKey(r)
+		if err != nil {
+			t.Fatalf("readKey failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+	if _, err := readKey(r); err == nil {
+		t.Errorf("Expected EOF")
+	}
+}
+
+// TestReadKeySlowTerminal tests escape sequences arriving in pieces
+func TestReadKeySlowTerminal(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		for _, chunk := range []string{"\x1b", "[", "B", "\x1b"} {
+			pw.Write([]byte(chunk))
+			time.Sleep(escapeTimeout / 4)
+		}
+		// A lone escape is the Esc key once the timeout expires
+		time.Sleep(2 * escapeTimeout)
+		pw.Write([]byte("q"))
+		pw.Close()
+	}()
+
+	r := newKeyReader(pr)
+	for _, want := range []string{"down", "esc", "q"} {
+		if got, err := readKey(r); err != nil || got != want {
+			t.Errorf("Expected %q, got %q (%v)", want, got, err)
+		}
+	}
+}
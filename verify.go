@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// checkSignature verifies the signers of p7 against what its container
+// signs: the Authenticode digest of a PE image, the module in front of an
+// appended signature, or the encapsulated content. It returns what was
+// checked and the outcome, or "" and the reason nothing could be checked.
+func checkSignature(data []byte, p7 *PKCS7) (string, error) {
+	if len(p7.Certificates) == 0 {
+		return "", errors.New("the SignedData carries no certificates")
+	}
+	if pe, err := ParsePE(data); err == nil && pe.Signed() {
+		if err := p7.VerifyEmbedded(); err != nil {
+			return "Authenticode signature", err
+		}
+		var content spcIndirectDataContent
+		if _, err := asn1.Unmarshal(p7.Content, &content); err != nil {
+			return "Authenticode signature", fmt.Errorf("invalid SpcIndirectDataContent: %w", err)
+		}
+		hash, ok := digestAlgorithms[content.MessageDigest.Algorithm.Algorithm.String()]
+		if !ok || !hash.Available() {
+			return "Authenticode signature", fmt.Errorf("%w %s", ErrPKCS7UnsupportedDigest, content.MessageDigest.Algorithm.Algorithm)
+		}
+		if digest := authenticodeDigest(pe, hash); !bytes.Equal(digest, content.MessageDigest.Digest) {
+			return "Authenticode signature", errors.New("image digest does not match the signed digest")
+		}
+		return "Authenticode signature and image digest", nil
+	}
+	if sig, err := ParseModuleSignature(data); err == nil {
+		return "module signature over the module content", p7.Verify(data[:sig.Offset])
+	}
+	if p7.Content != nil {
+		return "signature over the encapsulated content", p7.VerifyEmbedded()
+	}
+	return "", errors.New("the signed content is detached")
+}
+
+// embeddedPKCS7 parses the signature of a PE image's first WIN_CERTIFICATE
+// or of a module's appended signature
+func embeddedPKCS7(data []byte) (*PKCS7, error) {
+	if pe, err := ParsePE(data); err == nil {
+		certs, err := pe.Certificates()
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) == 0 {
+			return nil, errNoCertificateTable
+		}
+		return ParsePKCS7(certs[0].Data)
+	}
+	sig, err := ParseModuleSignature(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePKCS7(sig.Signature)
+}